@@ -0,0 +1,99 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"golang-observer-project/internal/models"
+	"log"
+	"net/http"
+	"time"
+)
+
+// agent pushes the metrics of the host to the observer
+type agent struct {
+	observerURL string
+	token       string
+	interval    time.Duration
+	collector   *collector
+	client      *http.Client
+}
+
+func newAgent(observerURL, token string, interval time.Duration, c *collector) *agent {
+	return &agent{
+		observerURL: observerURL,
+		token:       token,
+		interval:    interval,
+		collector:   c,
+		client:      &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// run reports the metrics every interval; the cpu usage is measured over the last interval
+func (a *agent) run() {
+	err := a.collector.sampleCPU()
+	if err != nil {
+		log.Println("cannot read cpu usage:", err)
+	}
+
+	for {
+		time.Sleep(a.interval)
+
+		metrics, err := a.collector.collect()
+		if err != nil {
+			log.Println("cannot read metrics:", err)
+			continue
+		}
+
+		err = a.report(metrics)
+		if err != nil {
+			log.Println("cannot report metrics:", err)
+		}
+	}
+}
+
+// report posts metrics to the observer
+func (a *agent) report(metrics models.HostMetrics) error {
+	body, err := json.Marshal(models.HostMetricsRequest{
+		IntervalSeconds: int(a.interval / time.Second),
+		Metrics:         metrics,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, a.observerURL+"/agent/metrics", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+a.token)
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer func(resp *http.Response) {
+		_ = resp.Body.Close()
+	}(resp)
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("POST /agent/metrics: %s", resp.Status)
+	}
+
+	var answer struct {
+		OK      bool   `json:"ok"`
+		Message string `json:"message"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&answer)
+	if err != nil {
+		return err
+	}
+
+	if !answer.OK {
+		return fmt.Errorf("metrics not accepted: %s", answer.Message)
+	}
+
+	return nil
+}
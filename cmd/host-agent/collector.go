@@ -0,0 +1,196 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"golang-observer-project/internal/models"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cpuTimes are the busy and total cpu time since boot, in clock ticks
+type cpuTimes struct {
+	busy  uint64
+	total uint64
+}
+
+// collector reads the metrics of the host from the proc file system and statfs
+type collector struct {
+	procDir string
+	disks   []string
+	lastCPU cpuTimes
+}
+
+func newCollector(procDir string, disks []string) *collector {
+	return &collector{procDir: procDir, disks: disks}
+}
+
+// sampleCPU remembers the cpu times, so the next collect measures the usage since now
+func (c *collector) sampleCPU() error {
+	times, _, err := c.readStat()
+	if err != nil {
+		return err
+	}
+
+	c.lastCPU = times
+
+	return nil
+}
+
+// collect reads all metrics
+func (c *collector) collect() (models.HostMetrics, error) {
+	var m models.HostMetrics
+	m.CollectedAt = time.Now()
+
+	times, cpus, err := c.readStat()
+	if err != nil {
+		return m, err
+	}
+	m.CPUs = cpus
+	if times.total > c.lastCPU.total {
+		m.CPUPercent = float64(times.busy-c.lastCPU.busy) / float64(times.total-c.lastCPU.total) * 100
+	}
+	c.lastCPU = times
+
+	meminfo, err := c.readMeminfo()
+	if err != nil {
+		return m, err
+	}
+	m.MemoryTotalBytes = meminfo["MemTotal"]
+	m.MemoryUsedBytes = meminfo["MemTotal"] - meminfo["MemAvailable"]
+	if m.MemoryTotalBytes > 0 {
+		m.MemoryPercent = float64(m.MemoryUsedBytes) / float64(m.MemoryTotalBytes) * 100
+	}
+	m.SwapTotalBytes = meminfo["SwapTotal"]
+	m.SwapUsedBytes = meminfo["SwapTotal"] - meminfo["SwapFree"]
+
+	loads, err := c.readFloats("loadavg", 3)
+	if err != nil {
+		return m, err
+	}
+	m.Load1, m.Load5, m.Load15 = loads[0], loads[1], loads[2]
+
+	uptime, err := c.readFloats("uptime", 1)
+	if err != nil {
+		return m, err
+	}
+	m.UptimeSeconds = uptime[0]
+
+	for _, path := range c.disks {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+
+		d, err := diskUsage(path)
+		if err != nil {
+			return m, err
+		}
+		m.Disks = append(m.Disks, d)
+	}
+
+	return m, nil
+}
+
+// readStat reads the cpu times and the number of cpus from stat
+func (c *collector) readStat() (cpuTimes, int, error) {
+	var times cpuTimes
+	cpus := 0
+
+	f, err := os.Open(filepath.Join(c.procDir, "stat"))
+	if err != nil {
+		return times, 0, err
+	}
+	defer func(f *os.File) {
+		_ = f.Close()
+	}(f)
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || !strings.HasPrefix(fields[0], "cpu") {
+			continue
+		}
+
+		if fields[0] != "cpu" {
+			cpus++
+			continue
+		}
+
+		// user nice system idle iowait irq softirq steal; guest time is already in user
+		for i := 1; i < len(fields) && i <= 8; i++ {
+			v, err := strconv.ParseUint(fields[i], 10, 64)
+			if err != nil {
+				return times, 0, fmt.Errorf("stat: %w", err)
+			}
+			times.total += v
+			if i != 4 && i != 5 {
+				times.busy += v
+			}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return times, 0, err
+	}
+
+	return times, cpus, nil
+}
+
+// readMeminfo reads meminfo, in bytes
+func (c *collector) readMeminfo() (map[string]uint64, error) {
+	f, err := os.Open(filepath.Join(c.procDir, "meminfo"))
+	if err != nil {
+		return nil, err
+	}
+	defer func(f *os.File) {
+		_ = f.Close()
+	}(f)
+
+	meminfo := make(map[string]uint64)
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+
+		v, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		if len(fields) == 3 && fields[2] == "kB" {
+			v *= 1024
+		}
+		meminfo[strings.TrimSuffix(fields[0], ":")] = v
+	}
+
+	return meminfo, scanner.Err()
+}
+
+// readFloats reads the first n numbers of a proc file such as loadavg or uptime
+func (c *collector) readFloats(name string, n int) ([]float64, error) {
+	b, err := os.ReadFile(filepath.Join(c.procDir, name))
+	if err != nil {
+		return nil, err
+	}
+
+	fields := strings.Fields(string(b))
+	if len(fields) < n {
+		return nil, fmt.Errorf("%s: expected %d values, got %q", name, n, string(b))
+	}
+
+	values := make([]float64, n)
+	for i := range values {
+		values[i], err = strconv.ParseFloat(fields[i], 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+	}
+
+	return values, nil
+}
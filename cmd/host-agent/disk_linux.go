@@ -0,0 +1,33 @@
+//go:build linux
+
+package main
+
+import (
+	"golang-observer-project/internal/models"
+	"syscall"
+)
+
+// diskUsage reads the usage of the file system mounted at path; like df, the blocks reserved
+// for root do not count as available
+func diskUsage(path string) (models.DiskUsage, error) {
+	var fs syscall.Statfs_t
+	err := syscall.Statfs(path, &fs)
+	if err != nil {
+		return models.DiskUsage{}, err
+	}
+
+	bsize := uint64(fs.Bsize)
+	used := (fs.Blocks - fs.Bfree) * bsize
+	available := fs.Bavail * bsize
+
+	d := models.DiskUsage{
+		Path:       path,
+		TotalBytes: fs.Blocks * bsize,
+		UsedBytes:  used,
+	}
+	if used+available > 0 {
+		d.UsedPercent = float64(used) / float64(used+available) * 100
+	}
+
+	return d, nil
+}
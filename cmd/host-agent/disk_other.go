@@ -0,0 +1,13 @@
+//go:build !linux
+
+package main
+
+import (
+	"errors"
+	"golang-observer-project/internal/models"
+)
+
+// diskUsage is only read on Linux
+func diskUsage(path string) (models.DiskUsage, error) {
+	return models.DiskUsage{}, errors.New("disk usage of " + path + " is only read on Linux")
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// main runs a host agent: it reads the system metrics of the Linux host it runs on and pushes
+// them to the observer every interval
+func main() {
+	observerURL := flag.String("observer", "http://localhost:4000", "URL of the observer")
+	token := flag.String("token", "", "token of the System host service (GET /admin/host-service/{id}/agent)")
+	interval := flag.Duration("interval", time.Minute, "how often to report metrics")
+	disks := flag.String("disks", "/", "comma separated mount points to report the usage of")
+	procDir := flag.String("proc", "/proc", "proc file system to read, e.g. /host/proc in a container")
+
+	flag.Parse()
+
+	if *token == "" {
+		log.Fatal("-token is required")
+	}
+
+	if *interval < time.Second {
+		log.Fatal("-interval must be at least a second")
+	}
+
+	c := newCollector(*procDir, strings.Split(*disks, ","))
+	a := newAgent(*observerURL, *token, *interval, c)
+
+	log.Printf("Host agent reporting to %s every %s....", *observerURL, *interval)
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
+
+	go a.run()
+
+	<-stop
+	log.Println("Stopping host agent....")
+}
@@ -0,0 +1,235 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"golang-observer-project/internal/checks"
+	"golang-observer-project/internal/models"
+	"golang-observer-project/internal/scheduling"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// checkSpread spreads the checks of a probe like the observer does
+const checkSpread = time.Minute
+
+var errUnauthorized = errors.New("probe token rejected")
+
+// agent talks to the observer on behalf of the probe
+type agent struct {
+	observerURL string
+	name        string
+	location    string
+	key         string
+	pluginDir   string
+	client      *http.Client
+	scheduler   *scheduling.Manager
+
+	mu    sync.Mutex
+	token string
+}
+
+func newAgent(observerURL, name, location, key, pluginDir string, scheduler *scheduling.Manager) *agent {
+	return &agent{
+		observerURL: observerURL,
+		name:        name,
+		location:    location,
+		key:         key,
+		pluginDir:   pluginDir,
+		client:      &http.Client{Timeout: 10 * time.Second},
+		scheduler:   scheduler,
+	}
+}
+
+// run refreshes the assignments right away and then every interval
+func (a *agent) run(interval time.Duration) {
+	for {
+		err := a.refresh()
+		if err != nil {
+			log.Println("cannot refresh assignments:", err)
+		}
+
+		time.Sleep(interval)
+	}
+}
+
+// register gets a new token from the observer
+func (a *agent) register() error {
+	req := models.ProbeRegisterRequest{Name: a.name, Location: a.location, Key: a.key}
+
+	var resp models.ProbeRegisterResponse
+	err := a.send(http.MethodPost, "/probe/register", "", req, &resp)
+	if err != nil {
+		return err
+	}
+
+	a.mu.Lock()
+	a.token = resp.Token
+	a.mu.Unlock()
+
+	log.Printf("Registered as probe %d\n", resp.ProbeID)
+
+	return nil
+}
+
+// call calls the probe API, registering first when there is no token yet or it was rejected
+func (a *agent) call(method, path string, body, dst interface{}) error {
+	for attempt := 0; attempt < 2; attempt++ {
+		a.mu.Lock()
+		token := a.token
+		a.mu.Unlock()
+
+		if token == "" {
+			if err := a.register(); err != nil {
+				return err
+			}
+			continue
+		}
+
+		err := a.send(method, path, token, body, dst)
+		if !errors.Is(err, errUnauthorized) {
+			return err
+		}
+
+		a.mu.Lock()
+		a.token = ""
+		a.mu.Unlock()
+	}
+
+	return errUnauthorized
+}
+
+// send makes one request to the observer and decodes the JSON answer into dst
+func (a *agent) send(method, path, token string, body, dst interface{}) error {
+	var payload io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		payload = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequest(method, a.observerURL+path, payload)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer func(resp *http.Response) {
+		_ = resp.Body.Close()
+	}(resp)
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		return errUnauthorized
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s %s: %s", method, path, resp.Status)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(dst)
+}
+
+// refresh pulls the assigned host services and schedules, reschedules or removes their checks
+func (a *agent) refresh() error {
+	var resp models.ProbeAssignmentsResponse
+	err := a.call(http.MethodGet, "/probe/assignments", nil, &resp)
+	if err != nil {
+		return err
+	}
+
+	assigned := make(map[int]bool, len(resp.Assignments))
+
+	for _, assignment := range resp.Assignments {
+		hs := assignment.HostService
+		assigned[hs.ID] = true
+
+		key := scheduling.Key(hs) + " " + assignment.URL + " " + checkConfig(assignment)
+		if current, ok := a.scheduler.Key(hs.ID); ok && current == key {
+			continue
+		}
+
+		schedule, err := scheduling.New(hs, a.scheduler.Location())
+		if err != nil {
+			log.Printf("cannot schedule host service %d: %s\n", hs.ID, err)
+			continue
+		}
+
+		schedule = scheduling.Jitter(schedule, scheduling.Offset(hs.ID, checkSpread), time.Now())
+		a.scheduler.Add(hs.ID, key, schedule, checkJob{agent: a, assignment: assignment})
+		log.Printf("Checking %s on %s %s\n", hs.Service.ServiceName, hs.HostName, scheduling.Describe(hs))
+	}
+
+	for _, managed := range a.scheduler.List() {
+		if !assigned[managed.HostServiceID] {
+			a.scheduler.Remove(managed.HostServiceID)
+			log.Printf("No longer checking host service %d\n", managed.HostServiceID)
+		}
+	}
+
+	return nil
+}
+
+// checkConfig describes what a check of an assignment runs besides its URL, so changed settings
+// are rescheduled
+func checkConfig(assignment models.ProbeAssignment) string {
+	return string(assignment.Settings)
+}
+
+// checkJob checks one assigned host service and reports the result
+type checkJob struct {
+	agent      *agent
+	assignment models.ProbeAssignment
+}
+
+func (j checkJob) Run() {
+	hs := j.assignment.HostService
+
+	result := checks.Check(checks.Target{
+		ServiceID: hs.ServiceID,
+		HostName:  hs.HostName,
+		URL:       j.assignment.URL,
+		Settings:  j.assignment.Settings,
+		PluginDir: j.agent.pluginDir,
+	})
+
+	req := models.ProbeResultsRequest{
+		Results: []models.ProbeCheckResult{
+			{
+				HostServiceID: hs.ID,
+				Status:        result.Status,
+				Message:       result.Message,
+				ComputeTimes:  result.ComputeTimes,
+				Perfdata:      result.Perfdata,
+				Steps:         result.Steps,
+			},
+		},
+	}
+
+	var resp struct {
+		OK      bool   `json:"ok"`
+		Message string `json:"message"`
+	}
+	err := j.agent.call(http.MethodPost, "/probe/results", req, &resp)
+	if err != nil {
+		log.Printf("cannot report result of host service %d: %s\n", hs.ID, err)
+		return
+	}
+
+	if !resp.OK {
+		log.Printf("result of host service %d not accepted: %s\n", hs.ID, resp.Message)
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"flag"
+	"github.com/robfig/cron/v3"
+	"golang-observer-project/internal/scheduling"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// main runs a probe agent: it registers with an observer, pulls the host services assigned
+// to it, checks them on their schedules from where it runs and posts the results back
+func main() {
+	hostname, _ := os.Hostname()
+
+	observerURL := flag.String("observer", "http://localhost:4000", "URL of the observer")
+	name := flag.String("name", hostname, "unique name of this probe")
+	location := flag.String("location", "", "location of this probe (e.g. Frankfurt)")
+	key := flag.String("key", "", "probe registration key (probe_registration_key preference of the observer)")
+	refresh := flag.Duration("refresh", time.Minute, "how often to pull the assigned host services")
+	timeZone := flag.String("timeZone", "Europe/Istanbul", "time zone of schedules without one, as on the observer")
+	pluginDir := flag.String("pluginDir", "", "directory of the plugins exec checks may run (exec checks are off without it)")
+
+	flag.Parse()
+
+	if *name == "" || *key == "" {
+		log.Fatal("-name and -key are required")
+	}
+
+	loc, err := time.LoadLocation(*timeZone)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	scheduler := scheduling.NewManager(cron.New(cron.WithLocation(loc), cron.WithChain(
+		cron.DelayIfStillRunning(cron.DefaultLogger),
+		cron.Recover(cron.DefaultLogger),
+	)))
+
+	a := newAgent(*observerURL, *name, *location, *key, *pluginDir, scheduler)
+
+	log.Printf("Probe %s (%s) reporting to %s....", *name, *location, *observerURL)
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
+
+	scheduler.Start()
+	go a.run(*refresh)
+
+	<-stop
+	log.Println("Stopping probe, waiting for running checks....")
+	<-scheduler.Stop().Done()
+}
@@ -2,24 +2,43 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"github.com/aymerick/douceur/inliner"
 	mail "github.com/xhit/go-simple-mail/v2"
 	"golang-observer-project/internal/channeldata"
+	"golang-observer-project/internal/mailer"
+	"golang-observer-project/internal/repository"
 	"html/template"
 	"jaytaylor.com/html2text"
 	"log"
 	"strconv"
+	"sync"
 	"time"
 )
 
-// NewWorker takes a numeric id and a channel w/ worker pool.
-func NewWorker(id int, workerPool chan chan channeldata.MailJob) Worker {
+const (
+	// mailPollInterval is how often the outbox is checked for due emails
+	mailPollInterval = 5 * time.Second
+	// mailRetryDelay is the wait before the first retry, doubled after every failure
+	mailRetryDelay    = 30 * time.Second
+	mailMaxRetryDelay = time.Hour
+	// defaultMailMaxAttempts is used unless the mail_max_attempts preference is set
+	defaultMailMaxAttempts = 8
+	// sentMailRetention is how long sent emails are kept in the outbox
+	sentMailRetention = 7 * 24 * time.Hour
+)
+
+// NewWorker takes a numeric id, a channel w/ worker pool and the repository holding the outbox.
+func NewWorker(id int, workerPool chan chan channeldata.MailJob, db repository.DatabaseRepo) Worker {
 	return Worker{
 		id:         id,
 		jobQueue:   make(chan channeldata.MailJob),
 		workerPool: workerPool,
 		quitChan:   make(chan bool),
+		done:       make(chan bool),
+		db:         db,
+		smtp:       &mailer.Conn{},
 	}
 }
 
@@ -29,20 +48,27 @@ type Worker struct {
 	jobQueue   chan channeldata.MailJob
 	workerPool chan chan channeldata.MailJob
 	quitChan   chan bool
+	done       chan bool
+	db         repository.DatabaseRepo
+	// smtp is the worker's own kept-alive connection to the mail server
+	smtp *mailer.Conn
 }
 
 // start starts the worker
 func (w Worker) start() {
 	go func() {
+		defer close(w.done)
+
 		for {
 			// Add jobQueue to the worker pool.
 			w.workerPool <- w.jobQueue
 
 			select {
 			case job := <-w.jobQueue:
-				w.processMailQueueJob(job.MailMessage)
+				w.finishJob(job, w.processMailQueueJob(job.MailMessage))
 			case <-w.quitChan:
 				fmt.Printf("worker%d stopping\n", w.id)
+				w.smtp.Close()
 				return
 			}
 		}
@@ -56,48 +82,181 @@ func (w Worker) stop() {
 	}()
 }
 
+// finishJob records the outcome of a send in the outbox: sent, retried later with
+// exponential backoff, or moved to the dead letters once out of attempts
+func (w Worker) finishJob(job channeldata.MailJob, sendErr error) {
+	if sendErr == nil {
+		_ = w.db.MarkMailSent(job.ID)
+		return
+	}
+
+	maxAttempts, err := strconv.Atoi(app.Preferences.Get("mail_max_attempts"))
+	if err != nil || maxAttempts < 1 {
+		maxAttempts = defaultMailMaxAttempts
+	}
+
+	if job.Attempts >= maxAttempts {
+		log.Printf("email %d failed %d times, moving it to the dead letters: %s\n", job.ID, job.Attempts, sendErr)
+		_ = w.db.MarkMailFailed(job.ID, time.Now(), sendErr.Error(), true)
+		return
+	}
+
+	delay := mailRetryDelay
+	for i := 1; i < job.Attempts && delay < mailMaxRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > mailMaxRetryDelay {
+		delay = mailMaxRetryDelay
+	}
+
+	log.Printf("email %d failed (attempt %d of %d), retrying in %s: %s\n", job.ID, job.Attempts, maxAttempts, delay, sendErr)
+	_ = w.db.MarkMailFailed(job.ID, time.Now().Add(delay), sendErr.Error(), false)
+}
+
 // NewDispatcher creates, and returns a new Dispatcher object.
-func NewDispatcher(jobQueue chan channeldata.MailJob, maxWorkers int) *Dispatcher {
+func NewDispatcher(maxWorkers int, db repository.DatabaseRepo) *Dispatcher {
 	workerPool := make(chan chan channeldata.MailJob, maxWorkers)
 	return &Dispatcher{
-		jobQueue:   jobQueue,
 		maxWorkers: maxWorkers,
 		workerPool: workerPool,
+		db:         db,
+		queued:     make(chan struct{}, 1),
 	}
 }
 
-// Dispatcher holds info for a dispatcher
+// Dispatcher holds info for a dispatcher. Emails are stored in the mail outbox as they are
+// queued, and workers are only fed from the outbox, so nothing is lost to SMTP outages,
+// crashes or restarts.
 type Dispatcher struct {
 	workerPool chan chan channeldata.MailJob
 	maxWorkers int
-	jobQueue   chan channeldata.MailJob
+	db         repository.DatabaseRepo
+	workers    []Worker
+	quit       chan bool
+	stopped    chan bool
+	// queued wakes the dispatcher when an email was stored, rather than waiting for the poll
+	queued chan struct{}
+	// assigning tracks jobs waiting for an idle worker
+	assigning sync.WaitGroup
+}
+
+// Queue stores an email in the outbox, and wakes the dispatcher to send it
+func (d *Dispatcher) Queue(msg channeldata.MailData) (int, error) {
+	id, err := d.db.InsertMail(msg)
+	if err != nil {
+		return 0, err
+	}
+
+	select {
+	case d.queued <- struct{}{}:
+	default:
+	}
+
+	return id, nil
 }
 
 // run runs the workers
 func (d *Dispatcher) run() {
+	d.quit = make(chan bool)
+	d.stopped = make(chan bool)
+
 	for i := 0; i < d.maxWorkers; i++ {
-		worker := NewWorker(i+1, d.workerPool)
+		worker := NewWorker(i+1, d.workerPool, d.db)
 		worker.start()
+		d.workers = append(d.workers, worker)
 	}
 
 	go d.dispatch()
 }
 
-// dispatch dispatches worker
+// stop stops the workers and waits for the emails being sent, or until ctx is done. Anything
+// unsent is picked up from the outbox on the next start.
+func (d *Dispatcher) stop(ctx context.Context) error {
+	close(d.quit)
+	<-d.stopped
+	d.assigning.Wait()
+
+	for _, w := range d.workers {
+		w.stop()
+	}
+
+	for _, w := range d.workers {
+		select {
+		case <-w.done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	return nil
+}
+
+// dispatch hands due emails from the outbox to idle workers
 func (d *Dispatcher) dispatch() {
+	poll := time.NewTicker(mailPollInterval)
+	purge := time.NewTicker(time.Hour)
+
+	d.claim()
+
 	for {
 		select {
-		case job := <-d.jobQueue:
-			go func() {
-				workerJobQueue := <-d.workerPool
-				workerJobQueue <- job
-			}()
+		case <-d.queued:
+			d.claim()
+		case <-poll.C:
+			d.claim()
+		case <-purge.C:
+			_ = d.db.PurgeSentMail(time.Now().Add(-sentMailRetention))
+		case <-d.quit:
+			poll.Stop()
+			purge.Stop()
+			close(d.stopped)
+			return
 		}
 	}
 }
 
+// claim takes as many due emails from the outbox as there are idle workers
+func (d *Dispatcher) claim() {
+	idle := len(d.workerPool)
+	if idle == 0 {
+		return
+	}
+
+	mails, err := d.db.ClaimDueMail(idle)
+	if err != nil {
+		return
+	}
+
+	for _, m := range mails {
+		d.assign(channeldata.MailJob{ID: m.ID, Attempts: m.Attempts, MailMessage: m.Message})
+	}
+}
+
+// assign hands a job to the next idle worker. On shutdown a claimed email is released
+// back to the outbox instead.
+func (d *Dispatcher) assign(job channeldata.MailJob) {
+	d.assigning.Add(1)
+	go func() {
+		defer d.assigning.Done()
+
+		select {
+		case workerJobQueue := <-d.workerPool:
+			select {
+			case workerJobQueue <- job:
+				return
+			case <-d.quit:
+			}
+		case <-d.quit:
+		}
+
+		_ = d.db.MarkMailFailed(job.ID, time.Now(), "interrupted by shutdown", false)
+	}()
+}
+
 // processMailQueueJob processes the main queue job (sends email)
-func (w Worker) processMailQueueJob(mailMessage channeldata.MailData) {
+func (w Worker) processMailQueueJob(mailMessage channeldata.MailData) error {
+	// one consistent snapshot of the preferences for the whole email
+	prefs := app.Preferences.Map()
 
 	data := struct {
 		Content       template.HTML
@@ -112,7 +271,7 @@ func (w Worker) processMailQueueJob(mailMessage channeldata.MailData) {
 		Content:       mailMessage.Content,
 		FromName:      mailMessage.FromName,
 		From:          mailMessage.FromAddress,
-		PreferenceMap: preferenceMap,
+		PreferenceMap: prefs,
 		IntMap:        mailMessage.IntMap,
 		StringMap:     mailMessage.StringMap,
 		FloatMap:      mailMessage.FloatMap,
@@ -127,7 +286,8 @@ func (w Worker) processMailQueueJob(mailMessage channeldata.MailData) {
 
 	var tpl bytes.Buffer
 	if err := t.Execute(&tpl, data); err != nil {
-		fmt.Print(err)
+		log.Println(err)
+		return err
 	}
 
 	result := tpl.String()
@@ -145,28 +305,6 @@ func (w Worker) processMailQueueJob(mailMessage channeldata.MailData) {
 		formattedMessage = result
 	}
 
-	port, _ := strconv.Atoi(preferenceMap["smtp_port"])
-
-	server := mail.NewSMTPClient()
-	server.Host = preferenceMap["smtp_server"]
-	server.Port = port
-	server.Username = preferenceMap["smtp_user"]
-	server.Password = preferenceMap["smtp_password"]
-	if preferenceMap["smtp_server"] == "localhost" {
-		server.Authentication = mail.AuthPlain
-	} else {
-		server.Authentication = mail.AuthLogin
-	}
-	server.Encryption = mail.EncryptionTLS
-	server.KeepAlive = false
-	server.ConnectTimeout = 10 * time.Second
-	server.SendTimeout = 10 * time.Second
-
-	smtpClient, err := server.Connect()
-	if err != nil {
-		log.Println(err)
-	}
-
 	email := mail.NewMSG()
 	email.SetFrom(mailMessage.FromAddress).
 		AddTo(mailMessage.ToAddress).
@@ -193,10 +331,11 @@ func (w Worker) processMailQueueJob(mailMessage channeldata.MailData) {
 	email.SetBody(mail.TextHTML, formattedMessage)
 	email.AddAlternative(mail.TextPlain, plainText)
 
-	err = email.Send(smtpClient)
+	err = w.smtp.Send(prefs, email)
 	if err != nil {
-		log.Println(err)
-	} else {
-		log.Println("Email Sent")
+		return err
 	}
+
+	log.Println("Email Sent")
+	return nil
 }
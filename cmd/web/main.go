@@ -1,8 +1,8 @@
 package main
 
 import (
-	"database/sql"
 	"encoding/gob"
+	"errors"
 	"github.com/pusher/pusher-http-go"
 	"golang-observer-project/internal/config"
 	"golang-observer-project/internal/handlers"
@@ -10,19 +10,29 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
 	"runtime"
+	"syscall"
 	"time"
 )
 
 var app config.AppConfig
 var repo *handlers.DBRepo
-var preferenceMap map[string]string
 var wsClient pusher.Client
+var mailDispatcher *Dispatcher
+var checkWorker *handlers.CheckWorker
+var shutdownTimeout time.Duration
 
 const observerVersion = "1.0.0"
-const maxWorkerPoolSize = 5
 const maxJobMaxWorkers = 5
 
+// schedulerLockKey is the Postgres advisory lock held by the instance running the scheduler
+const schedulerLockKey = 4711001
+
+// leaderRenewInterval is how often the leader checks that it still holds the lock, and the
+// other instances try to take it
+const leaderRenewInterval = 5 * time.Second
+
 func init() {
 	gob.Register(models.User{})
 	_ = os.Setenv("TZ", "UTC")
@@ -36,12 +46,6 @@ func main() {
 		log.Fatal(err)
 	}
 
-	// close channels & db when the application ends
-	defer close(app.MailQueue)
-	defer func(SQL *sql.DB) {
-		_ = SQL.Close()
-	}(app.DB.SQL)
-
 	// print info
 	log.Printf("******************************************")
 	log.Printf("** %sObserver%s v%s built in %s", "\033[31m", "\033[0m", observerVersion, runtime.Version())
@@ -60,11 +64,21 @@ func main() {
 		WriteTimeout:      5 * time.Second,
 	}
 
+	// stop gracefully on SIGINT/SIGTERM
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
+
 	log.Printf("Starting HTTP server on port %s....", *insecurePort)
 
 	// start the server
-	err = srv.ListenAndServe()
-	if err != nil {
-		log.Fatal(err)
-	}
+	go func() {
+		err := srv.ListenAndServe()
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal(err)
+		}
+	}()
+
+	sig := <-stop
+	log.Printf("Received %s, shutting down (waiting at most %s)....", sig, shutdownTimeout)
+	shutdown(srv, shutdownTimeout)
 }
@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"github.com/go-chi/cors"
+	"golang-observer-project/internal/handlers"
 	"golang-observer-project/internal/token"
 	"net/http"
 	"strings"
@@ -66,3 +67,46 @@ func authMiddleware(tokenMaker token.Maker) func(next http.Handler) http.Handler
 		})
 	}
 }
+
+// probeAuthMiddleware authenticates probe agents by the bearer token they got when registering
+func probeAuthMiddleware() func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fields := strings.Fields(r.Header.Get(authorizationHeaderKey))
+			if len(fields) != 2 || strings.ToLower(fields[0]) != authorizationTypeBearer {
+				http.Error(w, "invalid authorization header", http.StatusUnauthorized)
+				return
+			}
+
+			probe, err := handlers.Repo.AuthenticateProbe(fields[1])
+			if err != nil {
+				http.Error(w, "invalid probe token", http.StatusUnauthorized)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(handlers.WithProbe(r.Context(), probe)))
+		})
+	}
+}
+
+// hostAgentAuthMiddleware authenticates host agents by the bearer token of their system host
+// service
+func hostAgentAuthMiddleware() func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fields := strings.Fields(r.Header.Get(authorizationHeaderKey))
+			if len(fields) != 2 || strings.ToLower(fields[0]) != authorizationTypeBearer {
+				http.Error(w, "invalid authorization header", http.StatusUnauthorized)
+				return
+			}
+
+			agent, err := handlers.Repo.AuthenticateHostAgent(fields[1])
+			if err != nil {
+				http.Error(w, "invalid host agent token", http.StatusUnauthorized)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(handlers.WithHostAgent(r.Context(), agent)))
+		})
+	}
+}
@@ -21,6 +21,29 @@ func routes() http.Handler {
 		mux.Use(authMiddleware(handlers.Repo.TokenMaker))
 	})
 
+	// heartbeat pings, authenticated by the token in the URL
+	mux.Get("/ping/{token}", handlers.Repo.Ping)
+	mux.Post("/ping/{token}", handlers.Repo.Ping)
+	mux.Get("/ping/{token}/{signal}", handlers.Repo.Ping)
+	mux.Post("/ping/{token}/{signal}", handlers.Repo.Ping)
+
+	// probe agents
+	mux.Route("/probe", func(mux chi.Router) {
+		mux.Post("/register", handlers.Repo.RegisterProbe)
+
+		mux.Group(func(mux chi.Router) {
+			mux.Use(probeAuthMiddleware())
+			mux.Get("/assignments", handlers.Repo.ProbeAssignments)
+			mux.Post("/results", handlers.Repo.PostProbeResults)
+		})
+	})
+
+	// host agents
+	mux.Route("/agent", func(mux chi.Router) {
+		mux.Use(hostAgentAuthMiddleware())
+		mux.Post("/metrics", handlers.Repo.PostHostMetrics)
+	})
+
 	// admin routes
 	mux.Route("/admin", func(mux chi.Router) {
 		// all admin routes are protected
@@ -37,6 +60,7 @@ func routes() http.Handler {
 
 		// settings
 		mux.Post("/settings", handlers.Repo.PostSettings)
+		mux.Post("/settings/test-email", handlers.Repo.SendTestEmail)
 
 		// service status pages (all hosts)
 		mux.Get("/all-healthy", handlers.Repo.AllHealthyServices)
@@ -50,8 +74,19 @@ func routes() http.Handler {
 		mux.Post("/user/{id}", handlers.Repo.PostOneUser)
 		mux.Delete("/user/delete/{id}", handlers.Repo.DeleteUser)
 
+		// user notification subscriptions
+		mux.Get("/user/{id}/notifications", handlers.Repo.UserNotifications)
+		mux.Post("/user/{id}/quiet-hours", handlers.Repo.PostQuietHours)
+		mux.Post("/user/{id}/contact-methods", handlers.Repo.PostContactMethod)
+		mux.Delete("/user/{id}/contact-methods/{methodID}", handlers.Repo.DeleteContactMethod)
+		mux.Post("/user/{id}/subscriptions", handlers.Repo.PostSubscription)
+		mux.Delete("/user/{id}/subscriptions/{subscriptionID}", handlers.Repo.DeleteSubscription)
+
 		// schedule
 		mux.Get("/schedule", handlers.Repo.ListEntries)
+		mux.Get("/schedule/metrics", handlers.Repo.SchedulerMetrics)
+		mux.Get("/check-queue", handlers.Repo.CheckQueue)
+		mux.Get("/leader", handlers.Repo.LeaderStatus)
 
 		//preferences
 		mux.Get("/preferences", handlers.Repo.Preferences)
@@ -63,8 +98,62 @@ func routes() http.Handler {
 		mux.Get("/host/{id}", handlers.Repo.Host)
 		mux.Post("/host/{id}", handlers.Repo.PostHost)
 		mux.Post("/host/toggle-service", handlers.Repo.ToggleHostService)
+		mux.Post("/host-service/{id}/acknowledge", handlers.Repo.AcknowledgeHostService)
+		mux.Post("/host-service/{id}/schedule", handlers.Repo.PostHostServiceSchedule)
+		mux.Get("/host-service/{id}/probes", handlers.Repo.HostServiceProbes)
+		mux.Post("/host-service/{id}/probes", handlers.Repo.PostHostServiceProbes)
+		mux.Get("/host-service/{id}/heartbeat", handlers.Repo.HostServiceHeartbeat)
+		mux.Post("/host-service/{id}/heartbeat", handlers.Repo.PostHostServiceHeartbeat)
+		mux.Get("/host-service/{id}/agent", handlers.Repo.HostServiceAgent)
+		mux.Post("/host-service/{id}/agent", handlers.Repo.PostHostServiceAgent)
+		mux.Get("/host-service/{id}/metrics/{minutes}", handlers.Repo.HostServiceMetrics)
+		mux.Get("/host-service/{id}/exec", handlers.Repo.HostServiceExec)
+		mux.Post("/host-service/{id}/exec", handlers.Repo.PostHostServiceExec)
+		mux.Get("/host-service/{id}/perfdata/{minutes}", handlers.Repo.HostServicePerfdata)
+		mux.Get("/host-service/{id}/transaction", handlers.Repo.HostServiceTransaction)
+		mux.Post("/host-service/{id}/transaction", handlers.Repo.PostHostServiceTransaction)
+		mux.Get("/host-service/{id}/transaction/steps/{minutes}", handlers.Repo.HostServiceTransactionSteps)
+		mux.Get("/host-service/{id}/database", handlers.Repo.HostServiceDatabase)
+		mux.Post("/host-service/{id}/database", handlers.Repo.PostHostServiceDatabase)
+		mux.Get("/host-service/{id}/grpc", handlers.Repo.HostServiceGRPC)
+		mux.Post("/host-service/{id}/grpc", handlers.Repo.PostHostServiceGRPC)
+		mux.Get("/host-service/{id}/websocket", handlers.Repo.HostServiceWebSocket)
+		mux.Post("/host-service/{id}/websocket", handlers.Repo.PostHostServiceWebSocket)
+		mux.Get("/host-service/{id}/settings", handlers.Repo.HostServiceSettings)
+		mux.Post("/host-service/{id}/settings", handlers.Repo.PostHostServiceSettings)
 		mux.Get("/perform-check/{id}/{oldStatus}", handlers.Repo.PerformCheck)
 
+		// webhooks
+		mux.Get("/webhooks", handlers.Repo.AllWebhooks)
+		mux.Get("/webhooks/{id}", handlers.Repo.Webhook)
+		mux.Post("/webhooks/{id}", handlers.Repo.PostWebhook)
+		mux.Delete("/webhooks/{id}", handlers.Repo.DeleteWebhook)
+		mux.Post("/webhooks/{id}/test", handlers.Repo.SendTestWebhook)
+
+		// probe agents
+		mux.Get("/probes", handlers.Repo.AllProbes)
+		mux.Post("/probes/{id}", handlers.Repo.PostProbe)
+		mux.Delete("/probes/{id}", handlers.Repo.DeleteProbe)
+
+		// reports
+		mux.Get("/reports/digest", handlers.Repo.Digest)
+		mux.Post("/reports/digest/send", handlers.Repo.SendDigestNow)
+
+		// email templates
+		mux.Get("/email-templates", handlers.Repo.AllEmailTemplates)
+		mux.Get("/email-templates/{name}", handlers.Repo.EmailTemplate)
+		mux.Post("/email-templates/{name}", handlers.Repo.PostEmailTemplate)
+		mux.Delete("/email-templates/{name}", handlers.Repo.DeleteEmailTemplate)
+		mux.Post("/email-templates/{name}/preview", handlers.Repo.PreviewEmailTemplate)
+
+		// notifications
+		mux.Get("/notification-log", handlers.Repo.NotificationLog)
+		mux.Post("/notifications/test/{channel}", handlers.Repo.SendTestChatNotification)
+
+		// mail outbox
+		mux.Get("/mail-queue", handlers.Repo.MailQueue)
+		mux.Post("/mail-queue/{id}/retry", handlers.Repo.RetryMail)
+
 		// elastic
 		mux.Get("/get-documents-in-last-x-minutes/{indexName}/{hostID}/{serviceID}/{minutes}", handlers.Repo.GetDocumentsInLastXMinutes)
 	})
@@ -6,12 +6,13 @@ import (
 	"github.com/elastic/go-elasticsearch/v7"
 	"github.com/pusher/pusher-http-go"
 	"github.com/robfig/cron/v3"
-	"golang-observer-project/internal/channeldata"
 	"golang-observer-project/internal/config"
 	"golang-observer-project/internal/driver"
 	"golang-observer-project/internal/elastic/elastic"
+	"golang-observer-project/internal/election"
 	"golang-observer-project/internal/handlers"
 	"golang-observer-project/internal/helpers"
+	"golang-observer-project/internal/scheduling"
 	"golang-observer-project/internal/token"
 	"log"
 	"os"
@@ -37,6 +38,10 @@ func setupApp() (*string, error) {
 	pusherSecret := flag.String("pusherSecret", "123abc", "pusher secret")
 	pusherSecure := flag.Bool("pusherSecure", false, "pusher server uses SSL (true or false)")
 	jwtSecret := flag.String("jwtSecret", "jwtSecretManagerTry1234512345123", "secret key for signing JWTs")
+	flag.DurationVar(&shutdownTimeout, "shutdownTimeout", 30*time.Second, "how long to wait for running work on shutdown")
+	checkMode := flag.String("mode", config.CheckModeLocal, "where checks run: local, scheduler (queue them for workers) or worker (run queued checks)")
+	checkWorkers := flag.Int("checkWorkers", 5, "how many queued checks a worker runs at once")
+	pluginDir := flag.String("pluginDir", "", "directory of the plugins exec checks may run (exec checks are off without it)")
 
 	flag.Parse()
 
@@ -45,6 +50,13 @@ func setupApp() (*string, error) {
 		os.Exit(1)
 	}
 
+	switch *checkMode {
+	case config.CheckModeLocal, config.CheckModeScheduler, config.CheckModeWorker:
+	default:
+		fmt.Println("Invalid mode, use local, scheduler or worker.")
+		os.Exit(1)
+	}
+
 	log.Println("Connecting to database....")
 	dsnString := ""
 
@@ -71,24 +83,17 @@ func setupApp() (*string, error) {
 		log.Fatal("Cannot connect to database!", err)
 	}
 
-	// start mail channel
-	log.Println("Initializing mail channel and worker pool....")
-	mailQueue := make(chan channeldata.MailJob, maxWorkerPoolSize)
-
-	// Start the email dispatcher
-	log.Println("Starting email dispatcher....")
-	dispatcher := NewDispatcher(mailQueue, maxJobMaxWorkers)
-	dispatcher.run()
-
 	// define application configuration
 	a := config.AppConfig{
 		DB:           db,
 		InProduction: *inProduction,
 		Domain:       *domain,
 		PusherSecret: *pusherSecret,
-		MailQueue:    mailQueue,
 		Version:      observerVersion,
 		Identifier:   *identifier,
+		CheckMode:    *checkMode,
+		WorkerID:     workerID(*identifier),
+		PluginDir:    *pluginDir,
 	}
 
 	app = a
@@ -112,7 +117,7 @@ func setupApp() (*string, error) {
 	handlers.NewHandlers(repo, &app, tokenMaker, elasticClient)
 
 	log.Println("Getting preferences...")
-	preferenceMap = make(map[string]string)
+	preferenceMap := make(map[string]string)
 	preferences, err := repo.DB.AllPreferences()
 	if err != nil {
 		log.Fatal("Cannot read preferences:", err)
@@ -128,7 +133,13 @@ func setupApp() (*string, error) {
 	preferenceMap["identifier"] = *identifier
 	preferenceMap["version"] = observerVersion
 
-	app.PreferenceMap = preferenceMap
+	app.Preferences = config.NewPreferences(preferenceMap)
+
+	// Start the email dispatcher, once preferences (smtp settings) are known
+	log.Println("Starting email dispatcher....")
+	mailDispatcher = NewDispatcher(maxJobMaxWorkers, repo.DB)
+	mailDispatcher.run()
+	app.MailOutbox = mailDispatcher
 
 	// create a pusher client
 	wsClient = pusher.Client{
@@ -144,10 +155,6 @@ func setupApp() (*string, error) {
 
 	app.WsClient = wsClient
 
-	monitorMap := make(map[int]cron.EntryID)
-
-	app.MonitorMap = monitorMap
-
 	// set up a time zone with Istanbul
 	localZone, _ := time.LoadLocation("Europe/Istanbul")
 	scheduler := cron.New(cron.WithLocation(localZone), cron.WithChain(
@@ -155,12 +162,29 @@ func setupApp() (*string, error) {
 		cron.Recover(cron.DefaultLogger),
 	))
 
-	app.Scheduler = scheduler
+	app.Scheduler = scheduling.NewManager(scheduler)
 
-	go handlers.Repo.StartMonitoring()
+	app.ReportScheduler = cron.New(cron.WithLocation(localZone), cron.WithChain(
+		cron.Recover(cron.DefaultLogger),
+	))
+	handlers.Repo.ScheduleDigest()
+	handlers.Repo.StartReconciliation()
+	if app.CheckMode != config.CheckModeLocal {
+		handlers.Repo.StartCheckQueueHousekeeping()
+	}
+	app.ReportScheduler.Start()
 
-	if app.PreferenceMap["monitoring_live"] == "1" {
-		app.Scheduler.Start()
+	handlers.Repo.ApplyCheckLimits()
+
+	if app.CheckMode == config.CheckModeWorker {
+		log.Printf("Running queued checks as %s....", app.WorkerID)
+		checkWorker = handlers.NewCheckWorker(handlers.Repo, app.WorkerID, *checkWorkers)
+		checkWorker.Start()
+	} else {
+		// only the elected leader among the instances runs the scheduler
+		app.Elector = election.New(db.SQL, schedulerLockKey, app.WorkerID, leaderRenewInterval,
+			handlers.Repo.BecomeLeader, handlers.Repo.StepDown)
+		app.Elector.Start()
 	}
 
 	helpers.NewHelpers(&app)
@@ -168,6 +192,16 @@ func setupApp() (*string, error) {
 	return insecurePort, err
 }
 
+// workerID identifies this process among the workers sharing the check queue
+func workerID(identifier string) string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+
+	return fmt.Sprintf("%s@%s:%d", identifier, hostname, os.Getpid())
+}
+
 // createDirIfNotExist creates a directory if it does not exist
 func createDirIfNotExist(path string) error {
 	const mode = 0755
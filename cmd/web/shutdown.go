@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"golang-observer-project/internal/handlers"
+	"log"
+	"net/http"
+	"time"
+)
+
+// shutdown stops the application in order, all within timeout: the HTTP server stops accepting
+// requests, the schedulers and check worker finish their running checks and reports,
+// background notifications are delivered, email being sent is finished, pending Elastic
+// writes are flushed and finally the database is closed.
+func shutdown(srv *http.Server, timeout time.Duration) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	log.Println("Stopping HTTP server....")
+	if err := srv.Shutdown(ctx); err != nil {
+		log.Println("HTTP server did not stop cleanly:", err)
+	}
+
+	if app.Elector != nil {
+		if err := app.Elector.Stop(ctx); err != nil {
+			log.Println("leader election did not stop cleanly:", err)
+		}
+	}
+
+	log.Println("Stopping schedulers and waiting for running jobs....")
+	waitFor(ctx, "monitoring scheduler", app.Scheduler.Stop())
+	waitFor(ctx, "report scheduler", app.ReportScheduler.Stop())
+
+	// hand the scheduler over only once our own checks have finished
+	if app.Elector != nil {
+		if err := app.Elector.Resign(ctx); err != nil {
+			log.Println("leadership not given up yet, it ends when the database is closed:", err)
+		}
+	}
+	if checkWorker != nil {
+		if err := checkWorker.Stop(ctx); err != nil {
+			log.Println("queued checks still running, another worker takes them over:", err)
+		}
+	}
+
+	log.Println("Waiting for notifications to be delivered....")
+	if err := handlers.WaitForBackground(ctx); err != nil {
+		log.Println("notifications still in flight:", err)
+	}
+
+	log.Println("Stopping email dispatcher....")
+	if err := mailDispatcher.stop(ctx); err != nil {
+		log.Println("email still being sent, it will be retried from the outbox:", err)
+	}
+
+	log.Println("Flushing Elastic writes....")
+	if err := repo.ElasticClient.Flush(ctx); err != nil {
+		log.Println("Elastic writes still pending:", err)
+	}
+
+	log.Println("Closing database....")
+	if err := app.DB.SQL.Close(); err != nil {
+		log.Println(err)
+	}
+
+	log.Println("Shutdown complete")
+}
+
+// waitFor waits until the jobs of a stopped scheduler have finished, or until ctx is done
+func waitFor(ctx context.Context, name string, jobsDone context.Context) {
+	select {
+	case <-jobsDone.Done():
+	case <-ctx.Done():
+		log.Printf("%s jobs still running: %s\n", name, ctx.Err())
+	}
+}
@@ -23,5 +23,8 @@ type MailData struct {
 
 // MailJob is the unit of work to be performed when sending an email to chan
 type MailJob struct {
+	// ID is the mail outbox id, 0 when the email could not be stored
+	ID          int
+	Attempts    int
 	MailMessage MailData
 }
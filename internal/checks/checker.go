@@ -0,0 +1,103 @@
+package checks
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync"
+)
+
+// Target is what a check runs against: a host service of a host, and the settings of its check
+type Target struct {
+	ServiceID int
+	HostName  string
+	URL       string
+	// Settings are the settings of the check as JSON, nil when none are set
+	Settings json.RawMessage
+	// PluginDir is the directory of the plugins exec checks may run
+	PluginDir string
+}
+
+// Checker checks one kind of service. Checkers register themselves for their service id, so
+// the observer, workers and probes run whatever is registered without knowing every kind.
+type Checker interface {
+	Check(t Target) Result
+}
+
+// CheckerFunc is a function used as a Checker
+type CheckerFunc func(t Target) Result
+
+// Check calls f
+func (f CheckerFunc) Check(t Target) Result {
+	return f(t)
+}
+
+// Configurable is a Checker whose settings are kept as the generic settings of host services
+// rather than in a table of their own
+type Configurable interface {
+	Checker
+	// Validate checks settings before they are saved
+	Validate(settings json.RawMessage) error
+	// Secrets are the settings, by JSON name, that are not shown once saved
+	Secrets() []string
+}
+
+var (
+	checkersMu sync.RWMutex
+	checkers   = make(map[int]Checker)
+)
+
+// Register makes a checker check a service; registering a service twice panics
+func Register(serviceID int, checker Checker) {
+	checkersMu.Lock()
+	defer checkersMu.Unlock()
+
+	if _, ok := checkers[serviceID]; ok {
+		panic("checks: service " + strconv.Itoa(serviceID) + " registered twice")
+	}
+	checkers[serviceID] = checker
+}
+
+// Lookup returns the checker of a service
+func Lookup(serviceID int) (Checker, bool) {
+	checkersMu.RLock()
+	defer checkersMu.RUnlock()
+
+	checker, ok := checkers[serviceID]
+	return checker, ok
+}
+
+// Check runs the checker of the service of a target
+func Check(t Target) Result {
+	checker, ok := Lookup(t.ServiceID)
+	if !ok {
+		return Result{Status: "problem", Message: "unknown service " + strconv.Itoa(t.ServiceID)}
+	}
+
+	return checker.Check(t)
+}
+
+// hasSettings reports whether a target has settings
+func (t Target) hasSettings() bool {
+	s := bytes.TrimSpace(t.Settings)
+	return len(s) > 0 && !bytes.Equal(s, []byte("null"))
+}
+
+// decodeSettings decodes settings into v, which is left as it is without settings; unknown
+// fields are refused so that a typo is not silently ignored
+func decodeSettings(settings json.RawMessage, v interface{}) error {
+	if len(bytes.TrimSpace(settings)) == 0 {
+		return nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(settings))
+	decoder.DisallowUnknownFields()
+
+	err := decoder.Decode(v)
+	if err != nil {
+		return fmt.Errorf("invalid settings: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,177 @@
+// Package checks runs the checks of host services. It only needs the network, so the
+// observer and remote probe agents run the very same checks.
+package checks
+
+import (
+	"crypto/tls"
+	"golang-observer-project/internal/certificateutils"
+	"golang-observer-project/internal/models"
+	"log"
+	"net/http"
+	"net/http/httptrace"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Service ids of the checks
+const (
+	ServiceHTTP           = 1
+	ServiceHTTPS          = 2
+	ServiceSSLCertificate = 3
+)
+
+// certificateWarningDays is how many days before expiry a certificate is a warning, and
+// certificateProblemDays a problem
+const (
+	certificateWarningDays = 30
+	certificateProblemDays = 7
+)
+
+// Result is the outcome of a check
+type Result struct {
+	Status  string
+	Message string
+	// ComputeTimes are the timings of the request, for checks that make one
+	ComputeTimes *models.ComputeTimes
+	// Perfdata is the performance data of plugins run by exec checks
+	Perfdata []models.Perfdata
+	// Steps are the timings of the steps of a transaction
+	Steps []models.StepTimes
+}
+
+func init() {
+	Register(ServiceHTTP, CheckerFunc(func(t Target) Result { return HTTP(t.URL) }))
+	Register(ServiceHTTPS, CheckerFunc(func(t Target) Result { return HTTPS(t.URL) }))
+	Register(ServiceSSLCertificate, CheckerFunc(func(t Target) Result { return SSLCertificate(t.URL) }))
+}
+
+// Run runs the check of a service without settings against url
+func Run(serviceID int, url string) Result {
+	return Check(Target{ServiceID: serviceID, URL: url})
+}
+
+// HTTP checks that url answers 200 OK over plain http
+func HTTP(url string) Result {
+	url = strings.TrimSuffix(url, "/")
+	url = strings.Replace(url, "https://", "http://", -1)
+
+	return get(url)
+}
+
+// HTTPS checks that url answers 200 OK over https
+func HTTPS(url string) Result {
+	url = strings.TrimSuffix(url, "/")
+	url = strings.Replace(url, "http://", "https://", -1)
+
+	return get(url)
+}
+
+// get requests url, timing the request
+func get(url string) Result {
+	result := Result{ComputeTimes: ComputeTime(url)}
+
+	resp, err := http.Get(url)
+	if err != nil {
+		result.Status = "problem"
+		result.Message = err.Error()
+		return result
+	}
+
+	defer func(resp *http.Response) {
+		err := resp.Body.Close()
+		if err != nil {
+			log.Println(err)
+		}
+	}(resp)
+
+	result.Message = resp.Status
+	if resp.StatusCode != http.StatusOK {
+		result.Status = "problem"
+	} else {
+		result.Status = "healthy"
+	}
+
+	return result
+}
+
+// SSLCertificate checks how long the certificate of the host in url is still valid
+func SSLCertificate(url string) Result {
+	url = strings.Replace(url, "https://", "", -1)
+	url = strings.Replace(url, "http://", "", -1)
+
+	certDetails, err := certificateutils.GetCertificateDetails(url, 10)
+	if err != nil {
+		return Result{Status: "problem", Message: err.Error()}
+	}
+
+	certificateutils.CheckExpirationStatus(&certDetails, certificateWarningDays)
+
+	result := Result{
+		Status:  "healthy",
+		Message: certDetails.Hostname + " expiring in " + strconv.Itoa(certDetails.DaysUntilExpiration) + " days",
+	}
+
+	if certDetails.ExpiringSoon {
+		if certDetails.DaysUntilExpiration < certificateProblemDays {
+			result.Status = "problem"
+		} else {
+			result.Status = "warning"
+		}
+	}
+
+	return result
+}
+
+// ComputeTime requests url and times its DNS lookup, connect, TLS handshake, first byte and
+// total
+func ComputeTime(url string) *models.ComputeTimes {
+	var start time.Time
+	var computeTimes models.ComputeTimes
+
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return &computeTimes
+	}
+
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), timingTrace(&computeTimes, &start)))
+	start = time.Now()
+	resp, err := http.DefaultTransport.RoundTrip(req)
+	if err != nil {
+		computeTimes.ResponseStatus = 0
+	} else {
+		computeTimes.ResponseStatus = resp.StatusCode
+		_ = resp.Body.Close()
+	}
+
+	computeTimes.TotalTime = time.Since(start)
+
+	return &computeTimes
+}
+
+// timingTrace records the DNS lookup, connect, TLS handshake and first byte times of a request
+// started at start in computeTimes
+func timingTrace(computeTimes *models.ComputeTimes, start *time.Time) *httptrace.ClientTrace {
+	var connect, dns, tlsHandshake time.Time
+
+	return &httptrace.ClientTrace{
+		DNSStart: func(dsi httptrace.DNSStartInfo) { dns = time.Now() },
+		DNSDone: func(ddi httptrace.DNSDoneInfo) {
+			computeTimes.DNSDone = time.Since(dns)
+		},
+
+		TLSHandshakeStart: func() { tlsHandshake = time.Now() },
+		TLSHandshakeDone: func(cs tls.ConnectionState, err error) {
+			computeTimes.TLSHandshake = time.Since(tlsHandshake)
+		},
+
+		ConnectStart: func(network, addr string) { connect = time.Now() },
+		ConnectDone: func(network, addr string, err error) {
+			computeTimes.ConnectTime = time.Since(connect)
+		},
+
+		GotFirstResponseByte: func() {
+			computeTimes.FirstByte = time.Since(*start)
+		},
+	}
+}
@@ -0,0 +1,357 @@
+package checks
+
+import (
+	"context"
+	"crypto/tls"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v4"
+	"github.com/redis/go-redis/v9"
+	"golang-observer-project/internal/models"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Service ids of the database checks
+const (
+	ServicePostgres = 8
+	ServiceMySQL    = 9
+	ServiceRedis    = 10
+)
+
+// DefaultDatabaseTimeout is the timeout of a database check without one, and
+// MaxDatabaseTimeout the longest one allowed
+const (
+	DefaultDatabaseTimeout = 10 * time.Second
+	MaxDatabaseTimeout     = time.Minute
+)
+
+// TLS modes of database checks
+const (
+	TLSDisable = "disable"
+	TLSRequire = "require"
+	TLSVerify  = "verify"
+)
+
+// replicationLagQuery is the replication lag of a PostgreSQL standby in seconds, 0 on a primary
+const replicationLagQuery = `
+	SELECT CASE WHEN pg_is_in_recovery()
+		THEN COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()), 0)
+		ELSE 0 END::float8`
+
+func init() {
+	// without settings, the host of the URL is connected to with the defaults
+	checker := CheckerFunc(func(t Target) Result {
+		var dc models.DatabaseCheck
+		if err := decodeSettings(t.Settings, &dc); err != nil {
+			return Result{Status: "problem", Message: err.Error()}
+		}
+
+		return Database(t.ServiceID, dc, t.URL)
+	})
+
+	Register(ServicePostgres, checker)
+	Register(ServiceMySQL, checker)
+	Register(ServiceRedis, checker)
+}
+
+// IsDatabase reports whether a service is a database check
+func IsDatabase(serviceID int) bool {
+	return serviceID == ServicePostgres || serviceID == ServiceMySQL || serviceID == ServiceRedis
+}
+
+// ValidateDatabaseCheck checks the settings of a database check
+func ValidateDatabaseCheck(serviceID int, dc models.DatabaseCheck) error {
+	switch dc.TLSMode {
+	case "", TLSDisable, TLSRequire, TLSVerify:
+	default:
+		return fmt.Errorf("invalid TLS mode %q, use disable, require or verify", dc.TLSMode)
+	}
+
+	if dc.TimeoutSeconds < 0 || time.Duration(dc.TimeoutSeconds)*time.Second > MaxDatabaseTimeout {
+		return fmt.Errorf("the timeout must be at most %s", MaxDatabaseTimeout)
+	}
+
+	if dc.Address != "" {
+		if _, _, err := net.SplitHostPort(dc.Address); err != nil {
+			return fmt.Errorf("invalid address %q, use host:port", dc.Address)
+		}
+	}
+
+	if dc.LagWarningSeconds < 0 || dc.LagProblemSeconds < 0 {
+		return errors.New("the replication lag thresholds cannot be negative")
+	}
+	if (dc.LagWarningSeconds > 0 || dc.LagProblemSeconds > 0) && serviceID != ServicePostgres {
+		return errors.New("replication lag thresholds are only for PostgreSQL")
+	}
+
+	if serviceID == ServiceRedis && dc.Database != "" {
+		if _, err := strconv.Atoi(dc.Database); err != nil {
+			return fmt.Errorf("invalid Redis database %q, use its number", dc.Database)
+		}
+	}
+
+	return nil
+}
+
+// Database connects to the database of a database check and runs its query, timing both
+func Database(serviceID int, dc models.DatabaseCheck, hostURL string) Result {
+	timeout := time.Duration(dc.TimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = DefaultDatabaseTimeout
+	}
+	if timeout > MaxDatabaseTimeout {
+		timeout = MaxDatabaseTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	switch serviceID {
+	case ServicePostgres:
+		return postgres(ctx, dc, hostAddress(dc.Address, hostURL, "5432"))
+	case ServiceMySQL:
+		return mySQL(ctx, dc, hostAddress(dc.Address, hostURL, "3306"))
+	case ServiceRedis:
+		return redisCommand(ctx, dc, hostAddress(dc.Address, hostURL, "6379"))
+	}
+
+	return Result{Status: "problem", Message: "unknown service " + strconv.Itoa(serviceID)}
+}
+
+// hostAddress returns address, or the host of hostURL with the default port; an address
+// without a host, such as :5432, gets the host of hostURL
+func hostAddress(address, hostURL, defaultPort string) string {
+	host := hostURL
+	if u, err := url.Parse(hostURL); err == nil && u.Hostname() != "" {
+		host = u.Hostname()
+	}
+
+	if address == "" {
+		return net.JoinHostPort(host, defaultPort)
+	}
+
+	if h, port, err := net.SplitHostPort(address); err == nil && h == "" {
+		return net.JoinHostPort(host, port)
+	}
+
+	return address
+}
+
+// databaseTLS returns the TLS configuration of a TLS mode, nil without TLS
+func databaseTLS(mode, address string) *tls.Config {
+	// without certificates to parse there is no error
+	config, _ := clientTLS(mode, address, "", "", "", "")
+	return config
+}
+
+// databaseResult is the result of a connection made in connect that ran its query in query
+func databaseResult(connect, query time.Duration, queryText, reply string) Result {
+	msg := fmt.Sprintf("connected in %s, %s in %s", connect.Round(time.Millisecond), queryText,
+		query.Round(time.Millisecond))
+	if reply != "" {
+		msg += ": " + reply
+	}
+
+	return Result{
+		Status:  "healthy",
+		Message: msg,
+		ComputeTimes: &models.ComputeTimes{
+			ConnectTime: connect,
+			FirstByte:   query,
+			TotalTime:   connect + query,
+		},
+	}
+}
+
+// postgres checks a PostgreSQL server with pgx, and its replication lag when it has thresholds
+func postgres(ctx context.Context, dc models.DatabaseCheck, address string) Result {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return Result{Status: "problem", Message: err.Error()}
+	}
+
+	sslMode := "disable"
+	switch dc.TLSMode {
+	case TLSRequire:
+		sslMode = "require"
+	case TLSVerify:
+		sslMode = "verify-full"
+	}
+
+	config, err := pgx.ParseConfig(fmt.Sprintf("host=%s port=%s sslmode=%s", host, port, sslMode))
+	if err != nil {
+		return Result{Status: "problem", Message: err.Error()}
+	}
+	config.User = dc.Username
+	config.Password = dc.Password
+	config.Database = dc.Database
+
+	start := time.Now()
+	conn, err := pgx.ConnectConfig(ctx, config)
+	if err != nil {
+		return Result{Status: "problem", Message: "cannot connect: " + err.Error()}
+	}
+	connect := time.Since(start)
+
+	defer func(conn *pgx.Conn) {
+		_ = conn.Close(context.Background())
+	}(conn)
+
+	query := dc.Query
+	if query == "" {
+		query = "SELECT 1"
+	}
+
+	start = time.Now()
+	_, err = conn.Exec(ctx, query)
+	if err != nil {
+		return Result{Status: "problem", Message: fmt.Sprintf("%s failed: %s", query, err)}
+	}
+	result := databaseResult(connect, time.Since(start), query, "")
+
+	if dc.LagWarningSeconds <= 0 && dc.LagProblemSeconds <= 0 {
+		return result
+	}
+
+	var lag float64
+	err = conn.QueryRow(ctx, replicationLagQuery).Scan(&lag)
+	if err != nil {
+		return Result{Status: "problem", Message: "cannot read replication lag: " + err.Error(),
+			ComputeTimes: result.ComputeTimes}
+	}
+
+	result.Message += fmt.Sprintf(", replication lag %.1fs", lag)
+	switch {
+	case dc.LagProblemSeconds > 0 && lag >= dc.LagProblemSeconds:
+		result.Status = "problem"
+	case dc.LagWarningSeconds > 0 && lag >= dc.LagWarningSeconds:
+		result.Status = "warning"
+	}
+
+	return result
+}
+
+// mySQL checks a MySQL server
+func mySQL(ctx context.Context, dc models.DatabaseCheck, address string) Result {
+	config := mysql.NewConfig()
+	config.User = dc.Username
+	config.Passwd = dc.Password
+	config.Net = "tcp"
+	config.Addr = address
+	config.DBName = dc.Database
+	if tlsConfig := databaseTLS(dc.TLSMode, address); tlsConfig != nil {
+		config.TLS = tlsConfig
+	}
+
+	connector, err := mysql.NewConnector(config)
+	if err != nil {
+		return Result{Status: "problem", Message: err.Error()}
+	}
+
+	db := sql.OpenDB(connector)
+	defer func(db *sql.DB) {
+		_ = db.Close()
+	}(db)
+
+	start := time.Now()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return Result{Status: "problem", Message: "cannot connect: " + err.Error()}
+	}
+
+	defer func(conn *sql.Conn) {
+		_ = conn.Close()
+	}(conn)
+
+	err = conn.PingContext(ctx)
+	if err != nil {
+		return Result{Status: "problem", Message: "cannot connect: " + err.Error()}
+	}
+	connect := time.Since(start)
+
+	query := dc.Query
+	if query == "" {
+		query = "SELECT 1"
+	}
+
+	start = time.Now()
+	rows, err := conn.QueryContext(ctx, query)
+	if err != nil {
+		return Result{Status: "problem", Message: fmt.Sprintf("%s failed: %s", query, err)}
+	}
+	// read the whole result, a query can fail halfway
+	for rows.Next() {
+	}
+	err = rows.Err()
+	_ = rows.Close()
+	if err != nil {
+		return Result{Status: "problem", Message: fmt.Sprintf("%s failed: %s", query, err)}
+	}
+
+	return databaseResult(connect, time.Since(start), query, "")
+}
+
+// redisCommand checks a Redis server with a command, PING by default
+func redisCommand(ctx context.Context, dc models.DatabaseCheck, address string) Result {
+	db := 0
+	if dc.Database != "" {
+		var err error
+		db, err = strconv.Atoi(dc.Database)
+		if err != nil {
+			return Result{Status: "problem", Message: fmt.Sprintf("invalid Redis database %q", dc.Database)}
+		}
+	}
+
+	var start time.Time
+	var connect time.Duration
+
+	client := redis.NewClient(&redis.Options{
+		Addr:      address,
+		Username:  dc.Username,
+		Password:  dc.Password,
+		DB:        db,
+		TLSConfig: databaseTLS(dc.TLSMode, address),
+		PoolSize:  1,
+		// called once the connection is made and authenticated, and the database selected
+		OnConnect: func(ctx context.Context, cn *redis.Conn) error {
+			connect = time.Since(start)
+			return nil
+		},
+	})
+
+	defer func(client *redis.Client) {
+		_ = client.Close()
+	}(client)
+
+	command := dc.Query
+	if command == "" {
+		command = "PING"
+	}
+
+	args := make([]interface{}, 0)
+	for _, field := range strings.Fields(command) {
+		args = append(args, field)
+	}
+
+	start = time.Now()
+	reply, err := client.Do(ctx, args...).Result()
+	if errors.Is(err, redis.Nil) {
+		reply = "(nil)"
+	} else if err != nil {
+		return Result{Status: "problem", Message: fmt.Sprintf("%s failed: %s", command, err)}
+	}
+	total := time.Since(start)
+
+	query := total - connect
+	if query < 0 {
+		query = 0
+	}
+
+	return databaseResult(connect, query, command, fmt.Sprint(reply))
+}
@@ -0,0 +1,199 @@
+package checks
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"golang-observer-project/internal/models"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ServiceExec is the service id of exec checks, which run a Nagios compatible plugin
+const ServiceExec = 6
+
+// DefaultExecTimeout is the timeout of a plugin without one, and MaxExecTimeout the longest
+// one allowed
+const (
+	DefaultExecTimeout = 10 * time.Second
+	MaxExecTimeout     = 5 * time.Minute
+)
+
+// maxExecOutput is how much of the output of a plugin is read
+const maxExecOutput = 64 * 1024
+
+// execWaitDelay is how long to wait for the output of a plugin after it was killed
+const execWaitDelay = 2 * time.Second
+
+// execEnv is the whole environment of a plugin
+var execEnv = []string{"PATH=/usr/local/bin:/usr/bin:/bin", "LANG=C", "LC_ALL=C"}
+
+// ErrExecDisabled is returned for exec checks when no plugin directory is set
+var ErrExecDisabled = errors.New("exec checks are disabled, start with -pluginDir")
+
+// ExecMacros are the macros an argument of a plugin can contain, as in Nagios: $HOSTNAME$,
+// $HOSTURL$ and $HOSTADDRESS$, the host of the URL
+func ExecMacros(hostName, hostURL string) map[string]string {
+	address := hostURL
+	if u, err := url.Parse(hostURL); err == nil && u.Hostname() != "" {
+		address = u.Hostname()
+	}
+
+	return map[string]string{
+		"$HOSTNAME$":    hostName,
+		"$HOSTURL$":     hostURL,
+		"$HOSTADDRESS$": address,
+	}
+}
+
+// ValidPluginName checks that a plugin is named by a file name only
+func ValidPluginName(command string) error {
+	if command == "" || command != filepath.Base(command) || strings.HasPrefix(command, ".") {
+		return fmt.Errorf("invalid plugin %q, use the name of a file in the plugin directory", command)
+	}
+
+	return nil
+}
+
+// PluginPath returns the path of a plugin in pluginDir; a plugin is named by its file name only,
+// so nothing outside pluginDir can be run
+func PluginPath(pluginDir, command string) (string, error) {
+	if pluginDir == "" {
+		return "", ErrExecDisabled
+	}
+
+	err := ValidPluginName(command)
+	if err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(pluginDir, command)
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", fmt.Errorf("plugin %s not found", command)
+	}
+	if !info.Mode().IsRegular() || info.Mode().Perm()&0111 == 0 {
+		return "", fmt.Errorf("plugin %s is not an executable file", command)
+	}
+
+	return path, nil
+}
+
+func init() {
+	Register(ServiceExec, CheckerFunc(func(t Target) Result {
+		if !t.hasSettings() {
+			return Result{Status: "problem", Message: "no plugin set"}
+		}
+
+		var check models.ExecCheck
+		if err := decodeSettings(t.Settings, &check); err != nil {
+			return Result{Status: "problem", Message: err.Error()}
+		}
+
+		return Exec(check, t.PluginDir, ExecMacros(t.HostName, t.URL))
+	}))
+}
+
+// Exec runs the plugin of an exec check from pluginDir, without a shell, in pluginDir, with
+// an empty environment and within its timeout. Exit codes 0, 1 and 2 are healthy, warning and
+// problem; 3 (UNKNOWN) and any other code leave the status pending. The first line of the
+// output is the message and its perfdata is returned with the result.
+func Exec(check models.ExecCheck, pluginDir string, macros map[string]string) Result {
+	path, err := PluginPath(pluginDir, check.Command)
+	if err != nil {
+		return Result{Status: "problem", Message: err.Error()}
+	}
+
+	timeout := time.Duration(check.TimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = DefaultExecTimeout
+	}
+	if timeout > MaxExecTimeout {
+		timeout = MaxExecTimeout
+	}
+
+	args := make([]string, len(check.Arguments))
+	for i, arg := range check.Arguments {
+		for macro, value := range macros {
+			arg = strings.ReplaceAll(arg, macro, value)
+		}
+		args[i] = arg
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var stdout, stderr limitedBuffer
+	stdout.limit = maxExecOutput
+	stderr.limit = maxExecOutput
+
+	cmd := exec.CommandContext(ctx, path, args...)
+	cmd.Dir = pluginDir
+	cmd.Env = execEnv
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	cmd.WaitDelay = execWaitDelay
+	isolate(cmd)
+
+	err = cmd.Run()
+
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return Result{Status: "problem", Message: fmt.Sprintf("%s timed out after %s", check.Command, timeout)}
+	}
+
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		return Result{Status: "problem", Message: fmt.Sprintf("cannot run %s: %s", check.Command, err)}
+	}
+
+	code := cmd.ProcessState.ExitCode()
+	text, perfdata := ParsePluginOutput(stdout.String())
+	if text == "" {
+		text = firstLine(stderr.String())
+	}
+	if text == "" {
+		text = fmt.Sprintf("%s exited with status %d", check.Command, code)
+	}
+
+	result := Result{Message: text, Perfdata: perfdata}
+
+	switch code {
+	case 0:
+		result.Status = "healthy"
+	case 1:
+		result.Status = "warning"
+	case 2:
+		result.Status = "problem"
+	default:
+		result.Status = "pending"
+		if !strings.HasPrefix(strings.ToUpper(text), "UNKNOWN") {
+			result.Message = "UNKNOWN: " + text
+		}
+	}
+
+	return result
+}
+
+// limitedBuffer keeps the first limit bytes written to it and drops the rest
+type limitedBuffer struct {
+	bytes.Buffer
+	limit int
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := b.limit - b.Len(); room > 0 {
+		if len(p) > room {
+			b.Buffer.Write(p[:room])
+		} else {
+			b.Buffer.Write(p)
+		}
+	}
+
+	return len(p), nil
+}
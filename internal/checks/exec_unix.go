@@ -0,0 +1,17 @@
+//go:build unix
+
+package checks
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// isolate runs a plugin in its own process group, so a timeout also kills the processes it
+// started
+func isolate(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+}
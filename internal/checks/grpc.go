@@ -0,0 +1,238 @@
+package checks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"golang-observer-project/internal/models"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"net"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// ServiceGRPC is the service id of gRPC health checks
+const ServiceGRPC = 11
+
+// DefaultGRPCTimeout is the timeout of a gRPC health check without one, and MaxGRPCTimeout the
+// longest one allowed
+const (
+	DefaultGRPCTimeout = 10 * time.Second
+	MaxGRPCTimeout     = time.Minute
+)
+
+func init() {
+	// without settings, the whole server at the host and port of the URL is asked about
+	Register(ServiceGRPC, CheckerFunc(func(t Target) Result {
+		var gc models.GRPCCheck
+		if err := decodeSettings(t.Settings, &gc); err != nil {
+			return Result{Status: "problem", Message: err.Error()}
+		}
+
+		return GRPC(gc, t.URL)
+	}))
+}
+
+// ValidateGRPCCheck checks the settings of a gRPC health check, including its certificates
+func ValidateGRPCCheck(gc models.GRPCCheck) error {
+	switch gc.TLSMode {
+	case "", TLSDisable, TLSRequire, TLSVerify:
+	default:
+		return fmt.Errorf("invalid TLS mode %q, use disable, require or verify", gc.TLSMode)
+	}
+
+	if gc.TimeoutSeconds < 0 || time.Duration(gc.TimeoutSeconds)*time.Second > MaxGRPCTimeout {
+		return fmt.Errorf("the timeout must be at most %s", MaxGRPCTimeout)
+	}
+
+	if gc.Address != "" {
+		if _, _, err := net.SplitHostPort(gc.Address); err != nil {
+			return fmt.Errorf("invalid address %q, use host:port", gc.Address)
+		}
+	}
+
+	if gc.TLSMode == "" || gc.TLSMode == TLSDisable {
+		if gc.CACert != "" || gc.ClientCert != "" || gc.ClientKey != "" {
+			return errors.New("certificates need a TLS mode of require or verify")
+		}
+		return nil
+	}
+
+	_, err := clientTLS(gc.TLSMode, "localhost:0", gc.ServerName, gc.CACert, gc.ClientCert, gc.ClientKey)
+	return err
+}
+
+// GRPC calls the standard health service, grpc.health.v1.Health/Check, for the service of a
+// gRPC health check: SERVING is healthy, UNKNOWN a warning, and NOT_SERVING or a failed call a
+// problem. The connection and the call are timed apart.
+func GRPC(gc models.GRPCCheck, hostURL string) Result {
+	timeout := time.Duration(gc.TimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = DefaultGRPCTimeout
+	}
+	if timeout > MaxGRPCTimeout {
+		timeout = MaxGRPCTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	address := grpcAddress(gc, hostURL)
+
+	tlsConfig, err := clientTLS(gc.TLSMode, address, gc.ServerName, gc.CACert, gc.ClientCert, gc.ClientKey)
+	if err != nil {
+		return Result{Status: "problem", Message: err.Error()}
+	}
+
+	// the connection is made and retried in the background
+	times := &grpcTimes{}
+
+	creds := insecure.NewCredentials()
+	if tlsConfig != nil {
+		creds = credentials.NewTLS(tlsConfig)
+	}
+
+	dialer := func(ctx context.Context, addr string) (net.Conn, error) {
+		start := time.Now()
+		conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", addr)
+		times.set(&times.connect, time.Since(start))
+		return conn, err
+	}
+
+	conn, err := grpc.NewClient("passthrough:///"+address,
+		grpc.WithTransportCredentials(timedCredentials{creds, times}),
+		grpc.WithContextDialer(dialer),
+		grpc.WithUserAgent("observer"),
+	)
+	if err != nil {
+		return Result{Status: "problem", Message: err.Error()}
+	}
+
+	defer func(conn *grpc.ClientConn) {
+		_ = conn.Close()
+	}(conn)
+
+	// connect first, so the call is timed on its own; a failed connection fails the call below
+	// with the reason
+	start := time.Now()
+	conn.Connect()
+	for state := conn.GetState(); state != connectivity.Ready && state != connectivity.TransientFailure; state = conn.GetState() {
+		if !conn.WaitForStateChange(ctx, state) {
+			break
+		}
+	}
+	connected := time.Since(start)
+
+	start = time.Now()
+	resp, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{Service: gc.ServiceName})
+	call := time.Since(start)
+
+	times.mu.Lock()
+	computeTimes := &models.ComputeTimes{
+		ConnectTime:  times.connect,
+		TLSHandshake: times.handshake,
+		FirstByte:    call,
+		TotalTime:    connected + call,
+	}
+	times.mu.Unlock()
+
+	if err != nil {
+		return Result{Status: "problem", Message: grpcError(err, gc.ServiceName), ComputeTimes: computeTimes}
+	}
+
+	msg := fmt.Sprintf("%s in %s", resp.GetStatus(), call.Round(time.Millisecond))
+	if gc.ServiceName != "" {
+		msg = gc.ServiceName + " is " + msg
+	}
+
+	result := Result{Message: msg, ComputeTimes: computeTimes}
+	switch resp.GetStatus() {
+	case healthpb.HealthCheckResponse_SERVING:
+		result.Status = "healthy"
+	case healthpb.HealthCheckResponse_UNKNOWN:
+		result.Status = "warning"
+	default:
+		result.Status = "problem"
+	}
+
+	return result
+}
+
+// grpcAddress returns the address of a gRPC health check, or the host and port of hostURL; a
+// URL without a port uses 443 with TLS and 80 without
+func grpcAddress(gc models.GRPCCheck, hostURL string) string {
+	if gc.Address != "" {
+		return gc.Address
+	}
+
+	host, port := hostURL, ""
+	if u, err := url.Parse(hostURL); err == nil && u.Hostname() != "" {
+		host, port = u.Hostname(), u.Port()
+	}
+
+	if port == "" {
+		port = "80"
+		if gc.TLSMode == TLSRequire || gc.TLSMode == TLSVerify {
+			port = "443"
+		}
+	}
+
+	return net.JoinHostPort(host, port)
+}
+
+// grpcError describes a failed health check call
+func grpcError(err error, serviceName string) string {
+	s := status.Convert(err)
+
+	switch s.Code() {
+	case codes.NotFound:
+		return fmt.Sprintf("the server does not know the service %q", serviceName)
+	case codes.Unimplemented:
+		return "the server does not implement grpc.health.v1.Health"
+	case codes.DeadlineExceeded:
+		return "no answer in time"
+	case codes.Unavailable:
+		return "cannot connect: " + s.Message()
+	}
+
+	return s.Code().String() + ": " + s.Message()
+}
+
+// grpcTimes are the connection timings of a gRPC health check
+type grpcTimes struct {
+	mu        sync.Mutex
+	connect   time.Duration
+	handshake time.Duration
+}
+
+func (t *grpcTimes) set(d *time.Duration, value time.Duration) {
+	t.mu.Lock()
+	*d = value
+	t.mu.Unlock()
+}
+
+// timedCredentials times the TLS handshakes of transport credentials
+type timedCredentials struct {
+	credentials.TransportCredentials
+	times *grpcTimes
+}
+
+func (c timedCredentials) ClientHandshake(ctx context.Context, authority string, conn net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	start := time.Now()
+	tlsConn, info, err := c.TransportCredentials.ClientHandshake(ctx, authority, conn)
+	if c.Info().SecurityProtocol == "tls" {
+		c.times.set(&c.times.handshake, time.Since(start))
+	}
+	return tlsConn, info, err
+}
+
+func (c timedCredentials) Clone() credentials.TransportCredentials {
+	return timedCredentials{c.TransportCredentials.Clone(), c.times}
+}
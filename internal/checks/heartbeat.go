@@ -0,0 +1,64 @@
+package checks
+
+import (
+	"fmt"
+	"golang-observer-project/internal/models"
+	"strings"
+	"time"
+)
+
+// ServiceHeartbeat is the service id of heartbeats: jobs report in instead of being checked
+const ServiceHeartbeat = 4
+
+// heartbeatLogInMessage is how much of the log of a failed job goes in the status message
+const heartbeatLogInMessage = 200
+
+// Heartbeat decides the status of a heartbeat at now from the signals its job sent: a
+// problem when the job failed, started without finishing within the grace time, or has not
+// succeeded for a period plus the grace time
+func Heartbeat(hb models.Heartbeat, now time.Time) Result {
+	period := time.Duration(hb.PeriodSeconds) * time.Second
+	grace := time.Duration(hb.GraceSeconds) * time.Second
+
+	switch {
+	case hb.LastSignal == models.HeartbeatFail:
+		msg := "job failed at " + hb.LastSignalAt.Format("2006-01-02 15:04:05")
+		if line := firstLine(hb.LastLog); line != "" {
+			msg += ": " + line
+		}
+		return Result{Status: "problem", Message: msg}
+
+	case hb.LastSignal == models.HeartbeatStart && now.After(hb.LastStartAt.Add(grace)):
+		return Result{Status: "problem", Message: fmt.Sprintf("job started at %s and did not finish within %s",
+			hb.LastStartAt.Format("2006-01-02 15:04:05"), grace)}
+
+	case hb.LastSuccessAt.IsZero():
+		if now.After(hb.CreatedAt.Add(period + grace)) {
+			return Result{Status: "problem", Message: fmt.Sprintf("no ping since %s, expected every %s",
+				hb.CreatedAt.Format("2006-01-02 15:04:05"), period)}
+		}
+		return Result{Status: "pending", Message: "waiting for the first ping"}
+
+	case now.After(hb.LastSuccessAt.Add(period + grace)):
+		return Result{Status: "problem", Message: fmt.Sprintf("last ping at %s, expected every %s",
+			hb.LastSuccessAt.Format("2006-01-02 15:04:05"), period)}
+	}
+
+	return Result{Status: "healthy", Message: "last ping at " + hb.LastSuccessAt.Format("2006-01-02 15:04:05")}
+}
+
+// firstLine returns the first non-empty line of a log, shortened for a status message
+func firstLine(log string) string {
+	for _, line := range strings.Split(log, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if runes := []rune(line); len(runes) > heartbeatLogInMessage {
+			line = string(runes[:heartbeatLogInMessage]) + "..."
+		}
+		return line
+	}
+
+	return ""
+}
@@ -0,0 +1,101 @@
+package checks
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// JSONPath returns the value at path in a JSON document, formatted as text: strings as they
+// are, other values as JSON. The path is a simple JSONPath of keys and indexes, such as
+// $.data.items[0].id or $['data']['items'][0]['id'].
+func JSONPath(document []byte, path string) (string, error) {
+	var value interface{}
+	err := json.Unmarshal(document, &value)
+	if err != nil {
+		return "", fmt.Errorf("response is not JSON: %w", err)
+	}
+
+	steps, err := parseJSONPath(path)
+	if err != nil {
+		return "", err
+	}
+
+	for _, step := range steps {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			next, ok := v[step]
+			if !ok {
+				return "", fmt.Errorf("%s: no %q", path, step)
+			}
+			value = next
+		case []interface{}:
+			i, err := strconv.Atoi(step)
+			if err != nil || i < 0 || i >= len(v) {
+				return "", fmt.Errorf("%s: no index %s", path, step)
+			}
+			value = v[i]
+		default:
+			return "", fmt.Errorf("%s: cannot look up %q in %v", path, step, v)
+		}
+	}
+
+	if s, ok := value.(string); ok {
+		return s, nil
+	}
+
+	b, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+
+	return string(b), nil
+}
+
+// parseJSONPath splits a path into keys and indexes
+func parseJSONPath(path string) ([]string, error) {
+	if !strings.HasPrefix(path, "$") {
+		return nil, fmt.Errorf("invalid JSONPath %q, it must start with $", path)
+	}
+
+	var steps []string
+	rest := path[1:]
+
+	for rest != "" {
+		switch {
+		case strings.HasPrefix(rest, "['") || strings.HasPrefix(rest, `["`):
+			quote := rest[1:2]
+			end := strings.Index(rest[2:], quote+"]")
+			if end < 0 {
+				return nil, fmt.Errorf("invalid JSONPath %q", path)
+			}
+			steps = append(steps, rest[2:2+end])
+			rest = rest[2+end+2:]
+
+		case strings.HasPrefix(rest, "["):
+			end := strings.Index(rest, "]")
+			if end < 0 {
+				return nil, fmt.Errorf("invalid JSONPath %q", path)
+			}
+			steps = append(steps, rest[1:end])
+			rest = rest[end+1:]
+
+		case strings.HasPrefix(rest, "."):
+			end := strings.IndexAny(rest[1:], ".[")
+			if end < 0 {
+				end = len(rest) - 1
+			}
+			if end == 0 {
+				return nil, fmt.Errorf("invalid JSONPath %q", path)
+			}
+			steps = append(steps, rest[1:1+end])
+			rest = rest[1+end:]
+
+		default:
+			return nil, fmt.Errorf("invalid JSONPath %q", path)
+		}
+	}
+
+	return steps, nil
+}
@@ -0,0 +1,148 @@
+package checks
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/beevik/ntp"
+	"golang-observer-project/internal/models"
+	"math"
+	"net"
+	"strconv"
+	"time"
+)
+
+// ServiceNTP is the service id of NTP checks
+const ServiceNTP = 13
+
+// DefaultNTPTimeout is the timeout of an NTP check without one, and MaxNTPTimeout the longest
+// one allowed
+const (
+	DefaultNTPTimeout = 5 * time.Second
+	MaxNTPTimeout     = 30 * time.Second
+)
+
+func init() {
+	Register(ServiceNTP, ntpChecker{})
+}
+
+// ntpChecker asks a time server for its time, and holds its clock offset and stratum to
+// thresholds
+type ntpChecker struct{}
+
+func (ntpChecker) Validate(settings json.RawMessage) error {
+	var nc models.NTPCheck
+	if err := decodeSettings(settings, &nc); err != nil {
+		return err
+	}
+
+	if err := validateAddress(nc.Address); err != nil {
+		return err
+	}
+
+	if nc.OffsetWarningMS < 0 || nc.OffsetProblemMS < 0 || nc.StratumWarning < 0 || nc.StratumProblem < 0 {
+		return errors.New("the thresholds cannot be negative")
+	}
+
+	if nc.TimeoutSeconds < 0 || time.Duration(nc.TimeoutSeconds)*time.Second > MaxNTPTimeout {
+		return fmt.Errorf("the timeout must be at most %s", MaxNTPTimeout)
+	}
+
+	return nil
+}
+
+func (ntpChecker) Secrets() []string {
+	return nil
+}
+
+func (ntpChecker) Check(t Target) Result {
+	var nc models.NTPCheck
+	if err := decodeSettings(t.Settings, &nc); err != nil {
+		return Result{Status: "problem", Message: err.Error()}
+	}
+
+	return NTP(nc, t.URL)
+}
+
+// NTP asks the time server of an NTP check for its time. An unsynchronized server, or one
+// refusing to answer, is a problem; otherwise the clock offset and stratum are held to the
+// thresholds of the check. The round trip is the total time.
+func NTP(nc models.NTPCheck, hostURL string) Result {
+	timeout := time.Duration(nc.TimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = DefaultNTPTimeout
+	}
+	if timeout > MaxNTPTimeout {
+		timeout = MaxNTPTimeout
+	}
+
+	address := hostAddress(nc.Address, hostURL, "123")
+
+	resp, err := ntp.QueryWithOptions(address, ntp.QueryOptions{Timeout: timeout})
+	if err != nil {
+		return Result{Status: "problem", Message: "no answer from " + address + ": " + err.Error()}
+	}
+
+	computeTimes := &models.ComputeTimes{TotalTime: resp.RTT}
+
+	err = resp.Validate()
+	if err != nil {
+		return Result{Status: "problem", Message: "invalid answer: " + err.Error(), ComputeTimes: computeTimes}
+	}
+
+	offsetMS := float64(resp.ClockOffset) / float64(time.Millisecond)
+	stratum := int(resp.Stratum)
+
+	result := Result{
+		Status: "healthy",
+		Message: fmt.Sprintf("offset %.3f ms, stratum %d, reference %s", offsetMS, stratum,
+			resp.ReferenceString()),
+		ComputeTimes: computeTimes,
+		Perfdata: []models.Perfdata{
+			{Label: "offset", Value: offsetMS, UOM: "ms", Warning: threshold(nc.OffsetWarningMS),
+				Critical: threshold(nc.OffsetProblemMS)},
+			{Label: "stratum", Value: float64(stratum), Warning: threshold(float64(nc.StratumWarning)),
+				Critical: threshold(float64(nc.StratumProblem))},
+			{Label: "rtt", Value: float64(resp.RTT) / float64(time.Millisecond), UOM: "ms"},
+		},
+	}
+
+	offset := math.Abs(offsetMS)
+	switch {
+	case nc.OffsetProblemMS > 0 && offset >= nc.OffsetProblemMS,
+		nc.StratumProblem > 0 && stratum >= nc.StratumProblem:
+		result.Status = "problem"
+	case nc.OffsetWarningMS > 0 && offset >= nc.OffsetWarningMS,
+		nc.StratumWarning > 0 && stratum >= nc.StratumWarning:
+		result.Status = "warning"
+	}
+
+	return result
+}
+
+// validateAddress checks an address of settings: empty, host:port or :port
+func validateAddress(address string) error {
+	if address == "" {
+		return nil
+	}
+
+	_, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return fmt.Errorf("invalid address %q, use host:port", address)
+	}
+
+	if p, err := strconv.Atoi(port); err != nil || p < 1 || p > 65535 {
+		return fmt.Errorf("invalid port in address %q", address)
+	}
+
+	return nil
+}
+
+// threshold formats a threshold for perfdata, empty when it is off
+func threshold(value float64) string {
+	if value <= 0 {
+		return ""
+	}
+
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
@@ -0,0 +1,103 @@
+package checks
+
+import (
+	"encoding/binary"
+	"strings"
+	"testing"
+	"time"
+)
+
+// ntpEpoch is where NTP timestamps start
+var ntpEpoch = time.Date(1900, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// ntpTimestamp encodes t as an NTP timestamp
+func ntpTimestamp(b []byte, t time.Time) {
+	d := t.Sub(ntpEpoch)
+	seconds := uint64(d / time.Second)
+	fraction := uint64(d%time.Second) << 32 / uint64(time.Second)
+
+	binary.BigEndian.PutUint32(b, uint32(seconds))
+	binary.BigEndian.PutUint32(b[4:], uint32(fraction))
+}
+
+// ntpResponder is a time server on 127.0.0.1 whose clock is off by offset; a stratum of 0
+// is not answered
+func ntpResponder(t *testing.T, offset time.Duration, stratum byte) string {
+	return udpResponder(t, func(request []byte) []byte {
+		if len(request) < 48 || stratum == 0 {
+			return nil
+		}
+
+		now := time.Now().Add(offset)
+
+		reply := make([]byte, 48)
+		reply[0] = 4<<3 | 4 // no leap second warning, version 4, server mode
+		reply[1] = stratum
+		reply[2] = 6                                    // poll
+		reply[3] = 0xec                                 // precision
+		binary.BigEndian.PutUint32(reply[4:], 0x10)     // root delay
+		binary.BigEndian.PutUint32(reply[8:], 0x10)     // root dispersion
+		copy(reply[12:16], "GPS\x00")                   // reference id
+		ntpTimestamp(reply[16:], now.Add(-time.Minute)) // reference time
+		copy(reply[24:32], request[40:48])              // origin time, the transmit time of the request
+		ntpTimestamp(reply[32:], now)                   // receive time
+		ntpTimestamp(reply[40:], now)                   // transmit time
+
+		return reply
+	})
+}
+
+func TestNTPCheck(t *testing.T) {
+	tests := []struct {
+		name     string
+		offset   time.Duration
+		stratum  byte
+		settings map[string]interface{}
+		status   string
+		message  string
+	}{
+		{"in sync", 0, 1, map[string]interface{}{"offset_warning_ms": 500, "offset_problem_ms": 2000},
+			"healthy", "stratum 1"},
+		{"offset warning", time.Second, 2, map[string]interface{}{"offset_warning_ms": 500, "offset_problem_ms": 2000},
+			"warning", "offset"},
+		{"offset problem", -5 * time.Second, 2, map[string]interface{}{"offset_warning_ms": 500, "offset_problem_ms": 2000},
+			"problem", "offset"},
+		{"stratum warning", 0, 4, map[string]interface{}{"stratum_warning": 3, "stratum_problem": 8},
+			"warning", "stratum 4"},
+		{"unsynchronized", 0, 16, map[string]interface{}{}, "problem", "invalid answer"},
+		{"timeout", 0, 0, map[string]interface{}{"timeout_seconds": 1}, "problem", "no answer"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.settings["address"] = ntpResponder(t, tt.offset, tt.stratum)
+
+			result := Check(Target{ServiceID: ServiceNTP, URL: "http://127.0.0.1",
+				Settings: settings(t, tt.settings)})
+
+			if result.Status != tt.status || !strings.Contains(result.Message, tt.message) {
+				t.Errorf("got %s %q, want %s containing %q", result.Status, result.Message, tt.status, tt.message)
+			}
+		})
+	}
+}
+
+func TestNTPCheckPerfdata(t *testing.T) {
+	address := ntpResponder(t, time.Second, 1)
+
+	result := Check(Target{ServiceID: ServiceNTP, URL: "http://127.0.0.1",
+		Settings: settings(t, map[string]interface{}{"address": address, "offset_warning_ms": 500})})
+
+	if len(result.Perfdata) != 3 {
+		t.Fatalf("got %d perfdata, want offset, stratum and rtt", len(result.Perfdata))
+	}
+
+	offset := result.Perfdata[0]
+	if offset.Label != "offset" || offset.UOM != "ms" || offset.Warning != "500" || offset.Critical != "" {
+		t.Errorf("offset perfdata %+v", offset)
+	}
+	// the clock of the stub is a second ahead, give or take the round trip
+	if offset.Value < 900 || offset.Value > 1100 {
+		t.Errorf("offset %.3f ms, want about 1000", offset.Value)
+	}
+}
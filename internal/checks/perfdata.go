@@ -0,0 +1,130 @@
+package checks
+
+import (
+	"golang-observer-project/internal/models"
+	"strconv"
+	"strings"
+)
+
+// ParsePluginOutput splits the output of a Nagios plugin into the text of its first line and
+// its perfdata: what follows the | of the first line, and of the long output after its own |
+//
+//	DISK OK - free space: / 3326 MB (56%); | /=2643MB;5948;5958;0;5968
+//	/ 15272 MB (77%);
+//	/boot 68 MB (69%); | /boot=68MB;88;93;0;98
+//	/home=69357MB;253404;253409;0;253414
+func ParsePluginOutput(output string) (string, []models.Perfdata) {
+	lines := strings.Split(strings.TrimRight(output, "\r\n"), "\n")
+
+	text, perf, _ := strings.Cut(lines[0], "|")
+	text = strings.TrimSpace(text)
+
+	inPerfdata := false
+	for _, line := range lines[1:] {
+		if inPerfdata {
+			perf += " " + line
+			continue
+		}
+		if _, more, found := strings.Cut(line, "|"); found {
+			perf += " " + more
+			inPerfdata = true
+		}
+	}
+
+	return text, ParsePerfdata(perf)
+}
+
+// ParsePerfdata parses perfdata items 'label'=value[UOM];[warn];[crit];[min];[max]; items
+// without a number as their value, such as U, are left out
+func ParsePerfdata(perf string) []models.Perfdata {
+	var items []models.Perfdata
+
+	for _, item := range splitPerfdata(perf) {
+		// a quoted label may contain =, a value never does
+		eq := strings.LastIndex(item, "=")
+		if eq < 1 {
+			continue
+		}
+		label, data := item[:eq], item[eq+1:]
+
+		fields := strings.Split(data, ";")
+
+		value, uom := splitUOM(fields[0])
+		v, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			continue
+		}
+
+		p := models.Perfdata{Label: label, Value: v, UOM: uom}
+		if len(fields) > 1 {
+			p.Warning = fields[1]
+		}
+		if len(fields) > 2 {
+			p.Critical = fields[2]
+		}
+		if len(fields) > 3 {
+			p.Min = parseOptionalFloat(fields[3])
+		}
+		if len(fields) > 4 {
+			p.Max = parseOptionalFloat(fields[4])
+		}
+
+		items = append(items, p)
+	}
+
+	return items
+}
+
+// splitPerfdata splits perfdata on spaces outside quoted labels, and unquotes the labels; a
+// quote inside a quoted label is written twice
+func splitPerfdata(perf string) []string {
+	var items []string
+	var item strings.Builder
+	quoted := false
+
+	for i := 0; i < len(perf); i++ {
+		c := perf[i]
+		switch {
+		case c == '\'' && quoted && i+1 < len(perf) && perf[i+1] == '\'':
+			item.WriteByte('\'')
+			i++
+		case c == '\'':
+			quoted = !quoted
+		case (c == ' ' || c == '\t' || c == '\n' || c == '\r') && !quoted:
+			if item.Len() > 0 {
+				items = append(items, item.String())
+				item.Reset()
+			}
+		default:
+			item.WriteByte(c)
+		}
+	}
+
+	if item.Len() > 0 {
+		items = append(items, item.String())
+	}
+
+	return items
+}
+
+// splitUOM splits a value such as 2643MB or 56% into the number and its unit of measurement
+func splitUOM(value string) (string, string) {
+	end := strings.IndexFunc(value, func(r rune) bool {
+		return !strings.ContainsRune("0123456789.-+eE", r)
+	})
+	if end < 0 {
+		return value, ""
+	}
+
+	return value[:end], value[end:]
+}
+
+// parseOptionalFloat parses the min or max of a perfdata item, which may be left empty
+func parseOptionalFloat(value string) *float64 {
+	v, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return nil
+	}
+
+	return &v
+}
@@ -0,0 +1,391 @@
+package checks
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gosnmp/gosnmp"
+	"golang-observer-project/internal/models"
+	"math/big"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ServiceSNMP is the service id of SNMP checks
+const ServiceSNMP = 14
+
+// DefaultSNMPTimeout is the timeout of an SNMP check without one, and MaxSNMPTimeout the
+// longest one allowed
+const (
+	DefaultSNMPTimeout = 5 * time.Second
+	MaxSNMPTimeout     = 30 * time.Second
+)
+
+// maxSNMPOIDs is how many OIDs an SNMP check may get, the most one request usually carries
+const maxSNMPOIDs = gosnmp.MaxOids
+
+// SNMP versions and security levels
+const (
+	SNMPVersion2c = "2c"
+	SNMPVersion3  = "3"
+
+	SNMPNoAuthNoPriv = "noAuthNoPriv"
+	SNMPAuthNoPriv   = "authNoPriv"
+	SNMPAuthPriv     = "authPriv"
+)
+
+// snmpAuthProtocols and snmpPrivProtocols are the SNMPv3 protocols by name
+var (
+	snmpAuthProtocols = map[string]gosnmp.SnmpV3AuthProtocol{
+		"MD5":    gosnmp.MD5,
+		"SHA":    gosnmp.SHA,
+		"SHA224": gosnmp.SHA224,
+		"SHA256": gosnmp.SHA256,
+		"SHA384": gosnmp.SHA384,
+		"SHA512": gosnmp.SHA512,
+	}
+	snmpPrivProtocols = map[string]gosnmp.SnmpV3PrivProtocol{
+		"DES":     gosnmp.DES,
+		"AES":     gosnmp.AES,
+		"AES192":  gosnmp.AES192,
+		"AES256":  gosnmp.AES256,
+		"AES192C": gosnmp.AES192C,
+		"AES256C": gosnmp.AES256C,
+	}
+)
+
+func init() {
+	Register(ServiceSNMP, snmpChecker{})
+}
+
+// snmpChecker gets values over SNMP v2c or v3 and holds them to thresholds
+type snmpChecker struct{}
+
+func (snmpChecker) Validate(settings json.RawMessage) error {
+	var sc models.SNMPCheck
+	if err := decodeSettings(settings, &sc); err != nil {
+		return err
+	}
+
+	if err := validateAddress(sc.Address); err != nil {
+		return err
+	}
+
+	if sc.TimeoutSeconds < 0 || time.Duration(sc.TimeoutSeconds)*time.Second > MaxSNMPTimeout {
+		return fmt.Errorf("the timeout must be at most %s", MaxSNMPTimeout)
+	}
+
+	if _, err := snmpClient(sc, "localhost:161", time.Second); err != nil {
+		return err
+	}
+
+	if len(sc.OIDs) == 0 {
+		return errors.New("an SNMP check needs at least one OID")
+	}
+	if len(sc.OIDs) > maxSNMPOIDs {
+		return fmt.Errorf("an SNMP check gets at most %d OIDs", maxSNMPOIDs)
+	}
+
+	for _, o := range sc.OIDs {
+		if !validOID(o.OID) {
+			return fmt.Errorf("invalid OID %q, use numbers such as 1.3.6.1.2.1.1.3.0", o.OID)
+		}
+		if o.Expect != "" && (o.Warning != nil || o.Problem != nil) {
+			return fmt.Errorf("%s has both an expected value and thresholds", o.OID)
+		}
+	}
+
+	return nil
+}
+
+func (snmpChecker) Secrets() []string {
+	return []string{"community", "auth_passphrase", "priv_passphrase"}
+}
+
+func (snmpChecker) Check(t Target) Result {
+	var sc models.SNMPCheck
+	if err := decodeSettings(t.Settings, &sc); err != nil {
+		return Result{Status: "problem", Message: err.Error()}
+	}
+
+	return SNMP(sc, t.URL)
+}
+
+// SNMP gets the OIDs of an SNMP check in one request. A missing value is a problem; numeric
+// values are held to their thresholds and string values to their expected value. The request is
+// the total time.
+func SNMP(sc models.SNMPCheck, hostURL string) Result {
+	if len(sc.OIDs) == 0 {
+		return Result{Status: "problem", Message: "no OIDs set"}
+	}
+
+	timeout := time.Duration(sc.TimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = DefaultSNMPTimeout
+	}
+	if timeout > MaxSNMPTimeout {
+		timeout = MaxSNMPTimeout
+	}
+
+	client, err := snmpClient(sc, hostAddress(sc.Address, hostURL, "161"), timeout)
+	if err != nil {
+		return Result{Status: "problem", Message: err.Error()}
+	}
+
+	err = client.Connect()
+	if err != nil {
+		return Result{Status: "problem", Message: "cannot connect: " + err.Error()}
+	}
+
+	defer func(client *gosnmp.GoSNMP) {
+		_ = client.Conn.Close()
+	}(client)
+
+	oids := make([]string, 0, len(sc.OIDs))
+	for _, o := range sc.OIDs {
+		oids = append(oids, normalizeOID(o.OID))
+	}
+
+	start := time.Now()
+	packet, err := client.Get(oids)
+	computeTimes := &models.ComputeTimes{TotalTime: time.Since(start)}
+	if err != nil {
+		return Result{Status: "problem", Message: "no answer: " + err.Error(), ComputeTimes: computeTimes}
+	}
+	if packet.Error != gosnmp.NoError {
+		return Result{Status: "problem", Message: "the agent answered " + packet.Error.String(),
+			ComputeTimes: computeTimes}
+	}
+
+	values := make(map[string]gosnmp.SnmpPDU)
+	for _, v := range packet.Variables {
+		values[normalizeOID(v.Name)] = v
+	}
+
+	result := Result{Status: "healthy", ComputeTimes: computeTimes}
+	var messages []string
+
+	for _, o := range sc.OIDs {
+		label := o.Label
+		if label == "" {
+			label = o.OID
+		}
+
+		status, message, perfdata := snmpValue(o, label, values[normalizeOID(o.OID)])
+		messages = append(messages, message)
+		if perfdata != nil {
+			result.Perfdata = append(result.Perfdata, *perfdata)
+		}
+		result.Status = worseStatus(result.Status, status)
+	}
+
+	result.Message = strings.Join(messages, ", ")
+
+	return result
+}
+
+// snmpValue holds the value got for an OID to its thresholds or expected value
+func snmpValue(o models.SNMPOID, label string, v gosnmp.SnmpPDU) (string, string, *models.Perfdata) {
+	switch v.Type {
+	case gosnmp.NoSuchObject, gosnmp.NoSuchInstance, gosnmp.EndOfMibView, gosnmp.Null:
+		return "problem", label + " has no value", nil
+	}
+
+	value, numeric := snmpNumber(v)
+
+	if !numeric || o.Expect != "" {
+		text := snmpText(v)
+		if o.Expect != "" && text != o.Expect {
+			return "problem", fmt.Sprintf("%s is %q, expected %q", label, text, o.Expect), nil
+		}
+		return "healthy", fmt.Sprintf("%s is %q", label, text), nil
+	}
+
+	perfdata := &models.Perfdata{Label: label, Value: value}
+	message := fmt.Sprintf("%s is %s", label, strconv.FormatFloat(value, 'f', -1, 64))
+	if o.Warning != nil {
+		perfdata.Warning = snmpRange(*o.Warning, o.Below)
+	}
+	if o.Problem != nil {
+		perfdata.Critical = snmpRange(*o.Problem, o.Below)
+	}
+
+	reached := func(limit *float64) bool {
+		if limit == nil {
+			return false
+		}
+		if o.Below {
+			return value <= *limit
+		}
+		return value >= *limit
+	}
+
+	switch {
+	case reached(o.Problem):
+		return "problem", message, perfdata
+	case reached(o.Warning):
+		return "warning", message, perfdata
+	}
+
+	return "healthy", message, perfdata
+}
+
+// snmpNumber returns the value of a numeric SNMP variable
+func snmpNumber(v gosnmp.SnmpPDU) (float64, bool) {
+	switch v.Type {
+	case gosnmp.Integer, gosnmp.Counter32, gosnmp.Gauge32, gosnmp.TimeTicks, gosnmp.Counter64,
+		gosnmp.Uinteger32:
+		f, _ := new(big.Float).SetInt(gosnmp.ToBigInt(v.Value)).Float64()
+		return f, true
+	case gosnmp.OpaqueFloat:
+		f, ok := v.Value.(float32)
+		return float64(f), ok
+	case gosnmp.OpaqueDouble:
+		f, ok := v.Value.(float64)
+		return f, ok
+	}
+
+	return 0, false
+}
+
+// snmpText returns the value of an SNMP variable as text
+func snmpText(v gosnmp.SnmpPDU) string {
+	if b, ok := v.Value.([]byte); ok {
+		return string(b)
+	}
+
+	return fmt.Sprint(v.Value)
+}
+
+// snmpRange is a threshold as a Nagios range for perfdata: 10 alerts above 10, and 10: below
+func snmpRange(limit float64, below bool) string {
+	s := strconv.FormatFloat(limit, 'f', -1, 64)
+	if below {
+		return s + ":"
+	}
+
+	return s
+}
+
+// snmpClient returns the client of an SNMP check for address
+func snmpClient(sc models.SNMPCheck, address string, timeout time.Duration) (*gosnmp.GoSNMP, error) {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+	portNumber, err := strconv.ParseUint(port, 10, 16)
+	if err != nil {
+		return nil, fmt.Errorf("invalid port in address %q", address)
+	}
+
+	client := &gosnmp.GoSNMP{
+		Target:             host,
+		Port:               uint16(portNumber),
+		Transport:          "udp",
+		Timeout:            timeout,
+		Retries:            0,
+		MaxOids:            maxSNMPOIDs,
+		ExponentialTimeout: false,
+	}
+
+	switch sc.Version {
+	case "", SNMPVersion2c:
+		if sc.Username != "" || sc.AuthPassphrase != "" || sc.PrivPassphrase != "" {
+			return nil, errors.New("users and passphrases are for SNMPv3, set version 3")
+		}
+		client.Version = gosnmp.Version2c
+		client.Community = sc.Community
+		if client.Community == "" {
+			client.Community = "public"
+		}
+		return client, nil
+
+	case SNMPVersion3:
+	default:
+		return nil, fmt.Errorf("invalid SNMP version %q, use 2c or 3", sc.Version)
+	}
+
+	if sc.Username == "" {
+		return nil, errors.New("SNMPv3 needs a user")
+	}
+
+	usm := &gosnmp.UsmSecurityParameters{
+		UserName:               sc.Username,
+		AuthenticationProtocol: gosnmp.NoAuth,
+		PrivacyProtocol:        gosnmp.NoPriv,
+	}
+
+	switch sc.SecurityLevel {
+	case "", SNMPNoAuthNoPriv:
+		client.MsgFlags = gosnmp.NoAuthNoPriv
+	case SNMPAuthNoPriv, SNMPAuthPriv:
+		auth, ok := snmpAuthProtocols[strings.ToUpper(sc.AuthProtocol)]
+		if !ok {
+			return nil, fmt.Errorf("invalid auth protocol %q, use MD5, SHA, SHA224, SHA256, SHA384 or SHA512",
+				sc.AuthProtocol)
+		}
+		if len(sc.AuthPassphrase) < 8 {
+			return nil, errors.New("the auth passphrase needs at least 8 characters")
+		}
+		usm.AuthenticationProtocol = auth
+		usm.AuthenticationPassphrase = sc.AuthPassphrase
+		client.MsgFlags = gosnmp.AuthNoPriv
+
+		if sc.SecurityLevel == SNMPAuthPriv {
+			priv, ok := snmpPrivProtocols[strings.ToUpper(sc.PrivProtocol)]
+			if !ok {
+				return nil, fmt.Errorf("invalid privacy protocol %q, use DES, AES, AES192, AES256, AES192C or AES256C",
+					sc.PrivProtocol)
+			}
+			if len(sc.PrivPassphrase) < 8 {
+				return nil, errors.New("the privacy passphrase needs at least 8 characters")
+			}
+			usm.PrivacyProtocol = priv
+			usm.PrivacyPassphrase = sc.PrivPassphrase
+			client.MsgFlags = gosnmp.AuthPriv
+		}
+	default:
+		return nil, fmt.Errorf("invalid security level %q, use noAuthNoPriv, authNoPriv or authPriv",
+			sc.SecurityLevel)
+	}
+
+	client.Version = gosnmp.Version3
+	client.SecurityModel = gosnmp.UserSecurityModel
+	client.SecurityParameters = usm
+	client.ContextName = sc.ContextName
+
+	return client, nil
+}
+
+// validOID reports whether an OID is dotted numbers, with or without a leading dot
+func validOID(oid string) bool {
+	oid = strings.TrimPrefix(oid, ".")
+	if oid == "" {
+		return false
+	}
+
+	for _, part := range strings.Split(oid, ".") {
+		if _, err := strconv.ParseUint(part, 10, 32); err != nil {
+			return false
+		}
+	}
+
+	return true
+}
+
+// normalizeOID gives an OID the leading dot agents answer with
+func normalizeOID(oid string) string {
+	return "." + strings.TrimPrefix(oid, ".")
+}
+
+// worseStatus returns the worse of two statuses
+func worseStatus(a, b string) string {
+	rank := map[string]int{"healthy": 0, "pending": 1, "warning": 2, "problem": 3}
+	if rank[b] > rank[a] {
+		return b
+	}
+
+	return a
+}
@@ -0,0 +1,124 @@
+package checks
+
+import (
+	"github.com/gosnmp/gosnmp"
+	"strings"
+	"testing"
+)
+
+// snmpResponder is an SNMP v2c agent on 127.0.0.1 answering GET requests with the community
+// "public" from values, by OID; unknown OIDs have no such object. Requests with another
+// community are not answered, as real agents do.
+func snmpResponder(t *testing.T, values map[string]gosnmp.SnmpPDU) string {
+	return udpResponder(t, func(request []byte) []byte {
+		decoder := &gosnmp.GoSNMP{}
+		packet, err := decoder.SnmpDecodePacket(request)
+		if err != nil || packet.Community != "public" || packet.PDUType != gosnmp.GetRequest {
+			return nil
+		}
+
+		variables := make([]gosnmp.SnmpPDU, 0, len(packet.Variables))
+		for _, v := range packet.Variables {
+			value, ok := values[v.Name]
+			if !ok {
+				value = gosnmp.SnmpPDU{Type: gosnmp.NoSuchObject}
+			}
+			value.Name = v.Name
+			variables = append(variables, value)
+		}
+
+		reply := &gosnmp.SnmpPacket{
+			Version:   packet.Version,
+			Community: packet.Community,
+			PDUType:   gosnmp.GetResponse,
+			RequestID: packet.RequestID,
+			Variables: variables,
+		}
+
+		b, err := reply.MarshalMsg()
+		if err != nil {
+			t.Error(err)
+			return nil
+		}
+
+		return b
+	})
+}
+
+func TestSNMPCheck(t *testing.T) {
+	address := snmpResponder(t, map[string]gosnmp.SnmpPDU{
+		".1.3.6.1.2.1.1.5.0":          {Type: gosnmp.OctetString, Value: []byte("switch-1")},
+		".1.3.6.1.2.1.1.3.0":          {Type: gosnmp.TimeTicks, Value: uint32(123456)},
+		".1.3.6.1.4.1.2021.10.1.5.1":  {Type: gosnmp.Integer, Value: 85},
+		".1.3.6.1.4.1.2021.4.6.0":     {Type: gosnmp.Gauge32, Value: uint(2048)},
+		".1.3.6.1.2.1.2.2.1.10.1":     {Type: gosnmp.Counter32, Value: uint(1000)},
+		".1.3.6.1.4.1.2021.9.1.100.1": {Type: gosnmp.Integer, Value: 0},
+	})
+
+	warning, problem := 80.0, 95.0
+	lowWarning, lowProblem := 4096.0, 1024.0
+
+	tests := []struct {
+		name     string
+		settings map[string]interface{}
+		status   string
+		message  string
+	}{
+		{"string and number", map[string]interface{}{"oids": []map[string]interface{}{
+			{"oid": "1.3.6.1.2.1.1.5.0", "label": "name", "expect": "switch-1"},
+			{"oid": ".1.3.6.1.2.1.1.3.0", "label": "uptime"},
+		}}, "healthy", `name is "switch-1", uptime is 123456`},
+		{"above warning", map[string]interface{}{"oids": []map[string]interface{}{
+			{"oid": "1.3.6.1.4.1.2021.10.1.5.1", "label": "load", "warning": warning, "problem": problem},
+		}}, "warning", "load is 85"},
+		{"below warning", map[string]interface{}{"oids": []map[string]interface{}{
+			{"oid": "1.3.6.1.4.1.2021.4.6.0", "label": "free", "warning": lowWarning, "problem": lowProblem, "below": true},
+			{"oid": "1.3.6.1.2.1.2.2.1.10.1", "label": "in"},
+		}}, "warning", "free is 2048, in is 1000"},
+		{"unexpected value", map[string]interface{}{"oids": []map[string]interface{}{
+			{"oid": "1.3.6.1.2.1.1.5.0", "expect": "switch-2"},
+		}}, "problem", `expected "switch-2"`},
+		{"no such object", map[string]interface{}{"oids": []map[string]interface{}{
+			{"oid": "1.3.6.1.4.1.2021.9.1.100.1", "problem": 1.0},
+			{"oid": "1.3.6.1.2.1.1.99.0", "label": "missing"},
+		}}, "problem", "missing has no value"},
+		{"timeout", map[string]interface{}{"community": "private", "timeout_seconds": 1,
+			"oids": []map[string]interface{}{{"oid": "1.3.6.1.2.1.1.5.0"}}}, "problem", "no answer"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.settings["address"] = address
+
+			result := Check(Target{ServiceID: ServiceSNMP, URL: "http://127.0.0.1",
+				Settings: settings(t, tt.settings)})
+
+			if result.Status != tt.status || !strings.Contains(result.Message, tt.message) {
+				t.Errorf("got %s %q, want %s containing %q", result.Status, result.Message, tt.status, tt.message)
+			}
+		})
+	}
+}
+
+func TestSNMPCheckPerfdata(t *testing.T) {
+	address := snmpResponder(t, map[string]gosnmp.SnmpPDU{
+		".1.3.6.1.4.1.2021.4.6.0": {Type: gosnmp.Gauge32, Value: uint(512)},
+	})
+
+	warning, problem := 4096.0, 1024.0
+	result := Check(Target{ServiceID: ServiceSNMP, URL: "http://127.0.0.1",
+		Settings: settings(t, map[string]interface{}{"address": address, "oids": []map[string]interface{}{
+			{"oid": "1.3.6.1.4.1.2021.4.6.0", "label": "free", "warning": warning, "problem": problem, "below": true},
+		}})})
+
+	if result.Status != "problem" {
+		t.Errorf("got %s %q, want problem", result.Status, result.Message)
+	}
+
+	if len(result.Perfdata) != 1 {
+		t.Fatalf("got %d perfdata, want 1", len(result.Perfdata))
+	}
+	if p := result.Perfdata[0]; p.Label != "free" || p.Value != 512 || p.Warning != "4096:" || p.Critical != "1024:" {
+		t.Errorf("perfdata %+v", p)
+	}
+}
@@ -0,0 +1,134 @@
+package checks
+
+import (
+	"fmt"
+	"golang-observer-project/internal/models"
+	"strings"
+	"time"
+)
+
+// ServiceSystem is the service id of the system of a host, reported by its host agent
+const ServiceSystem = 5
+
+// System metrics that can have thresholds
+const (
+	MetricCPU    = "cpu"
+	MetricMemory = "memory"
+	MetricDisk   = "disk"
+	// MetricLoad is the 1 minute load average per CPU
+	MetricLoad = "load"
+)
+
+// systemMissedReports is how many reports an agent can miss before its host is a problem
+const systemMissedReports = 3
+
+// DefaultThresholds apply to the metrics a host service has no threshold of its own for
+var DefaultThresholds = []models.MetricThreshold{
+	{Metric: MetricCPU, Warning: 85, Problem: 95},
+	{Metric: MetricMemory, Warning: 85, Problem: 95},
+	{Metric: MetricDisk, Warning: 85, Problem: 95},
+	{Metric: MetricLoad, Warning: 1.5, Problem: 3},
+}
+
+// IsMetric reports whether a metric can have a threshold
+func IsMetric(metric string) bool {
+	for _, t := range DefaultThresholds {
+		if t.Metric == metric {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Thresholds returns the thresholds of every metric: the custom one when there is one, the
+// default one otherwise
+func Thresholds(custom []models.MetricThreshold) []models.MetricThreshold {
+	thresholds := make([]models.MetricThreshold, 0, len(DefaultThresholds))
+
+	for _, t := range DefaultThresholds {
+		for _, c := range custom {
+			if c.Metric == t.Metric {
+				t = c
+				break
+			}
+		}
+		thresholds = append(thresholds, t)
+	}
+
+	return thresholds
+}
+
+// System decides the status of the system of a host at now from the latest metrics of its
+// agent: a problem when the agent missed several reports, otherwise the worst status of the
+// metrics against their thresholds
+func System(agent models.HostAgent, custom []models.MetricThreshold, now time.Time) Result {
+	staleAfter := time.Duration(systemMissedReports*agent.IntervalSeconds) * time.Second
+
+	if agent.LastSeenAt.IsZero() {
+		if now.After(agent.CreatedAt.Add(staleAfter)) {
+			return Result{Status: "problem", Message: fmt.Sprintf("no metrics since %s, expected every %s",
+				agent.CreatedAt.Format("2006-01-02 15:04:05"), time.Duration(agent.IntervalSeconds)*time.Second)}
+		}
+		return Result{Status: "pending", Message: "waiting for the first metrics"}
+	}
+
+	if now.After(agent.LastSeenAt.Add(staleAfter)) {
+		return Result{Status: "problem", Message: fmt.Sprintf("last metrics at %s, expected every %s",
+			agent.LastSeenAt.Format("2006-01-02 15:04:05"), time.Duration(agent.IntervalSeconds)*time.Second)}
+	}
+
+	m := agent.LastMetrics
+	status := "healthy"
+	var exceeded, values []string
+
+	for _, t := range Thresholds(custom) {
+		value, label := metricValue(m, t.Metric)
+		values = append(values, label)
+
+		switch {
+		case value >= t.Problem:
+			status = "problem"
+			exceeded = append(exceeded, fmt.Sprintf("%s (problem at %g)", label, t.Problem))
+		case value >= t.Warning:
+			if status == "healthy" {
+				status = "warning"
+			}
+			exceeded = append(exceeded, fmt.Sprintf("%s (warning at %g)", label, t.Warning))
+		}
+	}
+
+	if len(exceeded) > 0 {
+		return Result{Status: status, Message: strings.Join(exceeded, ", ")}
+	}
+
+	return Result{Status: status, Message: strings.Join(values, ", ")}
+}
+
+// metricValue returns the value of a metric and a label for status messages; disk is the
+// fullest file system
+func metricValue(m models.HostMetrics, metric string) (float64, string) {
+	switch metric {
+	case MetricCPU:
+		return m.CPUPercent, fmt.Sprintf("cpu %.1f%%", m.CPUPercent)
+	case MetricMemory:
+		return m.MemoryPercent, fmt.Sprintf("memory %.1f%%", m.MemoryPercent)
+	case MetricDisk:
+		var fullest models.DiskUsage
+		for _, d := range m.Disks {
+			if d.UsedPercent >= fullest.UsedPercent {
+				fullest = d
+			}
+		}
+		return fullest.UsedPercent, fmt.Sprintf("disk %s %.1f%%", fullest.Path, fullest.UsedPercent)
+	case MetricLoad:
+		cpus := m.CPUs
+		if cpus < 1 {
+			cpus = 1
+		}
+		load := m.Load1 / float64(cpus)
+		return load, fmt.Sprintf("load %.2f per cpu", load)
+	}
+
+	return 0, metric
+}
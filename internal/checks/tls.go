@@ -0,0 +1,41 @@
+package checks
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net"
+)
+
+// clientTLS returns the TLS configuration of a TLS mode for a connection to address, nil
+// without TLS. caPEM replaces the system roots when verifying, serverName the host of address,
+// and certPEM and keyPEM are a client certificate for mutual TLS.
+func clientTLS(mode, address, serverName, caPEM, certPEM, keyPEM string) (*tls.Config, error) {
+	if mode != TLSRequire && mode != TLSVerify {
+		return nil, nil
+	}
+
+	if serverName == "" {
+		serverName, _, _ = net.SplitHostPort(address)
+	}
+
+	config := &tls.Config{ServerName: serverName, InsecureSkipVerify: mode == TLSRequire}
+
+	if caPEM != "" {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM([]byte(caPEM)) {
+			return nil, errors.New("the CA certificate is not a PEM certificate")
+		}
+		config.RootCAs = pool
+	}
+
+	if certPEM != "" || keyPEM != "" {
+		cert, err := tls.X509KeyPair([]byte(certPEM), []byte(keyPEM))
+		if err != nil {
+			return nil, errors.New("invalid client certificate: " + err.Error())
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	return config, nil
+}
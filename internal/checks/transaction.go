@@ -0,0 +1,351 @@
+package checks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"golang-observer-project/internal/models"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptrace"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ServiceTransaction is the service id of transactions, ordered HTTP steps such as login, add
+// to cart and checkout
+const ServiceTransaction = 7
+
+// DefaultTransactionTimeout is the timeout of a whole transaction without one, and
+// MaxTransactionTimeout the longest one allowed
+const (
+	DefaultTransactionTimeout = 30 * time.Second
+	MaxTransactionTimeout     = 5 * time.Minute
+)
+
+// maxStepBody is how much of a response is read for assertions and extraction
+const maxStepBody = 1024 * 1024
+
+// stepVariable is a variable in a step, {{name}}
+var stepVariable = regexp.MustCompile(`\{\{\s*([A-Za-z0-9_.-]+)\s*\}\}`)
+
+// Kinds of extraction and assertion of transaction steps
+const (
+	ExtractJSON   = "json"
+	ExtractRegex  = "regex"
+	ExtractHeader = "header"
+
+	AssertStatus       = "status"
+	AssertBodyContains = "body_contains"
+	AssertBodyRegex    = "body_regex"
+	AssertHeader       = "header"
+	AssertJSON         = "json"
+	AssertMaxTimeMS    = "max_time_ms"
+)
+
+func init() {
+	Register(ServiceTransaction, CheckerFunc(func(t Target) Result {
+		if !t.hasSettings() {
+			return Result{Status: "problem", Message: "no steps set"}
+		}
+
+		var transaction models.Transaction
+		if err := decodeSettings(t.Settings, &transaction); err != nil {
+			return Result{Status: "problem", Message: err.Error()}
+		}
+
+		return Transaction(transaction, t.URL)
+	}))
+}
+
+// ValidateTransaction checks that the steps of a transaction can run: a method and URL each,
+// and known extractions and assertions with valid expressions
+func ValidateTransaction(t models.Transaction) error {
+	if len(t.Steps) == 0 {
+		return errors.New("a transaction needs at least one step")
+	}
+
+	for i, step := range t.Steps {
+		name := stepName(i, step)
+
+		if step.URL == "" {
+			return fmt.Errorf("%s has no URL", name)
+		}
+
+		switch strings.ToUpper(step.Method) {
+		case "", http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+			http.MethodDelete, http.MethodOptions:
+		default:
+			return fmt.Errorf("%s has an invalid method %q", name, step.Method)
+		}
+
+		for _, e := range step.Extract {
+			if e.Name == "" || !stepVariable.MatchString("{{"+e.Name+"}}") {
+				return fmt.Errorf("%s extracts a variable without a valid name", name)
+			}
+
+			var err error
+			switch e.From {
+			case ExtractJSON:
+				_, err = parseJSONPath(e.Expression)
+			case ExtractRegex:
+				_, err = regexp.Compile(e.Expression)
+			case ExtractHeader:
+				if e.Expression == "" {
+					err = errors.New("no header name")
+				}
+			default:
+				err = fmt.Errorf("unknown source %q, use json, regex or header", e.From)
+			}
+			if err != nil {
+				return fmt.Errorf("%s cannot extract %s: %w", name, e.Name, err)
+			}
+		}
+
+		for _, a := range step.Assertions {
+			var err error
+			switch a.Type {
+			case AssertStatus, AssertMaxTimeMS:
+				_, err = strconv.Atoi(a.Value)
+			case AssertBodyContains:
+			case AssertBodyRegex:
+				_, err = regexp.Compile(a.Value)
+			case AssertHeader:
+				if a.Target == "" {
+					err = errors.New("no header name")
+				}
+			case AssertJSON:
+				_, err = parseJSONPath(a.Target)
+			default:
+				err = fmt.Errorf("unknown assertion %q", a.Type)
+			}
+			if err != nil {
+				return fmt.Errorf("%s has an invalid %s assertion: %w", name, a.Type, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Transaction runs the steps of a transaction in order with one cookie jar, and stops at the
+// first step that fails. Steps return their timings whether they passed or not.
+func Transaction(t models.Transaction, hostURL string) Result {
+	timeout := time.Duration(t.TimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = DefaultTransactionTimeout
+	}
+	if timeout > MaxTransactionTimeout {
+		timeout = MaxTransactionTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return Result{Status: "problem", Message: err.Error()}
+	}
+	client := &http.Client{Jar: jar}
+
+	variables := make(map[string]string)
+	var steps []models.StepTimes
+	var total time.Duration
+
+	for i, step := range t.Steps {
+		st := models.StepTimes{Step: i + 1, StepName: stepName(i, step)}
+
+		err := runStep(ctx, client, step, hostURL, variables, &st)
+		if err != nil {
+			st.Error = err.Error()
+			steps = append(steps, st)
+			return Result{Status: "problem", Message: fmt.Sprintf("%s failed: %s", st.StepName, err), Steps: steps}
+		}
+
+		st.Passed = true
+		steps = append(steps, st)
+		total += st.TotalTime
+	}
+
+	return Result{
+		Status:  "healthy",
+		Message: fmt.Sprintf("%d steps passed in %s", len(steps), total.Round(time.Millisecond)),
+		Steps:   steps,
+	}
+}
+
+// stepName names a step for messages: step 2 (add to cart)
+func stepName(i int, step models.TransactionStep) string {
+	if step.Name == "" {
+		return fmt.Sprintf("step %d", i+1)
+	}
+
+	return fmt.Sprintf("step %d (%s)", i+1, step.Name)
+}
+
+// runStep makes the request of a step, timing it in st, checks its assertions and extracts
+// its variables
+func runStep(ctx context.Context, client *http.Client, step models.TransactionStep, hostURL string,
+	variables map[string]string, st *models.StepTimes) error {
+	stepURL := substitute(step.URL, variables)
+	if strings.HasPrefix(stepURL, "/") {
+		stepURL = strings.TrimSuffix(hostURL, "/") + stepURL
+	}
+
+	method := strings.ToUpper(step.Method)
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	var body io.Reader
+	if step.Body != "" {
+		body = strings.NewReader(substitute(step.Body, variables))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, stepURL, body)
+	if err != nil {
+		return err
+	}
+	for name, value := range step.Headers {
+		req.Header.Set(name, substitute(value, variables))
+	}
+
+	var start time.Time
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), timingTrace(&st.ComputeTimes, &start)))
+	start = time.Now()
+
+	resp, err := client.Do(req)
+	if err != nil {
+		st.TotalTime = time.Since(start)
+		return err
+	}
+
+	defer func(resp *http.Response) {
+		_ = resp.Body.Close()
+	}(resp)
+
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, maxStepBody))
+	st.TotalTime = time.Since(start)
+	st.ResponseStatus = resp.StatusCode
+	if err != nil {
+		return err
+	}
+
+	err = assertStep(step, resp, respBody, st.TotalTime)
+	if err != nil {
+		return err
+	}
+
+	for _, e := range step.Extract {
+		value, err := extract(e, resp, respBody)
+		if err != nil {
+			return fmt.Errorf("cannot extract %s: %w", e.Name, err)
+		}
+		variables[e.Name] = value
+	}
+
+	return nil
+}
+
+// assertStep checks the assertions of a step; without a status assertion, the status must be
+// below 400
+func assertStep(step models.TransactionStep, resp *http.Response, body []byte, took time.Duration) error {
+	hasStatus := false
+
+	for _, a := range step.Assertions {
+		switch a.Type {
+		case AssertStatus:
+			hasStatus = true
+			if want, _ := strconv.Atoi(a.Value); resp.StatusCode != want {
+				return fmt.Errorf("expected status %d, got %s", want, resp.Status)
+			}
+
+		case AssertBodyContains:
+			if !strings.Contains(string(body), a.Value) {
+				return fmt.Errorf("body does not contain %q", a.Value)
+			}
+
+		case AssertBodyRegex:
+			re, err := regexp.Compile(a.Value)
+			if err != nil {
+				return err
+			}
+			if !re.Match(body) {
+				return fmt.Errorf("body does not match %q", a.Value)
+			}
+
+		case AssertHeader:
+			if got := resp.Header.Get(a.Target); !strings.Contains(got, a.Value) {
+				return fmt.Errorf("header %s is %q, expected %q", a.Target, got, a.Value)
+			}
+
+		case AssertJSON:
+			got, err := JSONPath(body, a.Target)
+			if err != nil {
+				return err
+			}
+			if got != a.Value {
+				return fmt.Errorf("%s is %q, expected %q", a.Target, got, a.Value)
+			}
+
+		case AssertMaxTimeMS:
+			if limit, _ := strconv.Atoi(a.Value); took > time.Duration(limit)*time.Millisecond {
+				return fmt.Errorf("took %s, more than %d ms", took.Round(time.Millisecond), limit)
+			}
+
+		default:
+			return fmt.Errorf("unknown assertion %q", a.Type)
+		}
+	}
+
+	if !hasStatus && resp.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("got %s", resp.Status)
+	}
+
+	return nil
+}
+
+// extract takes a variable from a response
+func extract(e models.TransactionExtract, resp *http.Response, body []byte) (string, error) {
+	switch e.From {
+	case ExtractJSON:
+		return JSONPath(body, e.Expression)
+
+	case ExtractRegex:
+		re, err := regexp.Compile(e.Expression)
+		if err != nil {
+			return "", err
+		}
+		match := re.FindSubmatch(body)
+		if match == nil {
+			return "", fmt.Errorf("body does not match %q", e.Expression)
+		}
+		if len(match) > 1 {
+			return string(match[1]), nil
+		}
+		return string(match[0]), nil
+
+	case ExtractHeader:
+		value := resp.Header.Get(e.Expression)
+		if value == "" {
+			return "", fmt.Errorf("no %s header", e.Expression)
+		}
+		return value, nil
+	}
+
+	return "", fmt.Errorf("unknown source %q", e.From)
+}
+
+// substitute replaces the {{name}} variables in s; unknown variables are left as they are
+func substitute(s string, variables map[string]string) string {
+	return stepVariable.ReplaceAllStringFunc(s, func(v string) string {
+		name := stepVariable.FindStringSubmatch(v)[1]
+		if value, ok := variables[name]; ok {
+			return value
+		}
+		return v
+	})
+}
@@ -0,0 +1,168 @@
+package checks
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"golang-observer-project/internal/models"
+	"net"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// ServiceUDP is the service id of generic UDP request/response checks
+const ServiceUDP = 15
+
+// DefaultUDPTimeout is the timeout of a UDP check without one, and MaxUDPTimeout the longest
+// one allowed
+const (
+	DefaultUDPTimeout = 5 * time.Second
+	MaxUDPTimeout     = 30 * time.Second
+)
+
+// maxUDPReply is the largest reply read, the largest UDP payload
+const maxUDPReply = 65507
+
+func init() {
+	Register(ServiceUDP, udpChecker{})
+}
+
+// udpChecker sends a datagram and checks the reply
+type udpChecker struct{}
+
+func (udpChecker) Validate(settings json.RawMessage) error {
+	var uc models.UDPCheck
+	if err := decodeSettings(settings, &uc); err != nil {
+		return err
+	}
+
+	if uc.Address == "" {
+		return errors.New("a UDP check needs an address, host:port or :port")
+	}
+	if err := validateAddress(uc.Address); err != nil {
+		return err
+	}
+
+	if _, err := udpPayload(uc); err != nil {
+		return err
+	}
+
+	if uc.Expect != "" {
+		if _, err := regexp.Compile(uc.Expect); err != nil {
+			return fmt.Errorf("invalid reply pattern: %w", err)
+		}
+	}
+
+	if uc.TimeoutSeconds < 0 || time.Duration(uc.TimeoutSeconds)*time.Second > MaxUDPTimeout {
+		return fmt.Errorf("the timeout must be at most %s", MaxUDPTimeout)
+	}
+
+	return nil
+}
+
+func (udpChecker) Secrets() []string {
+	return nil
+}
+
+func (udpChecker) Check(t Target) Result {
+	var uc models.UDPCheck
+	if err := decodeSettings(t.Settings, &uc); err != nil {
+		return Result{Status: "problem", Message: err.Error()}
+	}
+
+	return UDP(uc, t.URL)
+}
+
+// UDP sends the request of a UDP check and waits for a reply matching its pattern, or any
+// reply without one. The round trip is the total time.
+func UDP(uc models.UDPCheck, hostURL string) Result {
+	if uc.Address == "" {
+		return Result{Status: "problem", Message: "no address set"}
+	}
+
+	timeout := time.Duration(uc.TimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = DefaultUDPTimeout
+	}
+	if timeout > MaxUDPTimeout {
+		timeout = MaxUDPTimeout
+	}
+
+	payload, err := udpPayload(uc)
+	if err != nil {
+		return Result{Status: "problem", Message: err.Error()}
+	}
+
+	var expect *regexp.Regexp
+	if uc.Expect != "" {
+		expect, err = regexp.Compile(uc.Expect)
+		if err != nil {
+			return Result{Status: "problem", Message: err.Error()}
+		}
+	}
+
+	address := hostAddress(uc.Address, hostURL, "")
+
+	conn, err := net.DialTimeout("udp", address, timeout)
+	if err != nil {
+		return Result{Status: "problem", Message: "cannot connect: " + err.Error()}
+	}
+
+	defer func(conn net.Conn) {
+		_ = conn.Close()
+	}(conn)
+
+	start := time.Now()
+	_ = conn.SetDeadline(start.Add(timeout))
+
+	_, err = conn.Write(payload)
+	if err != nil {
+		return Result{Status: "problem", Message: "cannot send the request: " + err.Error()}
+	}
+
+	reply := make([]byte, maxUDPReply)
+	n, err := conn.Read(reply)
+	computeTimes := &models.ComputeTimes{TotalTime: time.Since(start)}
+	if err != nil {
+		var netErr net.Error
+		if errors.As(err, &netErr) && netErr.Timeout() {
+			return Result{Status: "problem", Message: "no reply from " + address + " in time",
+				ComputeTimes: computeTimes}
+		}
+		// a refused port comes back as an ICMP error on the read
+		return Result{Status: "problem", Message: "no reply: " + err.Error(), ComputeTimes: computeTimes}
+	}
+
+	text := string(reply[:n])
+	if uc.Hex {
+		text = hex.EncodeToString(reply[:n])
+	}
+
+	if expect != nil && !expect.MatchString(text) {
+		return Result{Status: "problem", Message: fmt.Sprintf("reply %q does not match %q", shorten(text, 100),
+			uc.Expect), ComputeTimes: computeTimes}
+	}
+
+	return Result{
+		Status: "healthy",
+		Message: fmt.Sprintf("%d byte reply in %s: %s", n, computeTimes.TotalTime.Round(time.Millisecond),
+			shorten(text, 100)),
+		ComputeTimes: computeTimes,
+	}
+}
+
+// udpPayload returns the request of a UDP check; hexadecimal requests may have spaces
+func udpPayload(uc models.UDPCheck) ([]byte, error) {
+	if !uc.Hex {
+		return []byte(uc.Send), nil
+	}
+
+	payload, err := hex.DecodeString(strings.Join(strings.Fields(uc.Send), ""))
+	if err != nil {
+		return nil, fmt.Errorf("the request is not hexadecimal: %w", err)
+	}
+
+	return payload, nil
+}
@@ -0,0 +1,98 @@
+package checks
+
+import (
+	"encoding/json"
+	"net"
+	"strings"
+	"testing"
+)
+
+// udpResponder answers every datagram on 127.0.0.1 with the reply of handle; a nil reply is
+// not answered. It returns the address it listens on.
+func udpResponder(t *testing.T, handle func(request []byte) []byte) string {
+	t.Helper()
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+
+	go func() {
+		buf := make([]byte, 65535)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+
+			if reply := handle(append([]byte(nil), buf[:n]...)); reply != nil {
+				_, _ = conn.WriteTo(reply, addr)
+			}
+		}
+	}()
+
+	return conn.LocalAddr().String()
+}
+
+// settings encodes the settings of a check
+func settings(t *testing.T, v interface{}) json.RawMessage {
+	t.Helper()
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return b
+}
+
+func TestUDPCheck(t *testing.T) {
+	address := udpResponder(t, func(request []byte) []byte {
+		if string(request) == "PING" {
+			return []byte("PONG v1.2")
+		}
+		if len(request) == 2 && request[0] == 0xca && request[1] == 0xfe {
+			return []byte{0xbe, 0xef}
+		}
+		return nil
+	})
+
+	tests := []struct {
+		name     string
+		settings map[string]interface{}
+		status   string
+		message  string
+	}{
+		{"text", map[string]interface{}{"send": "PING", "expect": `^PONG v\d`}, "healthy", "PONG v1.2"},
+		{"hex", map[string]interface{}{"send": "ca fe", "hex": true, "expect": "^beef$"}, "healthy", "beef"},
+		{"no match", map[string]interface{}{"send": "PING", "expect": "^PING"}, "problem", "does not match"},
+		{"timeout", map[string]interface{}{"send": "HELLO", "timeout_seconds": 1}, "problem", "in time"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.settings["address"] = address
+
+			result := Check(Target{ServiceID: ServiceUDP, URL: "http://127.0.0.1",
+				Settings: settings(t, tt.settings)})
+
+			if result.Status != tt.status || !strings.Contains(result.Message, tt.message) {
+				t.Errorf("got %s %q, want %s containing %q", result.Status, result.Message, tt.status, tt.message)
+			}
+		})
+	}
+}
+
+func TestUDPCheckHostOfURL(t *testing.T) {
+	address := udpResponder(t, func(request []byte) []byte { return request })
+	_, port, _ := net.SplitHostPort(address)
+
+	// :port uses the host of the URL
+	result := Check(Target{ServiceID: ServiceUDP, URL: "https://127.0.0.1/status",
+		Settings: settings(t, map[string]interface{}{"address": ":" + port, "send": "echo"})})
+
+	if result.Status != "healthy" || result.ComputeTimes == nil || result.ComputeTimes.TotalTime <= 0 {
+		t.Errorf("got %s %q, want a timed healthy result", result.Status, result.Message)
+	}
+}
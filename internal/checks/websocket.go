@@ -0,0 +1,253 @@
+package checks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/gorilla/websocket"
+	"golang-observer-project/internal/models"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// ServiceWebSocket is the service id of WebSocket checks
+const ServiceWebSocket = 12
+
+// DefaultWebSocketTimeout is the timeout of a WebSocket check without one, and
+// MaxWebSocketTimeout the longest one allowed
+const (
+	DefaultWebSocketTimeout = 10 * time.Second
+	MaxWebSocketTimeout     = time.Minute
+)
+
+// webSocketCloseWait is how long the close reply of the server is waited for
+const webSocketCloseWait = time.Second
+
+func init() {
+	// without settings, only the handshake with the host URL is checked
+	Register(ServiceWebSocket, CheckerFunc(func(t Target) Result {
+		var wc models.WebSocketCheck
+		if err := decodeSettings(t.Settings, &wc); err != nil {
+			return Result{Status: "problem", Message: err.Error()}
+		}
+
+		return WebSocket(wc, t.URL)
+	}))
+}
+
+// ValidateWebSocketCheck checks the settings of a WebSocket check
+func ValidateWebSocketCheck(wc models.WebSocketCheck) error {
+	if wc.URL != "" && !strings.HasPrefix(wc.URL, "/") {
+		u, err := url.Parse(wc.URL)
+		if err != nil || u.Host == "" {
+			return fmt.Errorf("invalid URL %q", wc.URL)
+		}
+		switch u.Scheme {
+		case "ws", "wss", "http", "https":
+		default:
+			return fmt.Errorf("invalid URL %q, use ws:// or wss://", wc.URL)
+		}
+	}
+
+	if wc.Expect != "" {
+		if _, err := regexp.Compile(wc.Expect); err != nil {
+			return fmt.Errorf("invalid reply pattern: %w", err)
+		}
+	}
+
+	if wc.TimeoutSeconds < 0 || time.Duration(wc.TimeoutSeconds)*time.Second > MaxWebSocketTimeout {
+		return fmt.Errorf("the timeout must be at most %s", MaxWebSocketTimeout)
+	}
+
+	return nil
+}
+
+// WebSocket makes the upgrade handshake of a WebSocket check, sends its message if it has one
+// and waits for a reply matching its pattern, then closes the connection cleanly. The handshake
+// is timed as the first byte time, and the round trip is the rest of the total time.
+func WebSocket(wc models.WebSocketCheck, hostURL string) Result {
+	timeout := time.Duration(wc.TimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = DefaultWebSocketTimeout
+	}
+	if timeout > MaxWebSocketTimeout {
+		timeout = MaxWebSocketTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	wsURL, err := webSocketURL(wc.URL, hostURL)
+	if err != nil {
+		return Result{Status: "problem", Message: err.Error()}
+	}
+
+	var expect *regexp.Regexp
+	if wc.Expect != "" {
+		expect, err = regexp.Compile(wc.Expect)
+		if err != nil {
+			return Result{Status: "problem", Message: err.Error()}
+		}
+	}
+
+	computeTimes := &models.ComputeTimes{}
+
+	dialer := websocket.Dialer{
+		Proxy: http.ProxyFromEnvironment,
+		NetDialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			start := time.Now()
+			conn, err := (&net.Dialer{}).DialContext(ctx, network, addr)
+			computeTimes.ConnectTime = time.Since(start)
+			return conn, err
+		},
+	}
+	if wc.Subprotocol != "" {
+		dialer.Subprotocols = []string{wc.Subprotocol}
+	}
+
+	header := http.Header{}
+	for name, value := range wc.Headers {
+		header.Set(name, value)
+	}
+
+	var start time.Time
+	ctx = httptrace.WithClientTrace(ctx, timingTrace(computeTimes, &start))
+	start = time.Now()
+
+	conn, resp, err := dialer.DialContext(ctx, wsURL, header)
+	computeTimes.FirstByte = time.Since(start)
+	if resp != nil {
+		computeTimes.ResponseStatus = resp.StatusCode
+	}
+	if err != nil {
+		computeTimes.TotalTime = computeTimes.FirstByte
+		msg := "handshake failed: " + err.Error()
+		if errors.Is(err, websocket.ErrBadHandshake) && resp != nil {
+			msg = "handshake failed: got " + resp.Status
+		}
+		return Result{Status: "problem", Message: msg, ComputeTimes: computeTimes}
+	}
+
+	defer func(conn *websocket.Conn) {
+		_ = conn.Close()
+	}(conn)
+
+	if wc.Subprotocol != "" && conn.Subprotocol() != wc.Subprotocol {
+		computeTimes.TotalTime = computeTimes.FirstByte
+		return Result{Status: "problem", Message: fmt.Sprintf("the server did not accept the %s subprotocol",
+			wc.Subprotocol), ComputeTimes: computeTimes}
+	}
+
+	deadline, _ := ctx.Deadline()
+	_ = conn.SetReadDeadline(deadline)
+	_ = conn.SetWriteDeadline(deadline)
+
+	msg := fmt.Sprintf("handshake in %s", computeTimes.FirstByte.Round(time.Millisecond))
+
+	if wc.Send != "" || expect != nil {
+		roundTrip := time.Now()
+
+		if wc.Send != "" {
+			err = conn.WriteMessage(websocket.TextMessage, []byte(wc.Send))
+			if err != nil {
+				computeTimes.TotalTime = time.Since(start)
+				return Result{Status: "problem", Message: "cannot send the message: " + err.Error(),
+					ComputeTimes: computeTimes}
+			}
+		}
+
+		reply, err := webSocketReply(conn, expect)
+		computeTimes.TotalTime = time.Since(start)
+		if err != nil {
+			return Result{Status: "problem", Message: err.Error(), ComputeTimes: computeTimes}
+		}
+
+		msg += fmt.Sprintf(", reply in %s: %s", time.Since(roundTrip).Round(time.Millisecond), reply)
+	} else {
+		computeTimes.TotalTime = computeTimes.FirstByte
+	}
+
+	webSocketClose(conn)
+
+	return Result{Status: "healthy", Message: msg, ComputeTimes: computeTimes}
+}
+
+// webSocketURL returns the URL of a WebSocket check: the host URL with a ws or wss scheme
+// without one, or joined to a path starting with /
+func webSocketURL(checkURL, hostURL string) (string, error) {
+	if checkURL == "" || strings.HasPrefix(checkURL, "/") {
+		checkURL = strings.TrimSuffix(hostURL, "/") + checkURL
+	}
+
+	u, err := url.Parse(checkURL)
+	if err != nil || u.Host == "" {
+		return "", fmt.Errorf("invalid URL %q", checkURL)
+	}
+
+	switch u.Scheme {
+	case "http":
+		u.Scheme = "ws"
+	case "https":
+		u.Scheme = "wss"
+	case "ws", "wss":
+	default:
+		return "", fmt.Errorf("invalid URL %q, use ws:// or wss://", checkURL)
+	}
+
+	return u.String(), nil
+}
+
+// webSocketReply reads messages until one matches expect, or returns the first one without a
+// pattern
+func webSocketReply(conn *websocket.Conn, expect *regexp.Regexp) (string, error) {
+	for {
+		_, message, err := conn.ReadMessage()
+		if err != nil {
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				if expect != nil {
+					return "", fmt.Errorf("no reply matching %q in time", expect.String())
+				}
+				return "", errors.New("no reply in time")
+			}
+			return "", errors.New("no reply: " + err.Error())
+		}
+
+		if expect == nil || expect.Match(message) {
+			return shorten(string(message), 100), nil
+		}
+	}
+}
+
+// webSocketClose sends a close message and waits a moment for the close reply of the server
+func webSocketClose(conn *websocket.Conn) {
+	deadline := time.Now().Add(webSocketCloseWait)
+
+	err := conn.WriteControl(websocket.CloseMessage,
+		websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), deadline)
+	if err != nil {
+		return
+	}
+
+	_ = conn.SetReadDeadline(deadline)
+	for {
+		if _, _, err := conn.NextReader(); err != nil {
+			return
+		}
+	}
+}
+
+// shorten cuts s to n characters for messages
+func shorten(s string, n int) string {
+	runes := []rune(strings.TrimSpace(s))
+	if len(runes) <= n {
+		return string(runes)
+	}
+
+	return string(runes[:n]) + "..."
+}
@@ -6,21 +6,50 @@ import (
 	"github.com/robfig/cron/v3"
 	"golang-observer-project/internal/channeldata"
 	"golang-observer-project/internal/driver"
+	"golang-observer-project/internal/election"
+	"golang-observer-project/internal/scheduling"
 	"html/template"
 )
 
+// Check modes: where the scheduled checks of this process run
+const (
+	// CheckModeLocal schedules checks and runs them in this process
+	CheckModeLocal = "local"
+	// CheckModeScheduler schedules checks and queues them in the database for workers
+	CheckModeScheduler = "scheduler"
+	// CheckModeWorker runs checks claimed from the database queue and schedules none
+	CheckModeWorker = "worker"
+)
+
+// MailOutbox stores outgoing email durably; Queue returns the id of the stored email
+type MailOutbox interface {
+	Queue(msg channeldata.MailData) (int, error)
+}
+
 // AppConfig holds application configuration
 type AppConfig struct {
-	DB            *driver.DB
-	InProduction  bool
-	Domain        string
-	MonitorMap    map[int]cron.EntryID
-	PreferenceMap map[string]string
-	Scheduler     *cron.Cron
+	DB           *driver.DB
+	InProduction bool
+	Domain       string
+	// Preferences are the site preferences
+	Preferences *Preferences
+	// Scheduler runs the scheduled checks of host services
+	Scheduler *scheduling.Manager
+	// ReportScheduler runs periodic reports and housekeeping, independent of whether monitoring is live
+	ReportScheduler *cron.Cron
+	// CheckMode is one of CheckModeLocal, CheckModeScheduler or CheckModeWorker
+	CheckMode string
+	// WorkerID identifies this process as the holder of check leases
+	WorkerID string
+	// Elector elects the one process that runs the Scheduler; nil for workers
+	Elector *election.Elector
+	// PluginDir is the directory of the plugins exec checks may run; exec checks are off without it
+	PluginDir     string
 	WsClient      pusher.Client
 	PusherSecret  string
 	TemplateCache map[string]*template.Template
-	MailQueue     chan channeldata.MailJob
+	// MailOutbox stores outgoing email until it is sent
+	MailOutbox    MailOutbox
 	Version       string
 	Identifier    string
 	ElasticConfig *elasticsearch.Client
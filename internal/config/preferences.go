@@ -0,0 +1,57 @@
+package config
+
+import "sync"
+
+// Preferences holds the site preferences, safe for use from handlers, scheduled checks and
+// workers at the same time
+type Preferences struct {
+	mu     sync.RWMutex
+	values map[string]string
+}
+
+// NewPreferences creates preferences holding a copy of values
+func NewPreferences(values map[string]string) *Preferences {
+	p := &Preferences{values: make(map[string]string, len(values))}
+	for k, v := range values {
+		p.values[k] = v
+	}
+	return p
+}
+
+// Get returns a preference, or an empty string when it is not set
+func (p *Preferences) Get(name string) string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return p.values[name]
+}
+
+// Set sets a preference
+func (p *Preferences) Set(name, value string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.values[name] = value
+}
+
+// SetAll sets several preferences at once
+func (p *Preferences) SetAll(values map[string]string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for k, v := range values {
+		p.values[k] = v
+	}
+}
+
+// Map returns a copy of all preferences, e.g. to hand to templates
+func (p *Preferences) Map() map[string]string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	values := make(map[string]string, len(p.values))
+	for k, v := range p.values {
+		values[k] = v
+	}
+	return values
+}
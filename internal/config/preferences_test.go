@@ -0,0 +1,53 @@
+package config
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestPreferencesConcurrentUse(t *testing.T) {
+	initial := map[string]string{"monitoring_live": "1"}
+	p := NewPreferences(initial)
+
+	// the preferences hold a copy of the initial values
+	initial["monitoring_live"] = "0"
+	if got := p.Get("monitoring_live"); got != "1" {
+		t.Fatalf("monitoring_live = %q, want 1", got)
+	}
+
+	var wg sync.WaitGroup
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+
+			for i := 0; i < 200; i++ {
+				name := "pref_" + strconv.Itoa(i%10)
+				switch i % 4 {
+				case 0:
+					p.Set(name, strconv.Itoa(w))
+				case 1:
+					p.SetAll(map[string]string{name: strconv.Itoa(i), "monitoring_live": "1"})
+				case 2:
+					p.Get(name)
+				case 3:
+					values := p.Map()
+					// the map is a copy, changing it does not change the preferences
+					values["monitoring_live"] = "0"
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	if got := p.Get("monitoring_live"); got != "1" {
+		t.Errorf("monitoring_live = %q, want 1", got)
+	}
+	if got := len(p.Map()); got != 11 {
+		t.Errorf("%d preferences, want 11", got)
+	}
+	if got := p.Get("unknown"); got != "" {
+		t.Errorf("unknown preference = %q, want empty", got)
+	}
+}
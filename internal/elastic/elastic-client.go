@@ -1,8 +1,21 @@
 package elastic
 
-import "golang-observer-project/internal/models"
+import (
+	"context"
+	"golang-observer-project/internal/models"
+	"time"
+)
 
 type Operations interface {
 	AddDocument(indexName string, documentID string, times models.ComputeTimes) error
 	GetDocumentsByIDAndInLastXMinutes(indexName string, minutes int, hostID int, serviceID int) ([]models.ComputeTimes, error)
+	AddHostMetrics(indexName string, documentID string, metrics models.HostMetrics) error
+	HostMetricsInLastXMinutes(indexName string, minutes int, hostServiceID int) ([]models.HostMetrics, error)
+	AddPerfdata(indexName string, documentID string, perfdata models.CheckPerfdata) error
+	PerfdataInLastXMinutes(indexName string, minutes int, hostServiceID int) ([]models.CheckPerfdata, error)
+	AddStepTimes(indexName string, documentID string, step models.StepTimes) error
+	StepTimesInLastXMinutes(indexName string, minutes int, hostServiceID int) ([]models.StepTimes, error)
+	SlowestServices(indexName string, since time.Time, size int) ([]models.ServicePerformance, error)
+	// Flush waits for documents still being indexed, or until ctx is done
+	Flush(ctx context.Context) error
 }
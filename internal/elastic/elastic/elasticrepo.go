@@ -1,9 +1,11 @@
 package elastic
 
 import (
+	"context"
 	"github.com/elastic/go-elasticsearch/v7"
 	"golang-observer-project/internal/config"
 	"golang-observer-project/internal/elastic"
+	"sync"
 )
 
 var app *config.AppConfig
@@ -11,6 +13,8 @@ var app *config.AppConfig
 type elasticRepo struct {
 	App           *config.AppConfig
 	ElasticClient *elasticsearch.Client
+	// writes tracks documents being indexed, so they can be flushed on shutdown
+	writes sync.WaitGroup
 }
 
 func NewElasticRepo(ElasticClient *elasticsearch.Client, a *config.AppConfig) elastic.Operations {
@@ -20,3 +24,19 @@ func NewElasticRepo(ElasticClient *elasticsearch.Client, a *config.AppConfig) el
 		ElasticClient: ElasticClient,
 	}
 }
+
+// Flush waits for documents still being indexed, or until ctx is done
+func (elastic *elasticRepo) Flush(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		elastic.writes.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -15,7 +15,20 @@ import (
 
 // AddDocument adds a document to an index
 func (elastic *elasticRepo) AddDocument(indexName string, documentID string, ct models.ComputeTimes) error {
-	docJSON, err := json.Marshal(ct)
+	return elastic.index(indexName, documentID, ct)
+}
+
+// AddHostMetrics adds the metrics a host agent reported to an index
+func (elastic *elasticRepo) AddHostMetrics(indexName string, documentID string, metrics models.HostMetrics) error {
+	return elastic.index(indexName, documentID, metrics)
+}
+
+// index adds any document to an index
+func (elastic *elasticRepo) index(indexName string, documentID string, doc interface{}) error {
+	elastic.writes.Add(1)
+	defer elastic.writes.Done()
+
+	docJSON, err := json.Marshal(doc)
 	if err != nil {
 		return err
 	}
@@ -101,9 +114,221 @@ func (elastic *elasticRepo) GetDocumentsByIDAndInLastXMinutes(indexName string,
 		computeTime.CreatedAt, _ = time.Parse(time.RFC3339, source["CreatedAt"].(string))
 		computeTime.ResponseStatus = int(source["ResponseStatus"].(float64))
 		computeTime.HostServices.ID = int(source["HostServices"].(map[string]interface{})["ID"].(float64))
+		if location, ok := source["Location"].(string); ok {
+			computeTime.Location = location
+		}
 
 		computeTimes = append(computeTimes, computeTime)
 	}
 
 	return computeTimes, nil
 }
+
+// HostMetricsInLastXMinutes returns the metrics a host agent reported in the last X minutes,
+// newest first
+func (elastic *elasticRepo) HostMetricsInLastXMinutes(indexName string, minutes int, hostServiceID int) ([]models.HostMetrics, error) {
+	sources, err := elastic.hostServiceDocuments(indexName, minutes, hostServiceID, "host_service_id", "created_at")
+	if err != nil {
+		return nil, err
+	}
+
+	var metrics []models.HostMetrics
+
+	for _, source := range sources {
+		var m models.HostMetrics
+		if err := json.Unmarshal(source, &m); err != nil {
+			return nil, err
+		}
+		metrics = append(metrics, m)
+	}
+
+	return metrics, nil
+}
+
+// AddPerfdata adds the perfdata of a plugin to an index
+func (elastic *elasticRepo) AddPerfdata(indexName string, documentID string, perfdata models.CheckPerfdata) error {
+	return elastic.index(indexName, documentID, perfdata)
+}
+
+// PerfdataInLastXMinutes returns the perfdata of the plugin of a host service in the last X
+// minutes, newest first
+func (elastic *elasticRepo) PerfdataInLastXMinutes(indexName string, minutes int, hostServiceID int) ([]models.CheckPerfdata, error) {
+	sources, err := elastic.hostServiceDocuments(indexName, minutes, hostServiceID, "host_service_id", "created_at")
+	if err != nil {
+		return nil, err
+	}
+
+	var perfdata []models.CheckPerfdata
+
+	for _, source := range sources {
+		var p models.CheckPerfdata
+		if err := json.Unmarshal(source, &p); err != nil {
+			return nil, err
+		}
+		perfdata = append(perfdata, p)
+	}
+
+	return perfdata, nil
+}
+
+// AddStepTimes adds the timings of a step of a transaction to an index
+func (elastic *elasticRepo) AddStepTimes(indexName string, documentID string, step models.StepTimes) error {
+	return elastic.index(indexName, documentID, step)
+}
+
+// StepTimesInLastXMinutes returns the timings of the steps of a transaction in the last X
+// minutes, newest first
+func (elastic *elasticRepo) StepTimesInLastXMinutes(indexName string, minutes int, hostServiceID int) ([]models.StepTimes, error) {
+	sources, err := elastic.hostServiceDocuments(indexName, minutes, hostServiceID, "HostServices.ID", "CreatedAt")
+	if err != nil {
+		return nil, err
+	}
+
+	var steps []models.StepTimes
+
+	for _, source := range sources {
+		var st models.StepTimes
+		if err := json.Unmarshal(source, &st); err != nil {
+			return nil, err
+		}
+		steps = append(steps, st)
+	}
+
+	return steps, nil
+}
+
+// hostServiceDocuments returns the documents of a host service created in the last X minutes,
+// newest first; idField and timeField name the host service id and creation time fields
+func (elastic *elasticRepo) hostServiceDocuments(indexName string, minutes int, hostServiceID int, idField, timeField string) ([]json.RawMessage, error) {
+	res, err := esquery.Search().
+		Query(esquery.Bool().
+			Must(esquery.Term(idField, hostServiceID)).
+			Filter(esquery.Range(timeField).
+				Gte("now-"+fmt.Sprintf("%dm", minutes)).Lte("now"))).
+		Size(10000).
+		Sort(timeField, "desc").
+		Run(
+			elastic.ElasticClient,
+			elastic.ElasticClient.Search.WithIndex(indexName),
+			elastic.ElasticClient.Search.WithContext(context.TODO()),
+		)
+
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	defer func(Body io.ReadCloser) {
+		err := Body.Close()
+		if err != nil {
+			fmt.Println(err)
+		}
+	}(res.Body)
+
+	if res.IsError() {
+		return nil, fmt.Errorf("error getting response: %s", res.String())
+	}
+
+	var r struct {
+		Hits struct {
+			Hits []struct {
+				Source json.RawMessage `json:"_source"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&r); err != nil {
+		return nil, err
+	}
+
+	sources := make([]json.RawMessage, 0, len(r.Hits.Hits))
+	for _, hit := range r.Hits.Hits {
+		sources = append(sources, hit.Source)
+	}
+
+	return sources, nil
+}
+
+// SlowestServices returns the host services with the highest average total response time since a given time
+func (elastic *elasticRepo) SlowestServices(indexName string, since time.Time, size int) ([]models.ServicePerformance, error) {
+	res, err := esquery.Search().
+		Query(esquery.Bool().
+			Filter(esquery.Range("CreatedAt").Gte(since.Format(time.RFC3339)))).
+		Aggs(esquery.TermsAgg("services", "HostServices.ID").
+			Size(uint64(size)).
+			Order(map[string]string{"avg_total": "desc"}).
+			Aggs(
+				esquery.Avg("avg_total", "TotalTime"),
+				esquery.Max("max_total", "TotalTime"),
+				esquery.TopHits("latest").
+					Size(1).
+					Sort("CreatedAt", esquery.OrderDesc).
+					SourceIncludes("Host.HostName", "HostServices.Service.ServiceName"),
+			)).
+		Size(0).
+		Run(
+			elastic.ElasticClient,
+			elastic.ElasticClient.Search.WithIndex(indexName),
+			elastic.ElasticClient.Search.WithContext(context.TODO()),
+		)
+
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	defer func(Body io.ReadCloser) {
+		err := Body.Close()
+		if err != nil {
+			fmt.Println(err)
+		}
+	}(res.Body)
+
+	if res.IsError() {
+		return nil, fmt.Errorf("error getting response: %s", res.String())
+	}
+
+	var r struct {
+		Aggregations struct {
+			Services struct {
+				Buckets []struct {
+					Key      int `json:"key"`
+					DocCount int `json:"doc_count"`
+					AvgTotal struct {
+						Value float64 `json:"value"`
+					} `json:"avg_total"`
+					MaxTotal struct {
+						Value float64 `json:"value"`
+					} `json:"max_total"`
+					Latest struct {
+						Hits struct {
+							Hits []struct {
+								Source models.ComputeTimes `json:"_source"`
+							} `json:"hits"`
+						} `json:"hits"`
+					} `json:"latest"`
+				} `json:"buckets"`
+			} `json:"services"`
+		} `json:"aggregations"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&r); err != nil {
+		return nil, err
+	}
+
+	var performances []models.ServicePerformance
+
+	for _, bucket := range r.Aggregations.Services.Buckets {
+		p := models.ServicePerformance{
+			HostServiceID: bucket.Key,
+			Checks:        bucket.DocCount,
+			AvgTotalTime:  time.Duration(bucket.AvgTotal.Value),
+			MaxTotalTime:  time.Duration(bucket.MaxTotal.Value),
+		}
+		if len(bucket.Latest.Hits.Hits) > 0 {
+			p.HostName = bucket.Latest.Hits.Hits[0].Source.Host.HostName
+			p.ServiceName = bucket.Latest.Hits.Hits[0].Source.HostServices.Service.ServiceName
+		}
+		performances = append(performances, p)
+	}
+
+	return performances, nil
+}
@@ -0,0 +1,286 @@
+package election
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"log"
+	"sync"
+	"time"
+)
+
+// Elector elects one leader among the processes sharing a Postgres database, with a session
+// advisory lock: the process whose connection holds the lock leads. The lock is released by
+// Postgres when that connection goes away, so a leader that dies or loses the database is
+// replaced within one interval.
+type Elector struct {
+	db        *sql.DB
+	key       int64
+	id        string
+	interval  time.Duration
+	onElected func()
+	onDemoted func()
+
+	// conn holds the lock while this process leads; only the campaigning goroutine uses it
+	conn *sql.Conn
+
+	mu      sync.RWMutex
+	leader  bool
+	since   time.Time
+	changes int
+
+	// quit stops campaigning and stopped is closed once it stopped; resign gives the lock up
+	// and done is closed once it is
+	quit    chan struct{}
+	stopped chan struct{}
+	resign  chan struct{}
+	done    chan struct{}
+}
+
+// Status describes the leadership as seen by one process
+type Status struct {
+	// ID identifies this process
+	ID string `json:"id"`
+	// Leader reports whether this process leads
+	Leader bool `json:"leader"`
+	// Since is when this process last became leader or stopped leading
+	Since time.Time `json:"since"`
+	// Changes counts how often the leadership of this process changed
+	Changes int `json:"changes"`
+	// LeaderID identifies the current leader, if any process holds the lock
+	LeaderID string `json:"leader_id"`
+}
+
+// applicationPrefix marks the connection of the leader in pg_stat_activity, so every process
+// can tell who leads
+const applicationPrefix = "observer-leader:"
+
+// New creates an elector that campaigns for the advisory lock key every interval as id.
+// onElected runs when this process becomes leader and onDemoted when it stops leading, both
+// on the goroutine of the elector.
+func New(db *sql.DB, key int64, id string, interval time.Duration, onElected, onDemoted func()) *Elector {
+	return &Elector{
+		db:        db,
+		key:       key,
+		id:        id,
+		interval:  interval,
+		onElected: onElected,
+		onDemoted: onDemoted,
+		since:     time.Now(),
+		quit:      make(chan struct{}),
+		stopped:   make(chan struct{}),
+		resign:    make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+}
+
+// Start campaigns once right away, then keeps campaigning, or renewing the lease of the
+// leader, every interval on a goroutine of its own
+func (e *Elector) Start() {
+	e.tick()
+
+	go e.campaign()
+}
+
+// campaign ticks every interval until Stop, then keeps the lock of a leader until Resign
+func (e *Elector) campaign() {
+	defer close(e.done)
+
+	ticker := time.NewTicker(e.interval)
+
+loop:
+	for {
+		select {
+		case <-e.quit:
+			break loop
+		case <-ticker.C:
+			e.tick()
+		}
+	}
+
+	ticker.Stop()
+	close(e.stopped)
+
+	<-e.resign
+	if e.conn != nil {
+		e.release()
+		log.Printf("%s resigned as leader\n", e.id)
+	}
+}
+
+// Stop stops campaigning, so the callbacks no longer run, but keeps the lock when this
+// process leads; Resign gives it up
+func (e *Elector) Stop(ctx context.Context) error {
+	close(e.quit)
+
+	select {
+	case <-e.stopped:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Resign releases the lock, so another process can take over right away. It is called after
+// Stop; the lock is released by the campaigning goroutine once its last tick is done.
+func (e *Elector) Resign(ctx context.Context) error {
+	close(e.resign)
+
+	var err error
+	select {
+	case <-e.done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	e.mu.Lock()
+	e.leader = false
+	e.mu.Unlock()
+
+	return err
+}
+
+// IsLeader reports whether this process leads
+func (e *Elector) IsLeader() bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	return e.leader
+}
+
+// Status returns the leadership as seen by this process, looking up the current leader
+func (e *Elector) Status() Status {
+	e.mu.RLock()
+	status := Status{
+		ID:      e.id,
+		Leader:  e.leader,
+		Since:   e.since,
+		Changes: e.changes,
+	}
+	e.mu.RUnlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT a.application_name FROM pg_locks l
+		JOIN pg_stat_activity a ON a.pid = l.pid
+		WHERE l.locktype = 'advisory' AND l.granted
+			AND l.classid = ($1::bigint >> 32)::oid AND l.objid = ($1::bigint & 4294967295)::oid AND l.objsubid = 1`
+
+	var name string
+	err := e.db.QueryRowContext(ctx, query, e.key).Scan(&name)
+	if err == nil && len(name) > len(applicationPrefix) {
+		status.LeaderID = name[len(applicationPrefix):]
+	}
+
+	return status
+}
+
+// tick renews the lease of the leader, or tries to take the lock, and runs the callbacks
+// when the leadership changed
+func (e *Elector) tick() {
+	var leader bool
+	if e.conn != nil {
+		leader = e.renew()
+	} else {
+		leader = e.acquire()
+	}
+
+	e.mu.Lock()
+	wasLeader := e.leader
+	e.leader = leader
+	if leader != wasLeader {
+		e.since = time.Now()
+		e.changes++
+	}
+	e.mu.Unlock()
+
+	if leader == wasLeader {
+		return
+	}
+
+	if leader {
+		log.Printf("%s became leader\n", e.id)
+		if e.onElected != nil {
+			e.onElected()
+		}
+	} else {
+		log.Printf("%s is no longer leader\n", e.id)
+		if e.onDemoted != nil {
+			e.onDemoted()
+		}
+	}
+}
+
+// acquire tries to take the lock on a connection of its own, kept for as long as this
+// process leads
+func (e *Elector) acquire() bool {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	conn, err := e.db.Conn(ctx)
+	if err != nil {
+		log.Println("cannot campaign for leader:", err)
+		return false
+	}
+
+	var locked bool
+	err = conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", e.key).Scan(&locked)
+	if err != nil || !locked {
+		if err != nil {
+			log.Println("cannot campaign for leader:", err)
+		}
+		_ = conn.Close()
+		return false
+	}
+
+	e.conn = conn
+
+	_, err = conn.ExecContext(ctx, "SELECT set_config('application_name', $1, false)", applicationPrefix+e.id)
+	if err != nil {
+		log.Println(err)
+	}
+
+	return true
+}
+
+// renew checks that the connection of the leader still holds the lock, and releases the
+// connection when it does not
+func (e *Elector) renew() bool {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM pg_locks
+			WHERE locktype = 'advisory' AND granted AND pid = pg_backend_pid()
+				AND classid = ($1::bigint >> 32)::oid AND objid = ($1::bigint & 4294967295)::oid AND objsubid = 1
+		)`
+
+	var held bool
+	err := e.conn.QueryRowContext(ctx, query, e.key).Scan(&held)
+	if err != nil || !held {
+		if err != nil {
+			log.Println("cannot renew leadership:", err)
+		}
+		e.release()
+		return false
+	}
+
+	return true
+}
+
+// release unlocks and discards the connection of the leader, so the lock cannot live on in
+// the connection pool
+func (e *Elector) release() {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, _ = e.conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", e.key)
+	_ = e.conn.Raw(func(any) error {
+		return driver.ErrBadConn
+	})
+	_ = e.conn.Close()
+	e.conn = nil
+}
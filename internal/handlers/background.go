@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// background tracks notifications being delivered outside of the check that raised them
+var background sync.WaitGroup
+
+// backgroundCtx is done once shutdown gives up waiting for background notifications, so
+// deliveries still retrying stop and record their failure
+var backgroundCtx, cancelBackground = context.WithCancel(context.Background())
+
+// backgroundGrace is how long cancelled notifications get to record their failure
+const backgroundGrace = 2 * time.Second
+
+// goBackground runs fn in its own goroutine, tracked so shutdown can wait for it
+func goBackground(fn func()) {
+	background.Add(1)
+	go func() {
+		defer background.Done()
+		fn()
+	}()
+}
+
+// WaitForBackground waits for background notifications to be delivered. Once ctx is done
+// the deliveries still going on are cancelled, and get a moment to record that they failed.
+func WaitForBackground(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		background.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+	}
+
+	cancelBackground()
+
+	select {
+	case <-done:
+	case <-time.After(backgroundGrace):
+	}
+
+	return ctx.Err()
+}
@@ -0,0 +1,201 @@
+package handlers
+
+import (
+	"context"
+	"github.com/robfig/cron/v3"
+	"golang-observer-project/internal/helpers"
+	"golang-observer-project/internal/models"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// checkLease is how long a worker holds a claimed check before another worker may take it
+	checkLease = 2 * time.Minute
+	// checkPollInterval is how long an idle worker waits before looking for checks again
+	checkPollInterval = 2 * time.Second
+	// checkJobRetention is how long finished checks are kept in the queue
+	checkJobRetention = 24 * time.Hour
+)
+
+// queueCheck puts a due check in the database queue for a worker to run. A check still
+// queued or running from its previous run is not queued twice.
+func (repo *DBRepo) queueCheck(hostServiceID, hostID int) {
+	queued, err := repo.DB.EnqueueCheck(hostServiceID, hostID)
+	if err != nil {
+		log.Printf("cannot queue check of host service %d: %s\n", hostServiceID, err)
+		return
+	}
+
+	if !queued {
+		log.Printf("check of host service %d is still queued or running, skipping\n", hostServiceID)
+	}
+}
+
+// checkQueueJob fails abandoned checks and purges finished ones
+type checkQueueJob struct{}
+
+func (j checkQueueJob) Run() {
+	failed, err := Repo.DB.FailAbandonedChecks()
+	if err != nil {
+		log.Println("cannot fail abandoned checks:", err)
+	} else if failed > 0 {
+		log.Printf("%d checks failed after their lease ran out on every attempt\n", failed)
+	}
+
+	err = Repo.DB.PurgeFinishedChecks(time.Now().Add(-checkJobRetention))
+	if err != nil {
+		log.Println("cannot purge finished checks:", err)
+	}
+}
+
+// StartCheckQueueHousekeeping looks after the check queue every minute on the report scheduler
+func (repo *DBRepo) StartCheckQueueHousekeeping() {
+	repo.App.ReportScheduler.Schedule(cron.Every(time.Minute), checkQueueJob{})
+}
+
+// CheckWorker claims checks from the database queue and runs them, with size checks in
+// flight at a time. Several workers, in one or many processes, share the queue.
+type CheckWorker struct {
+	repo *DBRepo
+	id   string
+	size int
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewCheckWorker creates a worker that claims checks under the given id
+func NewCheckWorker(repo *DBRepo, id string, size int) *CheckWorker {
+	return &CheckWorker{
+		repo: repo,
+		id:   id,
+		size: size,
+		quit: make(chan struct{}),
+	}
+}
+
+// Start starts claiming checks
+func (w *CheckWorker) Start() {
+	for i := 0; i < w.size; i++ {
+		w.wg.Add(1)
+		go w.loop()
+	}
+}
+
+// Stop stops claiming checks and waits for the running ones to finish, or until ctx is done.
+// Checks still running then are claimed by another worker once their lease runs out.
+func (w *CheckWorker) Stop(ctx context.Context) error {
+	close(w.quit)
+
+	done := make(chan struct{})
+	go func() {
+		w.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// loop claims and runs one check at a time until the worker is stopped
+func (w *CheckWorker) loop() {
+	defer w.wg.Done()
+
+	for {
+		select {
+		case <-w.quit:
+			return
+		default:
+		}
+
+		checks, err := w.repo.DB.ClaimChecks(w.id, checkLease, 1)
+		if err != nil || len(checks) == 0 {
+			select {
+			case <-w.quit:
+				return
+			case <-time.After(checkPollInterval):
+			}
+			continue
+		}
+
+		w.run(checks[0])
+	}
+}
+
+// run runs a claimed check, keeping its lease while it waits for a concurrency slot and
+// runs, and writes back the outcome
+func (w *CheckWorker) run(cj models.CheckJob) {
+	done := make(chan struct{})
+	go w.keepLease(cj.ID, done)
+
+	release := checkLimiter.Acquire(cj.HostID)
+	err := w.repo.ScheduledCheck(cj.HostServiceID)
+	release()
+	close(done)
+
+	lastError := ""
+	if err != nil {
+		lastError = err.Error()
+	}
+
+	err = w.repo.DB.FinishCheck(cj.ID, w.id, lastError)
+	if err != nil {
+		log.Printf("cannot finish check %d: %s\n", cj.ID, err)
+	}
+}
+
+// keepLease renews the lease on a check until done is closed
+func (w *CheckWorker) keepLease(id int, done chan struct{}) {
+	ticker := time.NewTicker(checkLease / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			held, err := w.repo.DB.ExtendCheckLease(id, w.id, checkLease)
+			if err != nil {
+				continue
+			}
+			if !held {
+				log.Printf("lost the lease on check %d\n", id)
+				return
+			}
+		}
+	}
+}
+
+// CheckQueue shows the depth of the check queue, the workers running checks and the checks
+// that failed
+func (repo *DBRepo) CheckQueue(w http.ResponseWriter, r *http.Request) {
+	stats, err := repo.DB.CheckQueueStats()
+	if err != nil {
+		log.Println(err)
+		ClientError(w, r, http.StatusBadRequest)
+		return
+	}
+
+	failed, err := repo.DB.FailedChecks(50)
+	if err != nil {
+		log.Println(err)
+		ClientError(w, r, http.StatusBadRequest)
+		return
+	}
+
+	var response models.CheckQueueResponse
+	response.OK = true
+	response.Message = "Check queue retrieved"
+	response.Mode = repo.App.CheckMode
+	response.Worker = repo.App.WorkerID
+	response.Stats = stats
+	response.Failed = failed
+
+	helpers.RenderJSON(w, response)
+}
@@ -0,0 +1,319 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"golang-observer-project/internal/checks"
+	"golang-observer-project/internal/helpers"
+	"golang-observer-project/internal/models"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+)
+
+// ownSettings are the settings of a check kept in a table of its own, with typed columns and
+// an endpoint of their own to save them
+type ownSettings struct {
+	load func(repo *DBRepo, hostServiceID int) (interface{}, error)
+	// secrets are the settings, by JSON name, that are not shown once saved
+	secrets []string
+	// secretMaps are the settings, by JSON name, whose values are all secrets, such as headers
+	secretMaps []string
+	// endpoint saves the settings, under /admin/host-service/{id}/
+	endpoint string
+}
+
+// settingsTables are the checks whose settings are kept in tables of their own; the settings
+// of other checks are their generic check settings
+var settingsTables = map[int]ownSettings{
+	Exec: {load: func(repo *DBRepo, hostServiceID int) (interface{}, error) {
+		return repo.DB.ExecCheck(hostServiceID)
+	}, endpoint: "exec"},
+	Transaction: {load: func(repo *DBRepo, hostServiceID int) (interface{}, error) {
+		return repo.DB.Transaction(hostServiceID)
+	}, endpoint: "transaction"},
+	Postgres: {load: databaseSettings, secrets: []string{"password"}, endpoint: "database"},
+	MySQL:    {load: databaseSettings, secrets: []string{"password"}, endpoint: "database"},
+	Redis:    {load: databaseSettings, secrets: []string{"password"}, endpoint: "database"},
+	GRPC: {load: func(repo *DBRepo, hostServiceID int) (interface{}, error) {
+		return repo.DB.GRPCCheck(hostServiceID)
+	}, secrets: []string{"client_key"}, endpoint: "grpc"},
+	WebSocket: {load: func(repo *DBRepo, hostServiceID int) (interface{}, error) {
+		return repo.DB.WebSocketCheck(hostServiceID)
+	}, secretMaps: []string{"headers"}, endpoint: "websocket"},
+}
+
+func databaseSettings(repo *DBRepo, hostServiceID int) (interface{}, error) {
+	return repo.DB.DatabaseCheck(hostServiceID)
+}
+
+// checkSettings returns the settings of the check of a host service, nil when none are set
+func (repo *DBRepo) checkSettings(hs models.HostServices) (json.RawMessage, error) {
+	if table, ok := settingsTables[hs.ServiceID]; ok {
+		settings, err := table.load(repo, hs.ID)
+		if errors.Is(err, models.ErrNoRecord) {
+			return nil, nil
+		} else if err != nil {
+			return nil, err
+		}
+		return json.Marshal(settings)
+	}
+
+	cs, err := repo.DB.CheckSettings(hs.ID)
+	if errors.Is(err, models.ErrNoRecord) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return cs.Settings, nil
+}
+
+// runCheck runs the registered checker of a host service with its settings
+func (repo *DBRepo) runCheck(h models.Host, hs models.HostServices) checks.Result {
+	settings, err := repo.checkSettings(hs)
+	if err != nil {
+		return checks.Result{Status: "problem", Message: err.Error()}
+	}
+
+	return checks.Check(checks.Target{
+		ServiceID: hs.ServiceID,
+		HostName:  h.HostName,
+		URL:       h.URL,
+		Settings:  settings,
+		PluginDir: repo.App.PluginDir,
+	})
+}
+
+// HostServiceSettings shows the settings of the check of a host service, generic or kept in a
+// table of its own; secrets are not shown, only whether they are set
+func (repo *DBRepo) HostServiceSettings(w http.ResponseWriter, r *http.Request) {
+	hs, checker, ok := repo.settingsHostService(w, r)
+	if !ok {
+		return
+	}
+
+	var response models.CheckSettingsResponse
+
+	if table, ok := settingsTables[hs.ServiceID]; ok {
+		settings, err := repo.checkSettings(hs)
+		if err != nil {
+			ClientError(w, r, http.StatusInternalServerError)
+			return
+		}
+
+		response.OK = true
+		response.Message = "Settings retrieved; they are saved with POST /admin/host-service/{id}/" + table.endpoint
+		response.Settings = json.RawMessage("{}")
+		response.SecretsSet = []string{}
+		if settings != nil {
+			response.Settings, response.SecretsSet, err = hideSecrets(settings, table.secrets)
+			if err == nil && len(table.secretMaps) > 0 {
+				var mapsSet []string
+				response.Settings, mapsSet, err = hideSecretMaps(response.Settings, table.secretMaps)
+				response.SecretsSet = append(response.SecretsSet, mapsSet...)
+			}
+			if err != nil {
+				ClientError(w, r, http.StatusInternalServerError)
+				return
+			}
+		}
+
+		helpers.RenderJSON(w, response)
+		return
+	}
+
+	cs, err := repo.DB.CheckSettings(hs.ID)
+	if errors.Is(err, models.ErrNoRecord) {
+		response.OK = true
+		response.Message = "No settings set"
+		response.Settings = json.RawMessage("{}")
+		response.SecretsSet = []string{}
+		helpers.RenderJSON(w, response)
+		return
+	} else if err != nil {
+		ClientError(w, r, http.StatusInternalServerError)
+		return
+	}
+
+	settings, secretsSet, err := hideSecrets(cs.Settings, checker.Secrets())
+	if err != nil {
+		ClientError(w, r, http.StatusInternalServerError)
+		return
+	}
+
+	response.OK = true
+	response.Message = "Settings retrieved"
+	response.Settings = settings
+	response.SecretsSet = secretsSet
+
+	helpers.RenderJSON(w, response)
+}
+
+// PostHostServiceSettings saves the generic settings of the check of a host service once its
+// checker validated them; empty secrets keep the ones saved before. Checks kept in a table of
+// their own are saved by their own endpoint, which this names.
+func (repo *DBRepo) PostHostServiceSettings(w http.ResponseWriter, r *http.Request) {
+	hs, checker, ok := repo.settingsHostService(w, r)
+	if !ok {
+		return
+	}
+
+	if table, ok := settingsTables[hs.ServiceID]; ok {
+		var response models.CheckSettingsResponse
+		response.Message = "The settings of this check are saved with POST /admin/host-service/{id}/" + table.endpoint
+		helpers.RenderJSON(w, response)
+		return
+	}
+
+	var settings map[string]interface{}
+	err := json.NewDecoder(r.Body).Decode(&settings)
+	if err != nil || settings == nil {
+		log.Println(err)
+		ClientError(w, r, http.StatusBadRequest)
+		return
+	}
+
+	saved, err := repo.DB.CheckSettings(hs.ID)
+	if err == nil {
+		keepSecrets(settings, saved.Settings, checker.Secrets())
+	}
+
+	b, err := json.Marshal(settings)
+	if err != nil {
+		ClientError(w, r, http.StatusBadRequest)
+		return
+	}
+
+	var response models.CheckSettingsResponse
+
+	err = checker.Validate(b)
+	if err != nil {
+		response.Message = err.Error()
+		helpers.RenderJSON(w, response)
+		return
+	}
+
+	err = repo.DB.UpsertCheckSettings(models.CheckSettings{HostServiceID: hs.ID, Settings: b})
+	if err != nil {
+		ClientError(w, r, http.StatusBadRequest)
+		return
+	}
+
+	response.OK = true
+	response.Message = "Settings saved"
+	response.Settings, response.SecretsSet, err = hideSecrets(b, checker.Secrets())
+	if err != nil {
+		ClientError(w, r, http.StatusInternalServerError)
+		return
+	}
+
+	helpers.RenderJSON(w, response)
+}
+
+// settingsHostService returns the host service in the URL and its checker when the checker
+// keeps generic settings, or no checker when the settings are kept in a table of their own; it
+// answers with an error for checks without settings
+func (repo *DBRepo) settingsHostService(w http.ResponseWriter, r *http.Request) (models.HostServices, checks.Configurable, bool) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		ClientError(w, r, http.StatusBadRequest)
+		return models.HostServices{}, nil, false
+	}
+
+	hs, err := repo.DB.GetHostServiceByID(id)
+	if err != nil {
+		ClientError(w, r, http.StatusNotFound)
+		return hs, nil, false
+	}
+
+	if _, ok := settingsTables[hs.ServiceID]; ok {
+		return hs, nil, true
+	}
+
+	checker, _ := checks.Lookup(hs.ServiceID)
+	configurable, ok := checker.(checks.Configurable)
+	if !ok {
+		ClientError(w, r, http.StatusBadRequest)
+		return hs, nil, false
+	}
+
+	return hs, configurable, true
+}
+
+// hideSecrets blanks the secrets set in settings, and returns which they were
+func hideSecrets(settings json.RawMessage, secrets []string) (json.RawMessage, []string, error) {
+	var values map[string]interface{}
+	err := json.Unmarshal(settings, &values)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	secretsSet := make([]string, 0)
+	for _, secret := range secrets {
+		if value, ok := values[secret].(string); ok && value != "" {
+			values[secret] = ""
+			secretsSet = append(secretsSet, secret)
+		}
+	}
+
+	b, err := json.Marshal(values)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return b, secretsSet, nil
+}
+
+// hideSecretMaps blanks the values of the secret maps in settings, and returns which were set,
+// by map and key, e.g. "headers.Authorization"
+func hideSecretMaps(settings json.RawMessage, secretMaps []string) (json.RawMessage, []string, error) {
+	var values map[string]interface{}
+	err := json.Unmarshal(settings, &values)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	secretsSet := make([]string, 0)
+	for _, name := range secretMaps {
+		m, _ := values[name].(map[string]interface{})
+		keys := make([]string, 0, len(m))
+		for key := range m {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			if value, ok := m[key].(string); ok && value != "" {
+				secretsSet = append(secretsSet, name+"."+key)
+			}
+			m[key] = ""
+		}
+	}
+
+	b, err := json.Marshal(values)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return b, secretsSet, nil
+}
+
+// keepSecrets fills the empty secrets of settings with the saved ones
+func keepSecrets(settings map[string]interface{}, saved json.RawMessage, secrets []string) {
+	var savedValues map[string]interface{}
+	if json.Unmarshal(saved, &savedValues) != nil {
+		return
+	}
+
+	for _, secret := range secrets {
+		if value, _ := settings[secret].(string); value != "" {
+			continue
+		}
+		if value, ok := savedValues[secret].(string); ok && value != "" {
+			settings[secret] = value
+		}
+	}
+}
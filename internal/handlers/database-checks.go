@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"golang-observer-project/internal/checks"
+	"golang-observer-project/internal/helpers"
+	"golang-observer-project/internal/models"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// HostServiceDatabase shows the connection and query of a database host service; the password
+// is not shown, only whether there is one
+func (repo *DBRepo) HostServiceDatabase(w http.ResponseWriter, r *http.Request) {
+	hs, ok := repo.databaseHostService(w, r)
+	if !ok {
+		return
+	}
+
+	dc, err := repo.DB.DatabaseCheck(hs.ID)
+	if errors.Is(err, models.ErrNoRecord) {
+		dc = models.DatabaseCheck{HostServiceID: hs.ID, TLSMode: checks.TLSDisable,
+			TimeoutSeconds: int(checks.DefaultDatabaseTimeout / time.Second)}
+	} else if err != nil {
+		ClientError(w, r, http.StatusInternalServerError)
+		return
+	}
+
+	repo.renderDatabaseCheck(w, dc, "Database check retrieved")
+}
+
+// PostHostServiceDatabase saves the connection and query of a database host service; an empty
+// password keeps the one saved before
+func (repo *DBRepo) PostHostServiceDatabase(w http.ResponseWriter, r *http.Request) {
+	hs, ok := repo.databaseHostService(w, r)
+	if !ok {
+		return
+	}
+
+	var dc models.DatabaseCheck
+	err := json.NewDecoder(r.Body).Decode(&dc)
+	if err != nil {
+		log.Println(err)
+		ClientError(w, r, http.StatusBadRequest)
+		return
+	}
+
+	err = checks.ValidateDatabaseCheck(hs.ServiceID, dc)
+	if err != nil {
+		var response models.DatabaseCheckResponse
+		response.Message = err.Error()
+		helpers.RenderJSON(w, response)
+		return
+	}
+
+	dc.HostServiceID = hs.ID
+	if dc.TLSMode == "" {
+		dc.TLSMode = checks.TLSDisable
+	}
+	if dc.TimeoutSeconds == 0 {
+		dc.TimeoutSeconds = int(checks.DefaultDatabaseTimeout / time.Second)
+	}
+
+	if dc.Password == "" {
+		saved, err := repo.DB.DatabaseCheck(hs.ID)
+		if err == nil {
+			dc.Password = saved.Password
+		}
+	}
+
+	err = repo.DB.UpsertDatabaseCheck(dc)
+	if err != nil {
+		ClientError(w, r, http.StatusBadRequest)
+		return
+	}
+
+	dc, err = repo.DB.DatabaseCheck(hs.ID)
+	if err != nil {
+		ClientError(w, r, http.StatusInternalServerError)
+		return
+	}
+
+	repo.renderDatabaseCheck(w, dc, "Database check saved")
+}
+
+// databaseHostService returns the database host service in the URL, or answers with an error
+func (repo *DBRepo) databaseHostService(w http.ResponseWriter, r *http.Request) (models.HostServices, bool) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		ClientError(w, r, http.StatusBadRequest)
+		return models.HostServices{}, false
+	}
+
+	hs, err := repo.DB.GetHostServiceByID(id)
+	if err != nil {
+		ClientError(w, r, http.StatusNotFound)
+		return hs, false
+	}
+
+	if !checks.IsDatabase(hs.ServiceID) {
+		ClientError(w, r, http.StatusBadRequest)
+		return hs, false
+	}
+
+	return hs, true
+}
+
+// renderDatabaseCheck answers with a database check without its password
+func (repo *DBRepo) renderDatabaseCheck(w http.ResponseWriter, dc models.DatabaseCheck, message string) {
+	var response models.DatabaseCheckResponse
+	response.OK = true
+	response.Message = message
+	response.PasswordSet = dc.Password != ""
+
+	dc.Password = ""
+	response.DatabaseCheck = dc
+
+	helpers.RenderJSON(w, response)
+}
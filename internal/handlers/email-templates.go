@@ -0,0 +1,308 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"golang-observer-project/internal/helpers"
+	"golang-observer-project/internal/mailtemplates"
+	"golang-observer-project/internal/models"
+	"golang-observer-project/internal/notifiers"
+	"golang-observer-project/internal/reports"
+	"html/template"
+	"log"
+	"net/http"
+	"time"
+)
+
+// emailTemplate returns the customised template with the given name, or the default from
+// views/email
+func (repo *DBRepo) emailTemplate(name string) (models.EmailTemplate, error) {
+	et, err := repo.DB.GetEmailTemplate(name)
+	if err == nil {
+		return et, nil
+	}
+
+	if !errors.Is(err, sql.ErrNoRows) {
+		log.Println(err)
+	}
+
+	return mailtemplates.LoadDefault(mailtemplates.DefaultDir, name)
+}
+
+// statusEmailContext gathers everything a status change email template can show
+func (repo *DBRepo) statusEmailContext(h models.Host, hs models.HostServices, sc notifiers.StatusChange) mailtemplates.Context {
+	ctx := mailtemplates.Context{
+		Host:          h,
+		HostService:   hs,
+		OldStatus:     sc.OldStatus,
+		NewStatus:     sc.NewStatus,
+		Message:       sc.Message,
+		Link:          sc.Link,
+		PreferenceMap: repo.App.Preferences.Map(),
+	}
+
+	if sc.NewStatus == "healthy" && sc.IncidentID > 0 {
+		downSince, err := repo.DB.ProblemStartedAt(hs.ID, sc.IncidentID)
+		if err != nil {
+			log.Println(err)
+		} else if !downSince.IsZero() && downSince.Year() > 1 {
+			ctx.DownSince = downSince
+			ctx.Duration = sc.CheckedAt.Sub(downSince).Round(time.Second)
+		}
+	}
+
+	computeTimes, err := repo.ElasticClient.GetDocumentsByIDAndInLastXMinutes("performances", 60, h.ID, hs.ID)
+	if err != nil {
+		log.Println(err)
+	} else if len(computeTimes) > 0 {
+		ctx.ComputeTimes = &computeTimes[0]
+	}
+
+	return ctx
+}
+
+// renderStatusEmail renders the subject and content of the email for a status change
+func (repo *DBRepo) renderStatusEmail(h models.Host, hs models.HostServices, sc notifiers.StatusChange) (string, template.HTML, error) {
+	name := mailtemplates.ForStatusChange(hs.ServiceID, sc.NewStatus, SSLCertificate)
+	if name == "" {
+		return "", "", errors.New("no email is sent for a change to " + sc.NewStatus)
+	}
+
+	et, err := repo.emailTemplate(name)
+	if err != nil {
+		return "", "", err
+	}
+
+	return mailtemplates.Render(et, repo.statusEmailContext(h, hs, sc))
+}
+
+// AllEmailTemplates lists the email templates that are in effect
+func (repo *DBRepo) AllEmailTemplates(w http.ResponseWriter, r *http.Request) {
+	var response models.EmailTemplatesResponse
+	response.OK = true
+	response.Message = "Email templates retrieved"
+
+	for _, name := range mailtemplates.Names {
+		et, err := repo.emailTemplate(name)
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+		response.Templates = append(response.Templates, et)
+	}
+
+	helpers.RenderJSON(w, response)
+}
+
+// EmailTemplate shows one email template
+func (repo *DBRepo) EmailTemplate(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+	if !mailtemplates.IsValidName(name) {
+		ClientError(w, r, http.StatusNotFound)
+		return
+	}
+
+	et, err := repo.emailTemplate(name)
+	if err != nil {
+		log.Println(err)
+		ClientError(w, r, http.StatusNotFound)
+		return
+	}
+
+	var response models.EmailTemplateResponse
+	response.OK = true
+	response.Message = "Email template retrieved"
+	response.Template = et
+
+	helpers.RenderJSON(w, response)
+}
+
+// PostEmailTemplate saves a customised email template
+func (repo *DBRepo) PostEmailTemplate(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+	if !mailtemplates.IsValidName(name) {
+		ClientError(w, r, http.StatusNotFound)
+		return
+	}
+
+	var req models.EmailTemplateRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		log.Println(err)
+		ClientError(w, r, http.StatusBadRequest)
+		return
+	}
+
+	et := models.EmailTemplate{Name: name, Subject: req.Subject, Body: req.Body}
+
+	var response models.EmailTemplateResponse
+	response.OK = true
+	response.Message = "Email template saved"
+
+	// refuse templates that can't render the sample event
+	_, _, err = mailtemplates.Render(et, sampleEmailContext(name))
+	if err != nil {
+		response.OK = false
+		response.Message = err.Error()
+		helpers.RenderJSON(w, response)
+		return
+	}
+
+	err = repo.DB.UpsertEmailTemplate(et)
+	if err != nil {
+		ClientError(w, r, http.StatusBadRequest)
+		return
+	}
+
+	et.Customised = true
+	response.Template = et
+
+	helpers.RenderJSON(w, response)
+}
+
+// DeleteEmailTemplate reverts an email template to its default
+func (repo *DBRepo) DeleteEmailTemplate(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+	if !mailtemplates.IsValidName(name) {
+		ClientError(w, r, http.StatusNotFound)
+		return
+	}
+
+	err := repo.DB.DeleteEmailTemplate(name)
+	if err != nil {
+		ClientError(w, r, http.StatusBadRequest)
+		return
+	}
+
+	var jsonResp jsonResp
+	jsonResp.OK = true
+	jsonResp.Message = "Email template reverted to default"
+
+	helpers.RenderJSON(w, jsonResp)
+}
+
+// PreviewEmailTemplate renders an email template against a sample event. A subject or body
+// in the request previews unsaved changes, otherwise the template in effect is used.
+func (repo *DBRepo) PreviewEmailTemplate(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+	if !mailtemplates.IsValidName(name) {
+		ClientError(w, r, http.StatusNotFound)
+		return
+	}
+
+	et, err := repo.emailTemplate(name)
+	if err != nil {
+		log.Println(err)
+		ClientError(w, r, http.StatusNotFound)
+		return
+	}
+
+	var req models.EmailTemplateRequest
+	if r.ContentLength > 0 {
+		err = json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			ClientError(w, r, http.StatusBadRequest)
+			return
+		}
+	}
+
+	if req.Subject != "" {
+		et.Subject = req.Subject
+	}
+
+	if req.Body != "" {
+		et.Body = req.Body
+	}
+
+	ctx := sampleEmailContext(name)
+	ctx.PreferenceMap = repo.App.Preferences.Map()
+	ctx.Link = repo.hostLink(ctx.Host.ID)
+
+	var response models.EmailPreviewResponse
+	response.OK = true
+	response.Message = "Email template rendered"
+
+	subject, body, err := mailtemplates.Render(et, ctx)
+	if err != nil {
+		response.OK = false
+		response.Message = err.Error()
+	}
+
+	response.Subject = subject
+	response.Body = string(body)
+
+	helpers.RenderJSON(w, response)
+}
+
+// sampleEmailContext returns a made up event to preview and validate email templates with
+func sampleEmailContext(name string) mailtemplates.Context {
+	now := time.Now()
+
+	ctx := mailtemplates.Context{
+		Host: models.Host{ID: 1, HostName: "example.com", URL: "https://example.com"},
+		HostService: models.HostServices{
+			ID:        1,
+			HostID:    1,
+			ServiceID: HTTPS,
+			Status:    "healthy",
+			HostName:  "example.com",
+			Service:   models.Services{ID: HTTPS, ServiceName: "HTTPS"},
+		},
+		OldStatus: "healthy",
+		NewStatus: "problem",
+		Message:   "503 Service Unavailable",
+		ComputeTimes: &models.ComputeTimes{
+			DNSDone:        12 * time.Millisecond,
+			ConnectTime:    25 * time.Millisecond,
+			TLSHandshake:   60 * time.Millisecond,
+			FirstByte:      180 * time.Millisecond,
+			TotalTime:      210 * time.Millisecond,
+			ResponseStatus: http.StatusServiceUnavailable,
+			CreatedAt:      now,
+		},
+		Data: map[string]interface{}{
+			"Period": "daily",
+			"From":   now.AddDate(0, 0, -1),
+			"To":     now,
+			"Report": reports.Digest{
+				Period:    "daily",
+				From:      now.AddDate(0, 0, -1),
+				To:        now,
+				Incidents: 1,
+				Downtime:  12 * time.Minute,
+				Hosts: []reports.HostUptime{
+					{HostID: 1, HostName: "example.com", Uptime: 99.17, Incidents: 1, Downtime: 12 * time.Minute},
+				},
+				SlowestServices: []models.ServicePerformance{
+					{HostServiceID: 1, HostName: "example.com", ServiceName: "HTTPS", Checks: 288,
+						AvgTotalTime: 210 * time.Millisecond, MaxTotalTime: 1200 * time.Millisecond},
+				},
+				ExpiringCertificates: []reports.CertificateExpiry{
+					{HostName: "example.com", ExpirationDate: now.AddDate(0, 0, 9).Format("2006-01-02"), DaysUntilExpiration: 9},
+				},
+			},
+		},
+	}
+
+	switch name {
+	case mailtemplates.Warning:
+		ctx.NewStatus = "warning"
+	case mailtemplates.Recovery:
+		ctx.OldStatus = "problem"
+		ctx.NewStatus = "healthy"
+		ctx.Message = "200 OK"
+		ctx.DownSince = now.Add(-12 * time.Minute)
+		ctx.Duration = 12 * time.Minute
+		ctx.ComputeTimes.ResponseStatus = http.StatusOK
+	case mailtemplates.CertificateExpiring:
+		ctx.HostService.ServiceID = SSLCertificate
+		ctx.HostService.Service = models.Services{ID: SSLCertificate, ServiceName: "TLS"}
+		ctx.NewStatus = "warning"
+		ctx.Message = "example.com expiring in 12 days"
+		ctx.ComputeTimes = nil
+	}
+
+	return ctx
+}
@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"golang-observer-project/internal/checks"
+	"golang-observer-project/internal/helpers"
+	"golang-observer-project/internal/models"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// perfdataIndex is the Elastic index of the perfdata of plugins and network checks
+const perfdataIndex = "perfdata"
+
+// addPerfdata stores the perfdata of a check run from location in Elastic
+func (repo *DBRepo) addPerfdata(perfdata []models.Perfdata, location string, h models.Host, hs models.HostServices) {
+	doc := models.CheckPerfdata{
+		ID:            uuid.New().String(),
+		Location:      location,
+		HostID:        h.ID,
+		HostName:      h.HostName,
+		HostServiceID: hs.ID,
+		Perfdata:      perfdata,
+		CreatedAt:     time.Now(),
+	}
+
+	err := repo.ElasticClient.AddPerfdata(perfdataIndex, doc.ID, doc)
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+// HostServiceExec shows the plugin of an exec host service
+func (repo *DBRepo) HostServiceExec(w http.ResponseWriter, r *http.Request) {
+	hs, ok := repo.execHostService(w, r)
+	if !ok {
+		return
+	}
+
+	var response models.ExecCheckResponse
+
+	ec, err := repo.DB.ExecCheck(hs.ID)
+	if errors.Is(err, models.ErrNoRecord) {
+		response.OK = true
+		response.Message = "No plugin set"
+		response.ExecCheck = models.ExecCheck{HostServiceID: hs.ID, Arguments: []string{},
+			TimeoutSeconds: int(checks.DefaultExecTimeout / time.Second)}
+		helpers.RenderJSON(w, response)
+		return
+	} else if err != nil {
+		ClientError(w, r, http.StatusInternalServerError)
+		return
+	}
+
+	response.OK = true
+	response.Message = "Plugin retrieved"
+	response.ExecCheck = ec
+
+	helpers.RenderJSON(w, response)
+}
+
+// PostHostServiceExec sets the plugin an exec host service runs, its arguments and timeout.
+// The plugin is looked up when the check runs, in the plugin directory of the observer or
+// worker, or of the probe, that runs it.
+func (repo *DBRepo) PostHostServiceExec(w http.ResponseWriter, r *http.Request) {
+	hs, ok := repo.execHostService(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.ExecCheckPostRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		log.Println(err)
+		ClientError(w, r, http.StatusBadRequest)
+		return
+	}
+
+	var response models.ExecCheckResponse
+
+	if req.TimeoutSeconds == 0 {
+		req.TimeoutSeconds = int(checks.DefaultExecTimeout / time.Second)
+	}
+	if req.TimeoutSeconds < 1 || time.Duration(req.TimeoutSeconds)*time.Second > checks.MaxExecTimeout {
+		response.Message = "The timeout must be between a second and " + checks.MaxExecTimeout.String()
+		helpers.RenderJSON(w, response)
+		return
+	}
+
+	err = checks.ValidPluginName(req.Command)
+	if err != nil {
+		response.Message = err.Error()
+		helpers.RenderJSON(w, response)
+		return
+	}
+
+	ec := models.ExecCheck{
+		HostServiceID:  hs.ID,
+		Command:        req.Command,
+		Arguments:      req.Arguments,
+		TimeoutSeconds: req.TimeoutSeconds,
+	}
+
+	err = repo.DB.UpsertExecCheck(ec)
+	if err != nil {
+		ClientError(w, r, http.StatusBadRequest)
+		return
+	}
+
+	ec, err = repo.DB.ExecCheck(hs.ID)
+	if err != nil {
+		ClientError(w, r, http.StatusInternalServerError)
+		return
+	}
+
+	response.OK = true
+	response.Message = "Plugin saved"
+	response.ExecCheck = ec
+
+	helpers.RenderJSON(w, response)
+}
+
+// HostServicePerfdata returns the perfdata the checks of a host service reported in the last
+// minutes, from the plugin of an exec check or the values of a network check
+func (repo *DBRepo) HostServicePerfdata(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		ClientError(w, r, http.StatusBadRequest)
+		return
+	}
+
+	minutes, err := strconv.Atoi(chi.URLParam(r, "minutes"))
+	if err != nil || minutes < 1 {
+		ClientError(w, r, http.StatusBadRequest)
+		return
+	}
+
+	perfdata, err := repo.ElasticClient.PerfdataInLastXMinutes(perfdataIndex, minutes, id)
+	if err != nil {
+		ClientError(w, r, http.StatusInternalServerError)
+		return
+	}
+
+	var response models.PerfdataResponse
+	response.OK = true
+	response.Message = "Perfdata retrieved"
+	response.Perfdata = perfdata
+
+	helpers.RenderJSON(w, response)
+}
+
+// execHostService returns the exec host service in the URL, or answers with an error
+func (repo *DBRepo) execHostService(w http.ResponseWriter, r *http.Request) (models.HostServices, bool) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		ClientError(w, r, http.StatusBadRequest)
+		return models.HostServices{}, false
+	}
+
+	hs, err := repo.DB.GetHostServiceByID(id)
+	if err != nil {
+		ClientError(w, r, http.StatusNotFound)
+		return hs, false
+	}
+
+	if hs.ServiceID != Exec {
+		ClientError(w, r, http.StatusBadRequest)
+		return hs, false
+	}
+
+	return hs, true
+}
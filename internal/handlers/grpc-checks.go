@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"golang-observer-project/internal/checks"
+	"golang-observer-project/internal/helpers"
+	"golang-observer-project/internal/models"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// HostServiceGRPC shows the address and TLS settings of a gRPC host service; the client key is
+// not shown, only whether there is one
+func (repo *DBRepo) HostServiceGRPC(w http.ResponseWriter, r *http.Request) {
+	hs, ok := repo.grpcHostService(w, r)
+	if !ok {
+		return
+	}
+
+	gc, err := repo.DB.GRPCCheck(hs.ID)
+	if errors.Is(err, models.ErrNoRecord) {
+		gc = models.GRPCCheck{HostServiceID: hs.ID, TLSMode: checks.TLSDisable,
+			TimeoutSeconds: int(checks.DefaultGRPCTimeout / time.Second)}
+	} else if err != nil {
+		ClientError(w, r, http.StatusInternalServerError)
+		return
+	}
+
+	repo.renderGRPCCheck(w, gc, "gRPC check retrieved")
+}
+
+// PostHostServiceGRPC saves the address and TLS settings of a gRPC host service; an empty client
+// key keeps the one saved before
+func (repo *DBRepo) PostHostServiceGRPC(w http.ResponseWriter, r *http.Request) {
+	hs, ok := repo.grpcHostService(w, r)
+	if !ok {
+		return
+	}
+
+	var gc models.GRPCCheck
+	err := json.NewDecoder(r.Body).Decode(&gc)
+	if err != nil {
+		log.Println(err)
+		ClientError(w, r, http.StatusBadRequest)
+		return
+	}
+
+	if gc.ClientKey == "" && gc.ClientCert != "" {
+		saved, err := repo.DB.GRPCCheck(hs.ID)
+		if err == nil {
+			gc.ClientKey = saved.ClientKey
+		}
+	}
+
+	err = checks.ValidateGRPCCheck(gc)
+	if err != nil {
+		var response models.GRPCCheckResponse
+		response.Message = err.Error()
+		helpers.RenderJSON(w, response)
+		return
+	}
+
+	gc.HostServiceID = hs.ID
+	if gc.TLSMode == "" {
+		gc.TLSMode = checks.TLSDisable
+	}
+	if gc.TimeoutSeconds == 0 {
+		gc.TimeoutSeconds = int(checks.DefaultGRPCTimeout / time.Second)
+	}
+
+	err = repo.DB.UpsertGRPCCheck(gc)
+	if err != nil {
+		ClientError(w, r, http.StatusBadRequest)
+		return
+	}
+
+	gc, err = repo.DB.GRPCCheck(hs.ID)
+	if err != nil {
+		ClientError(w, r, http.StatusInternalServerError)
+		return
+	}
+
+	repo.renderGRPCCheck(w, gc, "gRPC check saved")
+}
+
+// grpcHostService returns the gRPC host service in the URL, or answers with an error
+func (repo *DBRepo) grpcHostService(w http.ResponseWriter, r *http.Request) (models.HostServices, bool) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		ClientError(w, r, http.StatusBadRequest)
+		return models.HostServices{}, false
+	}
+
+	hs, err := repo.DB.GetHostServiceByID(id)
+	if err != nil {
+		ClientError(w, r, http.StatusNotFound)
+		return hs, false
+	}
+
+	if hs.ServiceID != GRPC {
+		ClientError(w, r, http.StatusBadRequest)
+		return hs, false
+	}
+
+	return hs, true
+}
+
+// renderGRPCCheck answers with a gRPC check without its client key
+func (repo *DBRepo) renderGRPCCheck(w http.ResponseWriter, gc models.GRPCCheck, message string) {
+	var response models.GRPCCheckResponse
+	response.OK = true
+	response.Message = message
+	response.ClientKeySet = gc.ClientKey != ""
+
+	gc.ClientKey = ""
+	response.GRPCCheck = gc
+
+	helpers.RenderJSON(w, response)
+}
@@ -126,9 +126,10 @@ func (repo *DBRepo) PostSettings(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// update app config
-	for k, v := range prefMap {
-		app.PreferenceMap[k] = v
-	}
+	app.Preferences.SetAll(prefMap)
+
+	repo.ScheduleDigest()
+	repo.ApplyCheckLimits()
 
 	var jsonResp jsonResp
 	jsonResp.OK = true
@@ -219,14 +220,34 @@ func (repo *DBRepo) PostHost(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 	} else {
-		_, err := repo.DB.InsertHost(host)
+		newID, err := repo.DB.InsertHost(host)
 		if err != nil {
 			log.Println(err)
 			ClientError(w, r, http.StatusBadRequest)
 			return
 		}
+		host.ID = newID
 	}
 
+	// clients that don't know about tags leave them untouched
+	if req.Tags != nil {
+		for _, tag := range req.Tags {
+			if tag = normalizeTag(tag); tag != "" {
+				host.Tags = append(host.Tags, tag)
+			}
+		}
+
+		err = repo.DB.UpdateHostTags(host.ID, host.Tags)
+		if err != nil {
+			log.Println(err)
+			ClientError(w, r, http.StatusBadRequest)
+			return
+		}
+	}
+
+	// (de)activating a host starts or stops the checks of all its services
+	repo.syncHostSchedule(host.ID)
+
 	jsonResp.Host = host
 
 	helpers.RenderJSON(w, jsonResp)
@@ -255,11 +276,10 @@ func (repo *DBRepo) ToggleHostService(w http.ResponseWriter, r *http.Request) {
 	if req.Active == 1 {
 		repo.pushScheduleChangeEvent(hs, "pending")
 		repo.pushStatusChangeEvent(h, hs, "pending")
-		repo.addToMonitorMap(hs)
-	} else {
-		repo.removeFromMonitorMap(hs)
 	}
 
+	repo.syncSchedule(hs, req.Active == 1 && h.Active == 1)
+
 	// return services object to the JSON response
 	helpers.RenderJSON(w, response)
 }
@@ -447,9 +467,14 @@ func (repo *DBRepo) SetSystemPref(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		jsonResp.OK = false
 		jsonResp.Message = err.Error()
+		helpers.RenderJSON(w, jsonResp)
+		return
 	}
 
-	repo.App.PreferenceMap["monitoring_live"] = req.PrefValue
+	repo.App.Preferences.Set(req.PrefName, req.PrefValue)
+	if req.PrefName == "monitoring_live" {
+		repo.applyMonitoringLive()
+	}
 
 	helpers.RenderJSON(w, jsonResp)
 }
@@ -462,29 +487,22 @@ func (repo *DBRepo) ToggleMonitoring(w http.ResponseWriter, r *http.Request) {
 		log.Println(err)
 	}
 
-	if reactJsResponse.Enabled == true {
-		log.Println("Starting monitoring...")
-		repo.App.PreferenceMap["monitoring_live"] = "1"
-		repo.StartMonitoring()
-		repo.App.Scheduler.Start()
-	} else {
-		log.Println("Stopping monitoring...")
-		repo.App.PreferenceMap["monitoring_live"] = "0"
-		for _, v := range repo.App.MonitorMap {
-			repo.App.Scheduler.Remove(v)
-		}
-
-		for k := range repo.App.MonitorMap {
-			delete(repo.App.MonitorMap, k)
-		}
+	live := "0"
+	if reactJsResponse.Enabled {
+		live = "1"
+	}
 
-		// delete all entries from scheduler, be sure to stop the scheduler
-		for _, v := range repo.App.Scheduler.Entries() {
-			repo.App.Scheduler.Remove(v.ID)
-		}
+	// the leader may be another instance, which picks the change up from the database
+	err = repo.DB.UpdateSystemPref("monitoring_live", live)
+	if err != nil {
+		ClientError(w, r, http.StatusInternalServerError)
+		return
+	}
 
-		repo.App.Scheduler.Stop()
+	repo.App.Preferences.Set("monitoring_live", live)
+	repo.applyMonitoringLive()
 
+	if !reactJsResponse.Enabled {
 		data := make(map[string]string)
 		data["message"] = "Monitoring stopped"
 
@@ -0,0 +1,246 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/go-chi/chi/v5"
+	"golang-observer-project/internal/checks"
+	"golang-observer-project/internal/helpers"
+	"golang-observer-project/internal/models"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxHeartbeatLog is the largest log a job can send along with a signal
+const maxHeartbeatLog = 10 * 1024
+
+// newToken returns a random token, for ping URLs and host agents
+func newToken() (string, error) {
+	b := make([]byte, 16)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
+
+// checkHeartbeat decides the status of a heartbeat host service from the signals of its job
+func (repo *DBRepo) checkHeartbeat(hs models.HostServices) checks.Result {
+	token, err := newToken()
+	if err != nil {
+		return checks.Result{Status: "problem", Message: err.Error()}
+	}
+
+	hb, err := repo.DB.EnsureHeartbeat(hs.ID, token)
+	if err != nil {
+		return checks.Result{Status: "problem", Message: err.Error()}
+	}
+
+	return checks.Heartbeat(hb, time.Now())
+}
+
+// pingURL returns the URL a job pings, under heartbeat_base_url, or observer_url
+func (repo *DBRepo) pingURL(token string) string {
+	baseURL := repo.App.Preferences.Get("heartbeat_base_url")
+	if baseURL == "" {
+		baseURL = repo.App.Preferences.Get("observer_url")
+	}
+	if baseURL == "" {
+		baseURL = fmt.Sprintf("http://%s", repo.App.Domain)
+	}
+	return fmt.Sprintf("%s/ping/%s", strings.TrimSuffix(baseURL, "/"), token)
+}
+
+// Ping records a signal from a job: /ping/{token} and /ping/{token}/success report success,
+// /ping/{token}/start a start and /ping/{token}/fail a failure. The body of a POST is kept as
+// the log of the run. The status of the host service is updated right away.
+func (repo *DBRepo) Ping(w http.ResponseWriter, r *http.Request) {
+	signal := chi.URLParam(r, "signal")
+	if signal == "" {
+		signal = models.HeartbeatSuccess
+	}
+
+	switch signal {
+	case models.HeartbeatStart, models.HeartbeatSuccess, models.HeartbeatFail:
+	default:
+		ClientError(w, r, http.StatusNotFound)
+		return
+	}
+
+	hb, err := repo.DB.HeartbeatByToken(chi.URLParam(r, "token"))
+	if errors.Is(err, models.ErrNoRecord) {
+		ClientError(w, r, http.StatusNotFound)
+		return
+	} else if err != nil {
+		ClientError(w, r, http.StatusInternalServerError)
+		return
+	}
+
+	var logPayload []byte
+	if r.Method == http.MethodPost {
+		logPayload, err = io.ReadAll(io.LimitReader(r.Body, maxHeartbeatLog))
+		if err != nil {
+			ClientError(w, r, http.StatusBadRequest)
+			return
+		}
+	}
+
+	hb, err = repo.DB.RecordHeartbeatSignal(hb.HostServiceID, signal, string(logPayload))
+	if err != nil {
+		ClientError(w, r, http.StatusInternalServerError)
+		return
+	}
+
+	repo.applyHeartbeat(hb)
+
+	var jsonResp jsonResp
+	jsonResp.OK = true
+	jsonResp.Message = "OK"
+
+	helpers.RenderJSON(w, jsonResp)
+}
+
+// applyHeartbeat updates the status of a monitored heartbeat host service after a signal,
+// without waiting for its next scheduled check
+func (repo *DBRepo) applyHeartbeat(hb models.Heartbeat) {
+	hs, err := repo.DB.GetHostServiceByID(hb.HostServiceID)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	h, err := repo.DB.FindHostByID(hs.HostID)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	if hs.Active != 1 || h.Active != 1 {
+		return
+	}
+
+	result := checks.Heartbeat(hb, time.Now())
+	repo.recordStatus(h, hs, result.Status, result.Message)
+
+	if result.Status != hs.Status {
+		repo.updateHostServiceStatusCount(h, hs, result.Status, result.Message)
+	}
+}
+
+// HostServiceHeartbeat shows the ping URL, settings, last signals and status of a heartbeat,
+// setting it up when needed
+func (repo *DBRepo) HostServiceHeartbeat(w http.ResponseWriter, r *http.Request) {
+	hs, ok := repo.heartbeatHostService(w, r)
+	if !ok {
+		return
+	}
+
+	token, err := newToken()
+	if err != nil {
+		ClientError(w, r, http.StatusInternalServerError)
+		return
+	}
+
+	hb, err := repo.DB.EnsureHeartbeat(hs.ID, token)
+	if err != nil {
+		ClientError(w, r, http.StatusBadRequest)
+		return
+	}
+
+	repo.renderHeartbeat(w, hb, "Heartbeat retrieved")
+}
+
+// PostHostServiceHeartbeat saves the period and grace time of a heartbeat, and gives it a new
+// ping URL when new_token is set
+func (repo *DBRepo) PostHostServiceHeartbeat(w http.ResponseWriter, r *http.Request) {
+	hs, ok := repo.heartbeatHostService(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.HeartbeatPostRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		log.Println(err)
+		ClientError(w, r, http.StatusBadRequest)
+		return
+	}
+
+	if req.PeriodSeconds < 1 || req.GraceSeconds < 0 {
+		var response models.HeartbeatResponse
+		response.Message = "The period must be at least a second and the grace time cannot be negative"
+		helpers.RenderJSON(w, response)
+		return
+	}
+
+	token, err := newToken()
+	if err != nil {
+		ClientError(w, r, http.StatusInternalServerError)
+		return
+	}
+
+	hb, err := repo.DB.EnsureHeartbeat(hs.ID, token)
+	if err != nil {
+		ClientError(w, r, http.StatusBadRequest)
+		return
+	}
+
+	hb.PeriodSeconds = req.PeriodSeconds
+	hb.GraceSeconds = req.GraceSeconds
+	if req.NewToken {
+		hb.Token = token
+	}
+
+	err = repo.DB.UpdateHeartbeat(hb)
+	if err != nil {
+		ClientError(w, r, http.StatusBadRequest)
+		return
+	}
+
+	repo.renderHeartbeat(w, hb, "Heartbeat saved")
+}
+
+// heartbeatHostService returns the heartbeat host service in the URL, or answers with an error
+func (repo *DBRepo) heartbeatHostService(w http.ResponseWriter, r *http.Request) (models.HostServices, bool) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		ClientError(w, r, http.StatusBadRequest)
+		return models.HostServices{}, false
+	}
+
+	hs, err := repo.DB.GetHostServiceByID(id)
+	if err != nil {
+		ClientError(w, r, http.StatusNotFound)
+		return hs, false
+	}
+
+	if hs.ServiceID != Heartbeat {
+		ClientError(w, r, http.StatusBadRequest)
+		return hs, false
+	}
+
+	return hs, true
+}
+
+// renderHeartbeat answers with a heartbeat, its ping URL and its current status
+func (repo *DBRepo) renderHeartbeat(w http.ResponseWriter, hb models.Heartbeat, message string) {
+	result := checks.Heartbeat(hb, time.Now())
+
+	var response models.HeartbeatResponse
+	response.OK = true
+	response.Message = message
+	response.Heartbeat = hb
+	response.PingURL = repo.pingURL(hb.Token)
+	response.Status = result.Status
+	response.StatusMessage = result.Message
+
+	helpers.RenderJSON(w, response)
+}
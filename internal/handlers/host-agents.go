@@ -0,0 +1,289 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"golang-observer-project/internal/checks"
+	"golang-observer-project/internal/helpers"
+	"golang-observer-project/internal/models"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// hostMetricsIndex is the Elastic index of the metrics host agents report
+const hostMetricsIndex = "host-metrics"
+
+type hostAgentContextKey struct{}
+
+// WithHostAgent returns a context carrying the authenticated host agent
+func WithHostAgent(ctx context.Context, a models.HostAgent) context.Context {
+	return context.WithValue(ctx, hostAgentContextKey{}, a)
+}
+
+// hostAgentFromContext returns the host agent authenticated for a request
+func hostAgentFromContext(ctx context.Context) (models.HostAgent, bool) {
+	a, ok := ctx.Value(hostAgentContextKey{}).(models.HostAgent)
+	return a, ok
+}
+
+// AuthenticateHostAgent returns the host agent with the token
+func (repo *DBRepo) AuthenticateHostAgent(token string) (models.HostAgent, error) {
+	return repo.DB.HostAgentByToken(token)
+}
+
+// checkSystem decides the status of a system host service from the latest metrics of its agent
+func (repo *DBRepo) checkSystem(hs models.HostServices) checks.Result {
+	token, err := newToken()
+	if err != nil {
+		return checks.Result{Status: "problem", Message: err.Error()}
+	}
+
+	agent, err := repo.DB.EnsureHostAgent(hs.ID, token)
+	if err != nil {
+		return checks.Result{Status: "problem", Message: err.Error()}
+	}
+
+	thresholds, err := repo.DB.MetricThresholds(hs.ID)
+	if err != nil {
+		return checks.Result{Status: "problem", Message: err.Error()}
+	}
+
+	return checks.System(agent, thresholds, time.Now())
+}
+
+// PostHostMetrics takes the metrics of a host agent, stores them in Elastic and updates the
+// status of the system host service right away
+func (repo *DBRepo) PostHostMetrics(w http.ResponseWriter, r *http.Request) {
+	agent, ok := hostAgentFromContext(r.Context())
+	if !ok {
+		ClientError(w, r, http.StatusUnauthorized)
+		return
+	}
+
+	var req models.HostMetricsRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		log.Println(err)
+		ClientError(w, r, http.StatusBadRequest)
+		return
+	}
+
+	var jsonResp jsonResp
+
+	if req.IntervalSeconds < 1 {
+		jsonResp.Message = "The interval must be at least a second"
+		helpers.RenderJSON(w, jsonResp)
+		return
+	}
+
+	hs, err := repo.DB.GetHostServiceByID(agent.HostServiceID)
+	if err != nil {
+		ClientError(w, r, http.StatusNotFound)
+		return
+	}
+
+	h, err := repo.DB.FindHostByID(hs.HostID)
+	if err != nil {
+		ClientError(w, r, http.StatusNotFound)
+		return
+	}
+
+	metrics := req.Metrics
+	metrics.ID = uuid.New().String()
+	metrics.HostID = h.ID
+	metrics.HostName = h.HostName
+	metrics.HostServiceID = hs.ID
+	metrics.CreatedAt = time.Now()
+
+	err = repo.ElasticClient.AddHostMetrics(hostMetricsIndex, metrics.ID, metrics)
+	if err != nil {
+		log.Println(err)
+	}
+
+	agent, err = repo.DB.RecordHostMetrics(hs.ID, req.IntervalSeconds, metrics)
+	if err != nil {
+		ClientError(w, r, http.StatusInternalServerError)
+		return
+	}
+
+	repo.applyHostMetrics(h, hs, agent)
+
+	jsonResp.OK = true
+	jsonResp.Message = "OK"
+
+	helpers.RenderJSON(w, jsonResp)
+}
+
+// applyHostMetrics updates the status of a monitored system host service after its agent
+// reported, without waiting for its next scheduled check
+func (repo *DBRepo) applyHostMetrics(h models.Host, hs models.HostServices, agent models.HostAgent) {
+	if hs.Active != 1 || h.Active != 1 {
+		return
+	}
+
+	thresholds, err := repo.DB.MetricThresholds(hs.ID)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	result := checks.System(agent, thresholds, time.Now())
+	repo.recordStatus(h, hs, result.Status, result.Message)
+
+	if result.Status != hs.Status {
+		repo.updateHostServiceStatusCount(h, hs, result.Status, result.Message)
+	}
+}
+
+// HostServiceAgent shows the token, latest metrics, thresholds and status of the agent of a
+// system host service, setting it up when needed
+func (repo *DBRepo) HostServiceAgent(w http.ResponseWriter, r *http.Request) {
+	hs, ok := repo.systemHostService(w, r)
+	if !ok {
+		return
+	}
+
+	token, err := newToken()
+	if err != nil {
+		ClientError(w, r, http.StatusInternalServerError)
+		return
+	}
+
+	agent, err := repo.DB.EnsureHostAgent(hs.ID, token)
+	if err != nil {
+		ClientError(w, r, http.StatusBadRequest)
+		return
+	}
+
+	repo.renderHostAgent(w, r, agent, "Host agent retrieved")
+}
+
+// PostHostServiceAgent replaces the thresholds of a system host service, and gives its agent a
+// new token when new_token is set
+func (repo *DBRepo) PostHostServiceAgent(w http.ResponseWriter, r *http.Request) {
+	hs, ok := repo.systemHostService(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.HostAgentPostRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		log.Println(err)
+		ClientError(w, r, http.StatusBadRequest)
+		return
+	}
+
+	for _, t := range req.Thresholds {
+		if !checks.IsMetric(t.Metric) || t.Warning < 0 || t.Problem < t.Warning {
+			var response models.HostAgentResponse
+			response.Message = "Thresholds need a known metric (cpu, memory, disk or load) and a problem value no lower than the warning value"
+			helpers.RenderJSON(w, response)
+			return
+		}
+	}
+
+	token, err := newToken()
+	if err != nil {
+		ClientError(w, r, http.StatusInternalServerError)
+		return
+	}
+
+	agent, err := repo.DB.EnsureHostAgent(hs.ID, token)
+	if err != nil {
+		ClientError(w, r, http.StatusBadRequest)
+		return
+	}
+
+	if req.NewToken {
+		err = repo.DB.UpdateHostAgentToken(hs.ID, token)
+		if err != nil {
+			ClientError(w, r, http.StatusBadRequest)
+			return
+		}
+		agent.Token = token
+	}
+
+	err = repo.DB.SetMetricThresholds(hs.ID, req.Thresholds)
+	if err != nil {
+		ClientError(w, r, http.StatusBadRequest)
+		return
+	}
+
+	repo.renderHostAgent(w, r, agent, "Host agent saved")
+}
+
+// HostServiceMetrics returns the metrics the agent of a system host service reported in the
+// last minutes
+func (repo *DBRepo) HostServiceMetrics(w http.ResponseWriter, r *http.Request) {
+	hs, ok := repo.systemHostService(w, r)
+	if !ok {
+		return
+	}
+
+	minutes, err := strconv.Atoi(chi.URLParam(r, "minutes"))
+	if err != nil || minutes < 1 {
+		ClientError(w, r, http.StatusBadRequest)
+		return
+	}
+
+	metrics, err := repo.ElasticClient.HostMetricsInLastXMinutes(hostMetricsIndex, minutes, hs.ID)
+	if err != nil {
+		ClientError(w, r, http.StatusInternalServerError)
+		return
+	}
+
+	var response models.HostMetricsResponse
+	response.OK = true
+	response.Message = "Host metrics retrieved"
+	response.Metrics = metrics
+
+	helpers.RenderJSON(w, response)
+}
+
+// systemHostService returns the system host service in the URL, or answers with an error
+func (repo *DBRepo) systemHostService(w http.ResponseWriter, r *http.Request) (models.HostServices, bool) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		ClientError(w, r, http.StatusBadRequest)
+		return models.HostServices{}, false
+	}
+
+	hs, err := repo.DB.GetHostServiceByID(id)
+	if err != nil {
+		ClientError(w, r, http.StatusNotFound)
+		return hs, false
+	}
+
+	if hs.ServiceID != System {
+		ClientError(w, r, http.StatusBadRequest)
+		return hs, false
+	}
+
+	return hs, true
+}
+
+// renderHostAgent answers with a host agent, the thresholds in effect and its current status
+func (repo *DBRepo) renderHostAgent(w http.ResponseWriter, r *http.Request, agent models.HostAgent, message string) {
+	thresholds, err := repo.DB.MetricThresholds(agent.HostServiceID)
+	if err != nil {
+		ClientError(w, r, http.StatusInternalServerError)
+		return
+	}
+
+	result := checks.System(agent, thresholds, time.Now())
+
+	var response models.HostAgentResponse
+	response.OK = true
+	response.Message = message
+	response.Agent = agent
+	response.Thresholds = checks.Thresholds(thresholds)
+	response.Status = result.Status
+	response.StatusMessage = result.Message
+
+	helpers.RenderJSON(w, response)
+}
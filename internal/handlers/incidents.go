@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"github.com/go-chi/chi/v5"
+	"golang-observer-project/internal/helpers"
+	"golang-observer-project/internal/notifiers"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// incidentNotifier describes a paging integration that is switched on and configured through
+// preferences
+type incidentNotifier struct {
+	channel    string
+	enabledKey string
+	urlKey     string
+	tokenKey   string
+	send       func(baseURL, token, action string, sc notifiers.StatusChange) error
+}
+
+var incidentNotifiers = []incidentNotifier{
+	{"pagerduty", "notify_via_pagerduty", "pagerduty_api_url", "pagerduty_routing_key", notifiers.SendPagerDuty},
+	{"opsgenie", "notify_via_opsgenie", "opsgenie_api_url", "opsgenie_api_key", notifiers.SendOpsgenie},
+}
+
+// sendIncidentNotifications sends an incident action to every enabled paging integration
+// and returns the last delivery error
+func (repo *DBRepo) sendIncidentNotifications(action string, sc notifiers.StatusChange) error {
+	var lastErr error
+
+	for _, n := range incidentNotifiers {
+		token := repo.App.Preferences.Get(n.tokenKey)
+		if repo.App.Preferences.Get(n.enabledKey) != "1" || token == "" {
+			continue
+		}
+
+		err := n.send(repo.App.Preferences.Get(n.urlKey), token, action, sc)
+		repo.logNotification(n.channel, action+" "+notifiers.DedupKey(sc.HostServiceID), sc, 1, err)
+		if err != nil {
+			lastErr = err
+		}
+	}
+
+	return lastErr
+}
+
+// AcknowledgeHostService acknowledges the open incident of a host service in the paging tools
+func (repo *DBRepo) AcknowledgeHostService(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.Atoi(chi.URLParam(r, "id"))
+
+	hs, err := repo.DB.GetHostServiceByID(id)
+	if err != nil {
+		log.Println(err)
+		ClientError(w, r, http.StatusNotFound)
+		return
+	}
+
+	h, err := repo.DB.FindHostByID(hs.HostID)
+	if err != nil {
+		log.Println(err)
+		ClientError(w, r, http.StatusNotFound)
+		return
+	}
+
+	msg := "Acknowledged in Observer"
+	eventID := repo.addEvents(h, hs, "acknowledged", msg)
+
+	sc := notifiers.StatusChange{
+		IncidentID:    eventID,
+		HostID:        h.ID,
+		HostServiceID: hs.ID,
+		ServiceID:     hs.ServiceID,
+		HostName:      h.HostName,
+		ServiceName:   hs.Service.ServiceName,
+		OldStatus:     hs.Status,
+		NewStatus:     hs.Status,
+		Message:       msg,
+		Link:          repo.hostLink(h.ID),
+		CheckedAt:     time.Now(),
+	}
+
+	var jsonResp jsonResp
+	jsonResp.OK = true
+	jsonResp.Message = "Host service acknowledged"
+	jsonResp.HostServiceID = hs.ID
+	jsonResp.HostID = hs.HostID
+
+	err = repo.sendIncidentNotifications(notifiers.IncidentAcknowledge, sc)
+	if err != nil {
+		jsonResp.OK = false
+		jsonResp.Message = err.Error()
+	}
+
+	helpers.RenderJSON(w, jsonResp)
+}
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"golang-observer-project/internal/election"
+	"golang-observer-project/internal/helpers"
+	"log"
+	"net/http"
+)
+
+// isLeader reports whether this process is the elected leader, which runs the scheduler and
+// sends the digest
+func (repo *DBRepo) isLeader() bool {
+	return repo.App.Elector != nil && repo.App.Elector.IsLeader()
+}
+
+// BecomeLeader takes over the scheduler when this process is elected: the preferences are read
+// again, since another instance may have changed them, and the scheduler entries are rebuilt
+// from the database
+func (repo *DBRepo) BecomeLeader() {
+	changed, err := repo.reloadPreferences()
+	if err != nil {
+		log.Println(err)
+	} else if len(changed) > 0 {
+		repo.ScheduleDigest()
+		repo.ApplyCheckLimits()
+	}
+
+	repo.App.Scheduler.RemoveAll()
+	repo.StartMonitoring()
+
+	if repo.App.Preferences.Get("monitoring_live") == "1" {
+		repo.App.Scheduler.Start()
+	}
+}
+
+// StepDown stops scheduling checks when this process is no longer the leader
+func (repo *DBRepo) StepDown() {
+	repo.App.Scheduler.RemoveAll()
+	repo.App.Scheduler.Stop()
+}
+
+type leaderStatusResponse struct {
+	OK      bool            `json:"ok"`
+	Message string          `json:"message"`
+	Mode    string          `json:"mode"`
+	Status  election.Status `json:"status"`
+}
+
+// LeaderStatus shows whether this process is the leader, since when, and which process leads
+func (repo *DBRepo) LeaderStatus(w http.ResponseWriter, r *http.Request) {
+	var response leaderStatusResponse
+	response.OK = true
+	response.Message = "Leader status"
+	response.Mode = repo.App.CheckMode
+
+	if repo.App.Elector == nil {
+		response.Message = "Workers do not take part in leader election"
+		response.Status.ID = repo.App.WorkerID
+	} else {
+		response.Status = repo.App.Elector.Status()
+	}
+
+	helpers.RenderJSON(w, response)
+}
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"golang-observer-project/internal/helpers"
+	"golang-observer-project/internal/models"
+	"log"
+	"net/http"
+	"strconv"
+)
+
+// MailQueue shows the depth of the mail outbox, emails being retried and the dead letters
+func (repo *DBRepo) MailQueue(w http.ResponseWriter, r *http.Request) {
+	stats, err := repo.DB.MailQueueStats()
+	if err != nil {
+		log.Println(err)
+		ClientError(w, r, http.StatusBadRequest)
+		return
+	}
+
+	failing, err := repo.DB.OutboxMailByStatus(models.MailPending, true, 50)
+	if err != nil {
+		log.Println(err)
+		ClientError(w, r, http.StatusBadRequest)
+		return
+	}
+
+	dead, err := repo.DB.OutboxMailByStatus(models.MailDead, false, 50)
+	if err != nil {
+		log.Println(err)
+		ClientError(w, r, http.StatusBadRequest)
+		return
+	}
+
+	var response models.MailQueueResponse
+	response.OK = true
+	response.Message = "Mail queue retrieved"
+	response.Stats = stats
+	response.Failing = failing
+	response.DeadLetters = dead
+
+	helpers.RenderJSON(w, response)
+}
+
+// RetryMail puts a dead letter back in the outbox
+func (repo *DBRepo) RetryMail(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		ClientError(w, r, http.StatusBadRequest)
+		return
+	}
+
+	var jsonResp jsonResp
+	jsonResp.OK = true
+	jsonResp.Message = "Email queued again"
+
+	err = repo.DB.RequeueMail(id)
+	if errors.Is(err, models.ErrNoRecord) {
+		jsonResp.OK = false
+		jsonResp.Message = "No dead letter with that id"
+	} else if err != nil {
+		ClientError(w, r, http.StatusBadRequest)
+		return
+	}
+
+	helpers.RenderJSON(w, jsonResp)
+}
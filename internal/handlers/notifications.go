@@ -0,0 +1,293 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"github.com/go-chi/chi/v5"
+	"golang-observer-project/internal/channeldata"
+	"golang-observer-project/internal/helpers"
+	"golang-observer-project/internal/models"
+	"golang-observer-project/internal/notifiers"
+	"golang-observer-project/internal/sms"
+	"html/template"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// notifyStatusChange sends a host service status change to every enabled notification channel
+func (repo *DBRepo) notifyStatusChange(h models.Host, hs models.HostServices, newStatus, msg string, incidentID int) {
+	sc := notifiers.StatusChange{
+		IncidentID:    incidentID,
+		HostID:        h.ID,
+		HostServiceID: hs.ID,
+		ServiceID:     hs.ServiceID,
+		HostName:      h.HostName,
+		ServiceName:   hs.Service.ServiceName,
+		OldStatus:     hs.Status,
+		NewStatus:     newStatus,
+		Message:       msg,
+		Link:          repo.hostLink(h.ID),
+		CheckedAt:     time.Now(),
+	}
+
+	// webhooks retry with backoff, so deliver them without holding up the check
+	goBackground(func() { repo.sendWebhooks(sc) })
+
+	// a change into pending, such as an exec check exiting UNKNOWN, is not announced, nor a
+	// change out of it, such as the first result of a new host service
+	if hs.Status != "pending" && newStatus != "pending" {
+		goBackground(func() { repo.sendChatNotifications(sc) })
+		goBackground(func() { repo.notifyRecipients(h, hs, sc) })
+	}
+
+	if action := notifiers.IncidentAction(hs.Status, newStatus); action != "" {
+		goBackground(func() {
+			_ = repo.sendIncidentNotifications(action, sc)
+		})
+	}
+}
+
+// notifyRecipients sends a status change by email, sms and telegram to every user subscribed
+// to it, or to the global notify_email and sms_notify_number when nobody has subscribed
+func (repo *DBRepo) notifyRecipients(h models.Host, hs models.HostServices, sc notifiers.StatusChange) {
+	contacts, ok := repo.subscribedContacts(h, sc.OldStatus, sc.NewStatus)
+	if !ok {
+		contacts = []contact{
+			{Name: repo.App.Preferences.Get("notify_name"), MethodType: "email", Address: repo.App.Preferences.Get("notify_email")},
+			{MethodType: "sms", Address: repo.App.Preferences.Get("sms_notify_number")},
+		}
+	}
+
+	// the email is the same for every recipient, so it is rendered at most once
+	var subject string
+	var content template.HTML
+	var rendered bool
+
+	for _, c := range contacts {
+		if c.Address == "" {
+			continue
+		}
+
+		switch c.MethodType {
+		case "email":
+			if repo.App.Preferences.Get("notify_via_email") != "1" {
+				continue
+			}
+
+			if !rendered {
+				var err error
+				subject, content, err = repo.renderStatusEmail(h, hs, sc)
+				if err != nil {
+					log.Println("cannot render status email:", err)
+					return
+				}
+				rendered = true
+			}
+
+			// the outbox keeps track of a queued email; one that could not be queued is never
+			// sent, so it is logged as failed here
+			err := helpers.SendEmail(channeldata.MailData{
+				ToName:    c.Name,
+				ToAddress: c.Address,
+				Subject:   subject,
+				Content:   content,
+			})
+			if err != nil {
+				repo.logNotification("email", c.Address, sc, 0, err)
+			}
+		case "sms":
+			if repo.App.Preferences.Get("notify_via_sms") == "1" {
+				repo.sendSMS(c.Address, sc)
+			}
+		case "telegram":
+			if repo.App.Preferences.Get("notify_via_telegram") == "1" {
+				err := notifiers.SendTelegram(repo.App.Preferences.Get("telegram_api_url"),
+					repo.App.Preferences.Get("telegram_bot_token"), c.Address, sc)
+				repo.logNotification("telegram", c.Address, sc, 1, err)
+			}
+		}
+	}
+}
+
+// chatNotifier describes a chat integration that is switched on and configured through preferences
+type chatNotifier struct {
+	channel    string
+	enabledKey string
+	urlKey     string
+	send       func(webhookURL string, sc notifiers.StatusChange) error
+}
+
+var chatNotifiers = []chatNotifier{
+	{"slack", "notify_via_slack", "slack_webhook_url", notifiers.SendSlack},
+	{"teams", "notify_via_teams", "teams_webhook_url", notifiers.SendTeams},
+	{"discord", "notify_via_discord", "discord_webhook_url", notifiers.SendDiscord},
+}
+
+// sendChatNotifications posts a status change to every enabled chat integration
+func (repo *DBRepo) sendChatNotifications(sc notifiers.StatusChange) {
+	for _, n := range chatNotifiers {
+		webhookURL := repo.App.Preferences.Get(n.urlKey)
+		if repo.App.Preferences.Get(n.enabledKey) != "1" || webhookURL == "" {
+			continue
+		}
+
+		err := n.send(webhookURL, sc)
+		repo.logNotification(n.channel, webhookURL, sc, 1, err)
+	}
+
+	if repo.App.Preferences.Get("notify_via_telegram") == "1" {
+		chatID := repo.App.Preferences.Get("telegram_chat_id")
+		err := notifiers.SendTelegram(repo.App.Preferences.Get("telegram_api_url"),
+			repo.App.Preferences.Get("telegram_bot_token"), chatID, sc)
+		repo.logNotification("telegram", chatID, sc, 1, err)
+	}
+}
+
+// sendSMS texts a status change through the configured SMS provider
+func (repo *DBRepo) sendSMS(to string, sc notifiers.StatusChange) {
+	msg := fmt.Sprintf("Service %s on host %s is now %s", sc.ServiceName, sc.HostName, strings.ToUpper(sc.NewStatus))
+
+	provider, err := sms.NewProvider(repo.App.Preferences.Map())
+	if err != nil {
+		repo.logNotification("sms", to, sc, 0, err)
+		return
+	}
+
+	messageID, err := provider.Send(to, msg)
+	if err == nil && messageID != "" {
+		log.Printf("%s accepted sms %s\n", provider.Name(), messageID)
+	}
+	repo.logNotification("sms/"+provider.Name(), to, sc, 1, err)
+}
+
+// SendTestChatNotification sends a sample status change to one chat or sms integration,
+// even if it is not switched on yet, so its settings can be verified
+func (repo *DBRepo) SendTestChatNotification(w http.ResponseWriter, r *http.Request) {
+	channel := chi.URLParam(r, "channel")
+
+	var jsonResp jsonResp
+	jsonResp.OK = false
+	jsonResp.Message = "Unknown notification channel"
+
+	switch channel {
+	case "telegram":
+		chatID := repo.App.Preferences.Get("telegram_chat_id")
+		err := notifiers.SendTelegram(repo.App.Preferences.Get("telegram_api_url"),
+			repo.App.Preferences.Get("telegram_bot_token"), chatID, sampleStatusChange())
+		repo.logNotification("telegram", chatID, sampleStatusChange(), 1, err)
+		jsonResp.OK, jsonResp.Message = testResult(err)
+	case "sms":
+		to := repo.App.Preferences.Get("sms_notify_number")
+		provider, err := sms.NewProvider(repo.App.Preferences.Map())
+		if err != nil {
+			repo.logNotification("sms", to, sampleStatusChange(), 0, err)
+		} else {
+			_, err = provider.Send(to, "This is a test message from Observer")
+			repo.logNotification("sms/"+provider.Name(), to, sampleStatusChange(), 1, err)
+		}
+		jsonResp.OK, jsonResp.Message = testResult(err)
+	}
+
+	for _, n := range chatNotifiers {
+		if n.channel != channel {
+			continue
+		}
+
+		webhookURL := repo.App.Preferences.Get(n.urlKey)
+		if webhookURL == "" {
+			jsonResp.Message = fmt.Sprintf("Preference %s is not set", n.urlKey)
+			break
+		}
+
+		sc := sampleStatusChange()
+		sc.Link = repo.hostLink(0)
+		err := n.send(webhookURL, sc)
+		repo.logNotification(n.channel, webhookURL, sc, 1, err)
+		jsonResp.OK, jsonResp.Message = testResult(err)
+	}
+
+	helpers.RenderJSON(w, jsonResp)
+}
+
+// testResult turns the outcome of a test notification into a response status and message
+func testResult(err error) (bool, string) {
+	if err != nil {
+		return false, err.Error()
+	}
+	return true, "Test notification sent"
+}
+
+// hostLink returns a link to a host in the observer front end
+func (repo *DBRepo) hostLink(hostID int) string {
+	baseURL := repo.App.Preferences.Get("observer_url")
+	if baseURL == "" {
+		baseURL = fmt.Sprintf("http://%s", repo.App.Domain)
+	}
+	return fmt.Sprintf("%s/admin/host/%d", strings.TrimSuffix(baseURL, "/"), hostID)
+}
+
+// sendWebhooks delivers a status change to all active webhooks, each on its own so one that
+// is down does not hold up the others while it is retried
+func (repo *DBRepo) sendWebhooks(sc notifiers.StatusChange) {
+	webhooks, err := repo.DB.AllWebhooks()
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	for _, wh := range webhooks {
+		if wh.Active != 1 {
+			continue
+		}
+		wh := wh
+		goBackground(func() { _ = repo.deliverWebhook(backgroundCtx, wh, sc, notifiers.WebhookMaxAttempts) })
+	}
+}
+
+// deliverWebhook sends a single webhook and records the outcome in the notification log
+func (repo *DBRepo) deliverWebhook(ctx context.Context, wh models.Webhook, sc notifiers.StatusChange, maxAttempts int) error {
+	attempts, err := notifiers.SendWebhook(ctx, wh, sc, maxAttempts)
+	repo.logNotification("webhook", wh.URL, sc, attempts, err)
+	return err
+}
+
+// logNotification records a notification delivery attempt
+func (repo *DBRepo) logNotification(channel, target string, sc notifiers.StatusChange, attempts int, sendErr error) {
+	entry := models.NotificationLog{
+		Channel:       channel,
+		Target:        target,
+		HostServiceID: sc.HostServiceID,
+		EventType:     sc.NewStatus,
+		Status:        "sent",
+		Attempts:      attempts,
+	}
+	if sendErr != nil {
+		log.Printf("%s notification to %s failed: %s\n", channel, target, sendErr)
+		entry.Status = "failed"
+		entry.Error = sendErr.Error()
+	}
+
+	err := repo.DB.InsertNotificationLog(entry)
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+// NotificationLog lists the most recent notification deliveries
+func (repo *DBRepo) NotificationLog(w http.ResponseWriter, r *http.Request) {
+	entries, err := repo.DB.RecentNotificationLogs(100)
+	if err != nil {
+		log.Println(err)
+		ClientError(w, r, http.StatusBadRequest)
+		return
+	}
+
+	var response models.NotificationLogResponse
+	response.OK = true
+	response.Message = "Notification log retrieved"
+	response.Entries = entries
+
+	helpers.RenderJSON(w, response)
+}
@@ -4,23 +4,30 @@ import (
 	"fmt"
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
-	"golang-observer-project/internal/certificateutils"
-	"golang-observer-project/internal/channeldata"
+	"github.com/robfig/cron/v3"
+	"golang-observer-project/internal/checks"
 	"golang-observer-project/internal/helpers"
 	"golang-observer-project/internal/models"
-	"golang-observer-project/internal/sms"
-	"html/template"
+	"golang-observer-project/internal/scheduling"
 	"log"
 	"net/http"
 	"strconv"
-	"strings"
 	"time"
 )
 
 const (
-	HTTP           = 1
-	HTTPS          = 2
-	SSLCertificate = 3
+	HTTP           = checks.ServiceHTTP
+	HTTPS          = checks.ServiceHTTPS
+	SSLCertificate = checks.ServiceSSLCertificate
+	Heartbeat      = checks.ServiceHeartbeat
+	System         = checks.ServiceSystem
+	Exec           = checks.ServiceExec
+	Transaction    = checks.ServiceTransaction
+	Postgres       = checks.ServicePostgres
+	MySQL          = checks.ServiceMySQL
+	Redis          = checks.ServiceRedis
+	GRPC           = checks.ServiceGRPC
+	WebSocket      = checks.ServiceWebSocket
 )
 
 type jsonResp struct {
@@ -34,19 +41,28 @@ type jsonResp struct {
 	LastCheck     time.Time `json:"last_check"`
 }
 
-// ScheduledCheck is used to check a host service on a schedule
-func (repo *DBRepo) ScheduledCheck(hsID int) {
+// ScheduledCheck is used to check a host service on a schedule; the error tells a worker
+// that the check could not be run at all
+func (repo *DBRepo) ScheduledCheck(hsID int) error {
 
 	hs, err := repo.DB.GetHostServiceByID(hsID)
 	if err != nil {
 		log.Println(err)
-		return
+		return err
 	}
 
 	h, err := repo.DB.FindHostByID(hs.HostID)
 	if err != nil {
 		log.Println(err)
-		return
+		return err
+	}
+
+	// services checked by probes get their status from the quorum of the probe results, also
+	// when some probes stopped reporting
+	probes, err := repo.DB.ProbesForHostService(hs.ID)
+	if err == nil && len(probes) > 0 {
+		repo.applyQuorum(hs.ID)
+		return nil
 	}
 
 	newStatus, msg := repo.testServiceForHost(h, hs)
@@ -55,6 +71,7 @@ func (repo *DBRepo) ScheduledCheck(hsID int) {
 		repo.updateHostServiceStatusCount(h, hs, newStatus, msg)
 	}
 
+	return nil
 }
 
 func (repo *DBRepo) updateHostServiceStatusCount(h models.Host, hs models.HostServices, newStatus, msg string) {
@@ -124,6 +141,32 @@ func (repo *DBRepo) PerformCheck(w http.ResponseWriter, r *http.Request) {
 		okay = false
 	}
 
+	// services checked by probes get their status from the quorum of the probe results, not
+	// from a check run here
+	probes, err := repo.DB.ProbesForHostService(hs.ID)
+	if okay && err == nil && len(probes) > 0 {
+		repo.applyQuorum(hs.ID)
+
+		hs, err = repo.DB.GetHostServiceByID(hs.ID)
+		if err != nil {
+			log.Printf("error getting host service by id: %s\n", err)
+			helpers.RenderJSON(w, jsonResp{OK: false, Message: "error getting host service"})
+			return
+		}
+
+		helpers.RenderJSON(w, jsonResp{
+			OK:            true,
+			Message:       hs.LastMessage,
+			ServiceID:     hs.ServiceID,
+			HostServiceID: hs.ID,
+			HostID:        hs.HostID,
+			OldStatus:     oldStatus,
+			NewStatus:     hs.Status,
+			LastCheck:     hs.LastCheck,
+		})
+		return
+	}
+
 	newStatus, msg := repo.testServiceForHost(h, hs)
 	repo.addEvents(h, hs, newStatus, msg)
 	if newStatus != hs.Status {
@@ -173,80 +216,51 @@ func (repo *DBRepo) PerformCheck(w http.ResponseWriter, r *http.Request) {
 }
 
 func (repo *DBRepo) testServiceForHost(h models.Host, hs models.HostServices) (string, string) {
-	var msg, newStatus string
-
+	// heartbeats and host agents report in; every other service is checked by its checker
+	var result checks.Result
 	switch hs.ServiceID {
-	case HTTP:
-		msg, newStatus = repo.testHTTP(h.URL, h, hs)
-		break
-	case HTTPS:
-		msg, newStatus = repo.testHTTPS(h.URL, h, hs)
-		break
-	case SSLCertificate:
-		msg, newStatus = repo.testSSLCert(h.URL, h, hs)
-		break
+	case Heartbeat:
+		result = repo.checkHeartbeat(hs)
+	case System:
+		result = repo.checkSystem(hs)
+	default:
+		result = repo.runCheck(h, hs)
+	}
+
+	if result.ComputeTimes != nil {
+		repo.addComputeTimes(result.ComputeTimes, repo.App.Identifier, h, hs)
+	}
+
+	if len(result.Perfdata) > 0 {
+		repo.addPerfdata(result.Perfdata, repo.App.Identifier, h, hs)
+	}
+
+	if len(result.Steps) > 0 {
+		repo.addStepTimes(result.Steps, repo.App.Identifier, h, hs)
 	}
 
+	repo.recordStatus(h, hs, result.Status, result.Message)
+
+	return result.Status, result.Message
+}
+
+// recordStatus announces the status a check found: a change is pushed, added to the event
+// log and notified
+func (repo *DBRepo) recordStatus(h models.Host, hs models.HostServices, newStatus, msg string) {
 	if newStatus != hs.Status {
 		repo.pushStatusChangeEvent(h, hs, newStatus)
 		// add to the event log
-		repo.addEvents(h, hs, newStatus, msg)
-
-		if repo.App.PreferenceMap["notify_via_email"] == "1" {
-			if hs.Status != "pending" {
-				mm := channeldata.MailData{
-					ToName:    repo.App.PreferenceMap["notify_name"],
-					ToAddress: repo.App.PreferenceMap["notify_email"],
-				}
-				if newStatus == "healthy" {
-					mm.Subject = fmt.Sprintf("HEALTHY : service %s on host %s", hs.Service.ServiceName, h.HostName)
-					mm.Content = template.HTML(fmt.Sprintf("Service %s on host %s is now <strong>HEALTHY</strong>",
-						hs.Service.ServiceName, h.HostName))
-				} else if newStatus == "problem" {
-					mm.Subject = fmt.Sprintf("PROBLEM : service %s on host %s", hs.Service.ServiceName, h.HostName)
-					mm.Content = template.HTML(fmt.Sprintf("Service %s on host %s is now <strong>PROBLEM</strong>",
-						hs.Service.ServiceName, h.HostName))
-				} else if newStatus == "warning" {
-					mm.Subject = fmt.Sprintf("WARNING : service %s on host %s", hs.Service.ServiceName, h.HostName)
-					mm.Content = template.HTML(fmt.Sprintf("Service %s on host %s is now <strong>WARNING</strong>",
-						hs.Service.ServiceName, h.HostName))
-				}
-
-				helpers.SendEmail(mm)
-			}
-		}
+		eventID := repo.addEvents(h, hs, newStatus, msg)
 
-		if repo.App.PreferenceMap["notify_via_sms"] == "1" {
-			if hs.Status != "pending" {
-				to := repo.App.PreferenceMap["sms_notify_number"]
-				msg := ""
-
-				if newStatus == "healthy" {
-					msg = fmt.Sprintf("Service %s on host %s is now HEALTHY",
-						hs.Service.ServiceName, h.HostName)
-				} else if newStatus == "problem" {
-					msg = fmt.Sprintf("Service %s on host %s is now PROBLEM",
-						hs.Service.ServiceName, h.HostName)
-				} else if newStatus == "warning" {
-					msg = fmt.Sprintf("Service %s on host %s is now WARNING",
-						hs.Service.ServiceName, h.HostName)
-				}
-
-				err := sms.SendTextTwilio(to, msg, repo.App)
-				if err != nil {
-					log.Println(err)
-				}
-			}
-		}
+		repo.notifyStatusChange(h, hs, newStatus, msg, eventID)
 	}
 
 	repo.pushScheduleChangeEvent(hs, newStatus)
-
-	return newStatus, msg
 }
 
-func (repo *DBRepo) addEvents(h models.Host, hs models.HostServices, newStatus string, msg string) {
-	err := repo.DB.InsertEvent(models.Event{
+// addEvents adds a status change to the event log and returns the id of the new event
+func (repo *DBRepo) addEvents(h models.Host, hs models.HostServices, newStatus string, msg string) int {
+	eventID, err := repo.DB.InsertEvent(models.Event{
 		EventType:     newStatus,
 		HostServiceID: hs.ID,
 		HostID:        hs.HostID,
@@ -259,6 +273,7 @@ func (repo *DBRepo) addEvents(h models.Host, hs models.HostServices, newStatus s
 	if err != nil {
 		log.Println(err)
 	}
+	return eventID
 }
 
 func (repo *DBRepo) pushStatusChangeEvent(h models.Host, hs models.HostServices, newStatus string) {
@@ -283,8 +298,8 @@ func (repo *DBRepo) pushScheduleChangeEvent(hs models.HostServices, newStatus st
 	data["host_service_id"] = strconv.Itoa(hs.ID)
 	data["service_id"] = strconv.Itoa(hs.ServiceID)
 	data["host_id"] = strconv.Itoa(hs.HostID)
-	if app.Scheduler.Entry(repo.App.MonitorMap[hs.ID]).Next.After(yearOne) {
-		data["next_run"] = app.Scheduler.Entry(repo.App.MonitorMap[hs.ID]).Next.Format("2006-01-02 15:04:05")
+	if entry, ok := repo.App.Scheduler.Entry(hs.ID); ok && entry.Next.After(yearOne) {
+		data["next_run"] = entry.Next.Format("2006-01-02 15:04:05")
 	} else {
 		data["next_run"] = "Pending..."
 	}
@@ -298,7 +313,7 @@ func (repo *DBRepo) pushScheduleChangeEvent(hs models.HostServices, newStatus st
 	data["last_run"] = locationNow.Format("2006-01-02 15:04:05")
 	data["host"] = hs.HostName
 	data["service"] = hs.Service.ServiceName
-	data["schedule"] = fmt.Sprintf("@every %d%s", hs.SchedulerNumber, hs.SchedulerUnit)
+	data["schedule"] = scheduling.Describe(hs)
 	data["status"] = newStatus
 	data["icon"] = hs.Service.Icon
 
@@ -306,46 +321,11 @@ func (repo *DBRepo) pushScheduleChangeEvent(hs models.HostServices, newStatus st
 
 }
 
-func (repo *DBRepo) testHTTP(url string, h models.Host, hs models.HostServices) (string, string) {
-	if strings.HasSuffix(url, "/") {
-		url = strings.TrimSuffix(url, "/")
-	}
-
-	url = strings.Replace(url, "https://", "http://", -1)
-
-	resp, err := http.Get(url)
-	if err != nil {
-		cpTime, _ := repo.addElastic(url, h, hs)
-		data := make(map[string]interface{})
-		data["service_info"] = cpTime
-		_ = repo.broadcastMessageJsonObject("public-channel", "host-service-check-response", data)
-		return err.Error(), "problem"
-	}
-
-	cpTime, _ := repo.addElastic(url, h, hs)
-	data := make(map[string]interface{})
-	data["service_info"] = cpTime
-
-	_ = repo.broadcastMessageJsonObject("public-channel", "host-service-check-response", data)
-
-	defer func(resp *http.Response) {
-		err := resp.Body.Close()
-		if err != nil {
-			log.Println(err)
-		}
-	}(resp)
-
-	if resp.StatusCode != http.StatusOK {
-		return resp.Status, "problem"
-	} else {
-		return resp.Status, "healthy"
-	}
-}
-
-func (repo *DBRepo) addElastic(url string, h models.Host, hs models.HostServices) (*models.ComputeTimes, error) {
-	computeTimes := helpers.ComputeTime(url)
-
+// addComputeTimes stores the timings of a check made from location in Elastic, and pushes
+// them to clients
+func (repo *DBRepo) addComputeTimes(computeTimes *models.ComputeTimes, location string, h models.Host, hs models.HostServices) {
 	computeTimes.ID = uuid.New().String()
+	computeTimes.Location = location
 	computeTimes.Host = h
 	computeTimes.HostServices = hs
 	computeTimes.CreatedAt = time.Now()
@@ -353,131 +333,65 @@ func (repo *DBRepo) addElastic(url string, h models.Host, hs models.HostServices
 
 	err := repo.ElasticClient.AddDocument("performances", computeTimes.ID, *computeTimes)
 	if err != nil {
-		return nil, err
-	}
-	return computeTimes, err
-}
-
-// testHTTPS tests an url with https
-func (repo *DBRepo) testHTTPS(url string, h models.Host, hs models.HostServices) (string, string) {
-	if strings.HasSuffix(url, "/") {
-		url = strings.TrimSuffix(url, "/")
-	}
-
-	url = strings.Replace(url, "http://", "https://", -1)
-
-	resp, err := http.Get(url)
-	if err != nil {
-		_, _ = repo.addElastic(url, h, hs)
-		return err.Error(), "problem"
+		log.Println(err)
 	}
 
-	cpTime, _ := repo.addElastic(url, h, hs)
 	data := make(map[string]interface{})
-	data["service_info"] = cpTime
+	data["service_info"] = computeTimes
 
 	_ = repo.broadcastMessageJsonObject("public-channel", "host-service-check-response", data)
+}
 
-	defer func(resp *http.Response) {
-		err := resp.Body.Close()
+// addToSchedule schedules the checks of a host service, when this process schedules checks
+func (repo *DBRepo) addToSchedule(hs models.HostServices) {
+	if repo.schedulesChecks() {
+		entry, err := repo.scheduleHostService(hs)
 		if err != nil {
 			log.Println(err)
+			return
 		}
-	}(resp)
 
-	if resp.StatusCode != http.StatusOK {
-		return resp.Status, "problem"
-	} else {
-		return resp.Status, "healthy"
+		repo.broadcastScheduleChanged(hs, entry)
 	}
 }
 
-func (repo *DBRepo) testSSLCert(url string, h models.Host, hs models.HostServices) (string, string) {
-	if strings.HasPrefix(url, "https://") {
-		url = strings.Replace(url, "https://", "", -1)
+// rescheduleCheck moves the checks of a scheduled host service to its current schedule
+func (repo *DBRepo) rescheduleCheck(hs models.HostServices) {
+	schedule, err := repo.hostServiceSchedule(hs)
+	if err != nil {
+		log.Println(err)
+		return
 	}
 
-	if strings.HasPrefix(url, "http://") {
-		url = strings.Replace(url, "http://", "", -1)
+	_, ok := repo.App.Scheduler.Reschedule(hs.ID, scheduling.Key(hs), schedule, job{HostServiceID: hs.ID, HostID: hs.HostID})
+	if ok {
+		entry, _ := repo.App.Scheduler.Entry(hs.ID)
+		repo.broadcastScheduleChanged(hs, entry)
 	}
-
-	// scanning ssl cert for expiry date
-	var certDetailsChannel chan certificateutils.CertificateDetails
-	var errorsChannel chan error
-	certDetailsChannel = make(chan certificateutils.CertificateDetails, 1)
-	errorsChannel = make(chan error, 1)
-
-	var messages, newStatus string
-
-	scanHost(url, certDetailsChannel, errorsChannel)
-
-	for i, certDetailsInQueue := 0, len(certDetailsChannel); i < certDetailsInQueue; i++ {
-		certDetails := <-certDetailsChannel
-		certificateutils.CheckExpirationStatus(&certDetails, 30)
-
-		if certDetails.ExpiringSoon {
-
-			if certDetails.DaysUntilExpiration < 7 {
-				messages = certDetails.Hostname + " expiring in " + strconv.Itoa(certDetails.DaysUntilExpiration) + " days"
-				newStatus = "problem"
-			} else {
-				messages = certDetails.Hostname + " expiring in " + strconv.Itoa(certDetails.DaysUntilExpiration) + " days"
-				newStatus = "warning"
-			}
-		} else {
-			messages = certDetails.Hostname + " expiring in " + strconv.Itoa(certDetails.DaysUntilExpiration) + " days"
-			newStatus = "healthy"
-		}
-	}
-
-	return messages, newStatus
-
 }
 
-// scanHost gets cert details from an internet host
-func scanHost(hostname string, certDetailsChannel chan certificateutils.CertificateDetails, errorsChannel chan error) {
-
-	res, err := certificateutils.GetCertificateDetails(hostname, 10)
-	if err != nil {
-		errorsChannel <- err
-	} else {
-		certDetailsChannel <- res
-	}
-}
+// broadcastScheduleChanged tells clients about the new scheduler entry of a host service
+func (repo *DBRepo) broadcastScheduleChanged(hs models.HostServices, entry cron.Entry) {
+	data := make(map[string]string)
+	data["host_service_id"] = strconv.Itoa(hs.ID)
+	data["service_id"] = strconv.Itoa(hs.ServiceID)
+	data["host_id"] = strconv.Itoa(hs.HostID)
+	data["next_run"] = entry.Next.Format("2006-01-02 15:04:05")
+	data["last_run"] = time.Now().Format("2006-01-02 15:04:05")
+	data["host"] = hs.HostName
+	data["service"] = hs.Service.ServiceName
+	data["schedule"] = scheduling.Describe(hs)
+	data["status"] = hs.Status
+	data["icon"] = hs.Service.Icon
+	data["message"] = fmt.Sprintf("%s is %s", hs.Service.ServiceName, hs.Status)
 
-func (repo *DBRepo) addToMonitorMap(hs models.HostServices) {
-	if repo.App.PreferenceMap["monitoring_live"] == "1" {
-		var j job
-		j.HostServiceID = hs.ID
-		scheduleID, err := repo.App.Scheduler.AddJob(fmt.Sprintf("@every %d%s", hs.SchedulerNumber, hs.SchedulerUnit), j)
-		if err != nil {
-			log.Println(err)
-			return
-		}
-		repo.App.MonitorMap[hs.ID] = scheduleID
-		data := make(map[string]string)
-		data["host_service_id"] = strconv.Itoa(hs.ID)
-		data["service_id"] = strconv.Itoa(hs.ServiceID)
-		data["host_id"] = strconv.Itoa(hs.HostID)
-		data["next_run"] = repo.App.Scheduler.Entry(scheduleID).Next.Format("2006-01-02 15:04:05")
-		data["last_run"] = time.Now().Format("2006-01-02 15:04:05")
-		data["host"] = hs.HostName
-		data["service"] = hs.Service.ServiceName
-		data["schedule"] = fmt.Sprintf("@every %d%s", hs.SchedulerNumber, hs.SchedulerUnit)
-		data["status"] = hs.Status
-		data["icon"] = hs.Service.Icon
-		data["message"] = fmt.Sprintf("%s is %s", hs.Service.ServiceName, hs.Status)
-
-		_ = repo.broadcastMessage("public-channel", "schedule-changed-event", data)
-	}
+	_ = repo.broadcastMessage("public-channel", "schedule-changed-event", data)
 }
 
-func (repo *DBRepo) removeFromMonitorMap(hs models.HostServices) {
-	if repo.App.PreferenceMap["monitoring_live"] == "1" {
-		if scheduleID, ok := repo.App.MonitorMap[hs.ID]; ok {
-			repo.App.Scheduler.Remove(scheduleID)
-			delete(repo.App.MonitorMap, hs.ID)
-
+// removeFromSchedule stops the scheduled checks of a host service
+func (repo *DBRepo) removeFromSchedule(hs models.HostServices) {
+	if repo.App.Preferences.Get("monitoring_live") == "1" {
+		if repo.App.Scheduler.Remove(hs.ID) {
 			data := make(map[string]string)
 			data["host_service_id"] = strconv.Itoa(hs.ID)
 			err := repo.broadcastMessage("public-channel", "schedule-item-removed-event", data)
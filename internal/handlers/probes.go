@@ -0,0 +1,486 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/go-chi/chi/v5"
+	"golang-observer-project/internal/helpers"
+	"golang-observer-project/internal/models"
+	"golang-observer-project/internal/scheduling"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// probeResultMinAge is the shortest time a probe result counts towards the quorum; otherwise
+// a result counts for three check intervals
+const probeResultMinAge = 5 * time.Minute
+
+// quorumMu keeps two results arriving at once from both announcing the same status change
+var quorumMu sync.Mutex
+
+type probeContextKey struct{}
+
+// WithProbe returns a context carrying the authenticated probe
+func WithProbe(ctx context.Context, p models.Probe) context.Context {
+	return context.WithValue(ctx, probeContextKey{}, p)
+}
+
+// probeFromContext returns the probe authenticated for a request
+func probeFromContext(ctx context.Context) (models.Probe, bool) {
+	p, ok := ctx.Value(probeContextKey{}).(models.Probe)
+	return p, ok
+}
+
+// hashProbeToken hashes a probe token, which is only stored hashed
+func hashProbeToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// AuthenticateProbe returns the active probe with the token
+func (repo *DBRepo) AuthenticateProbe(token string) (models.Probe, error) {
+	return repo.DB.AuthenticateProbe(hashProbeToken(token))
+}
+
+// RegisterProbe registers a probe agent with the probe_registration_key preference, and gives
+// it a new token for the probe API
+func (repo *DBRepo) RegisterProbe(w http.ResponseWriter, r *http.Request) {
+	var req models.ProbeRegisterRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		log.Println(err)
+		ClientError(w, r, http.StatusBadRequest)
+		return
+	}
+
+	key := repo.App.Preferences.Get("probe_registration_key")
+	if key == "" || subtle.ConstantTimeCompare([]byte(key), []byte(req.Key)) != 1 {
+		ClientError(w, r, http.StatusForbidden)
+		return
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		ClientError(w, r, http.StatusBadRequest)
+		return
+	}
+
+	b := make([]byte, 32)
+	_, err = rand.Read(b)
+	if err != nil {
+		log.Println(err)
+		ClientError(w, r, http.StatusInternalServerError)
+		return
+	}
+	token := hex.EncodeToString(b)
+
+	p, err := repo.DB.RegisterProbe(req.Name, strings.TrimSpace(req.Location), hashProbeToken(token))
+	if err != nil {
+		ClientError(w, r, http.StatusBadRequest)
+		return
+	}
+
+	if p.Active != 1 {
+		ClientError(w, r, http.StatusForbidden)
+		return
+	}
+
+	log.Printf("Probe %s registered from %s\n", p.Name, p.Location)
+
+	var response models.ProbeRegisterResponse
+	response.OK = true
+	response.Message = "Probe registered"
+	response.ProbeID = p.ID
+	response.Token = token
+
+	helpers.RenderJSON(w, response)
+}
+
+// ProbeAssignments lists the host services the authenticated probe checks
+func (repo *DBRepo) ProbeAssignments(w http.ResponseWriter, r *http.Request) {
+	p, ok := probeFromContext(r.Context())
+	if !ok {
+		ClientError(w, r, http.StatusUnauthorized)
+		return
+	}
+
+	assignments, err := repo.DB.ProbeAssignments(p.ID)
+	if err != nil {
+		ClientError(w, r, http.StatusBadRequest)
+		return
+	}
+
+	// the checks run on the probe with their settings
+	for i, a := range assignments {
+		settings, err := repo.checkSettings(a.HostService)
+		if err != nil {
+			ClientError(w, r, http.StatusInternalServerError)
+			return
+		}
+		assignments[i].Settings = settings
+	}
+
+	var response models.ProbeAssignmentsResponse
+	response.OK = true
+	response.Message = "Assignments retrieved"
+	response.Assignments = assignments
+
+	helpers.RenderJSON(w, response)
+}
+
+// PostProbeResults records the results of the authenticated probe, stores their timings
+// under its location and updates the status of the host services by quorum
+func (repo *DBRepo) PostProbeResults(w http.ResponseWriter, r *http.Request) {
+	p, ok := probeFromContext(r.Context())
+	if !ok {
+		ClientError(w, r, http.StatusUnauthorized)
+		return
+	}
+
+	var req models.ProbeResultsRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		log.Println(err)
+		ClientError(w, r, http.StatusBadRequest)
+		return
+	}
+
+	location := p.Location
+	if location == "" {
+		location = p.Name
+	}
+
+	var resp jsonResp
+	resp.OK = true
+	resp.Message = "Results recorded"
+
+	for _, result := range req.Results {
+		switch result.Status {
+		case "healthy", "warning", "problem", "pending":
+		default:
+			resp.OK = false
+			resp.Message = fmt.Sprintf("invalid status %q for host service %d", result.Status, result.HostServiceID)
+			continue
+		}
+
+		if !repo.probeChecks(p.ID, result.HostServiceID) {
+			resp.OK = false
+			resp.Message = fmt.Sprintf("host service %d is not assigned to this probe", result.HostServiceID)
+			continue
+		}
+
+		err = repo.DB.UpsertProbeResult(models.ProbeResult{
+			HostServiceID: result.HostServiceID,
+			ProbeID:       p.ID,
+			Status:        result.Status,
+			Message:       result.Message,
+			CheckedAt:     time.Now(),
+		})
+		if err != nil {
+			ClientError(w, r, http.StatusBadRequest)
+			return
+		}
+
+		if result.ComputeTimes != nil || len(result.Perfdata) > 0 || len(result.Steps) > 0 {
+			hs, err := repo.DB.GetHostServiceByID(result.HostServiceID)
+			if err == nil {
+				h, err := repo.DB.FindHostByID(hs.HostID)
+				if err == nil && result.ComputeTimes != nil {
+					repo.addComputeTimes(result.ComputeTimes, location, h, hs)
+				}
+				if err == nil && len(result.Perfdata) > 0 {
+					repo.addPerfdata(result.Perfdata, location, h, hs)
+				}
+				if err == nil && len(result.Steps) > 0 {
+					repo.addStepTimes(result.Steps, location, h, hs)
+				}
+			}
+		}
+
+		repo.applyQuorum(result.HostServiceID)
+	}
+
+	helpers.RenderJSON(w, resp)
+}
+
+// probeChecks reports whether a probe is assigned to a host service
+func (repo *DBRepo) probeChecks(probeID, hostServiceID int) bool {
+	probes, err := repo.DB.ProbesForHostService(hostServiceID)
+	if err != nil {
+		return false
+	}
+
+	for _, p := range probes {
+		if p.ID == probeID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// probeQuorum is how many of n probes must agree on a status: probe_quorum when it is
+// between 1 and n, or a majority
+func (repo *DBRepo) probeQuorum(n int) int {
+	quorum, err := strconv.Atoi(repo.App.Preferences.Get("probe_quorum"))
+	if err != nil || quorum < 1 || quorum > n {
+		quorum = n/2 + 1
+	}
+
+	return quorum
+}
+
+// probeResultMaxAge is how long a probe result for a host service counts: three of its check
+// intervals, at least probeResultMinAge
+func (repo *DBRepo) probeResultMaxAge(hs models.HostServices) time.Duration {
+	maxAge := probeResultMinAge
+
+	schedule, err := scheduling.New(hs, repo.App.Scheduler.Location())
+	if err != nil {
+		return maxAge
+	}
+
+	runs := scheduling.NextRuns(schedule, time.Now(), 2)
+	if len(runs) == 2 && 3*runs[1].Sub(runs[0]) > maxAge {
+		maxAge = 3 * runs[1].Sub(runs[0])
+	}
+
+	return maxAge
+}
+
+// applyQuorum sets the status of a host service checked by probes from their recent
+// results, and announces a change like any other check
+func (repo *DBRepo) applyQuorum(hostServiceID int) {
+	quorumMu.Lock()
+	defer quorumMu.Unlock()
+
+	hs, err := repo.DB.GetHostServiceByID(hostServiceID)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	h, err := repo.DB.FindHostByID(hs.HostID)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	probes, err := repo.DB.ProbesForHostService(hs.ID)
+	if err != nil || len(probes) == 0 {
+		return
+	}
+
+	results, err := repo.DB.ProbeResultsForHostService(hs.ID)
+	if err != nil {
+		return
+	}
+
+	// pending results, such as UNKNOWN from a plugin, do not count either
+	var fresh []models.ProbeResult
+	since := time.Now().Add(-repo.probeResultMaxAge(hs))
+	for _, result := range results {
+		if result.CheckedAt.After(since) && result.Status != "pending" {
+			fresh = append(fresh, result)
+		}
+	}
+
+	newStatus, msg, ok := quorumStatus(fresh, repo.probeQuorum(len(probes)))
+	if !ok {
+		return
+	}
+
+	repo.recordStatus(h, hs, newStatus, msg)
+
+	if newStatus != hs.Status {
+		repo.updateHostServiceStatusCount(h, hs, newStatus, msg)
+	}
+}
+
+// quorumStatus decides a status from the results of several locations: problem when at least
+// quorum of them report a problem, warning when at least quorum report a problem or warning,
+// and healthy otherwise. ok is false when fewer than quorum locations reported.
+func quorumStatus(results []models.ProbeResult, quorum int) (status, msg string, ok bool) {
+	if len(results) < quorum || len(results) == 0 {
+		return "", "", false
+	}
+
+	var problems, warnings []string
+	for _, r := range results {
+		location := r.Location
+		if location == "" {
+			location = r.ProbeName
+		}
+
+		switch r.Status {
+		case "problem":
+			problems = append(problems, fmt.Sprintf("%s (%s)", location, r.Message))
+		case "warning":
+			warnings = append(warnings, fmt.Sprintf("%s (%s)", location, r.Message))
+		}
+	}
+
+	switch {
+	case len(problems) >= quorum:
+		return "problem", fmt.Sprintf("%d of %d locations report a problem: %s",
+			len(problems), len(results), strings.Join(problems, ", ")), true
+	case len(problems)+len(warnings) >= quorum:
+		return "warning", fmt.Sprintf("%d of %d locations report a problem or warning: %s",
+			len(problems)+len(warnings), len(results), strings.Join(append(problems, warnings...), ", ")), true
+	}
+
+	return "healthy", fmt.Sprintf("%d of %d locations are healthy",
+		len(results)-len(problems)-len(warnings), len(results)), true
+}
+
+// AllProbes lists all probes
+func (repo *DBRepo) AllProbes(w http.ResponseWriter, r *http.Request) {
+	probes, err := repo.DB.AllProbes()
+	if err != nil {
+		ClientError(w, r, http.StatusBadRequest)
+		return
+	}
+
+	var response models.ProbesJsonResponse
+	response.OK = true
+	response.Message = "Probes retrieved"
+	response.Probes = probes
+
+	helpers.RenderJSON(w, response)
+}
+
+// PostProbe switches a probe on or off
+func (repo *DBRepo) PostProbe(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		ClientError(w, r, http.StatusBadRequest)
+		return
+	}
+
+	var req models.ProbePostRequest
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		log.Println(err)
+		ClientError(w, r, http.StatusBadRequest)
+		return
+	}
+
+	err = repo.DB.UpdateProbeActive(id, req.Active)
+	if err != nil {
+		ClientError(w, r, http.StatusBadRequest)
+		return
+	}
+
+	var jsonResp jsonResp
+	jsonResp.OK = true
+	jsonResp.Message = "Probe updated"
+
+	helpers.RenderJSON(w, jsonResp)
+}
+
+// DeleteProbe deletes a probe
+func (repo *DBRepo) DeleteProbe(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.Atoi(chi.URLParam(r, "id"))
+	err := repo.DB.DeleteProbe(id)
+	if err != nil {
+		ClientError(w, r, http.StatusBadRequest)
+		return
+	}
+
+	var jsonResp jsonResp
+	jsonResp.OK = true
+	jsonResp.Message = "Probe deleted"
+
+	helpers.RenderJSON(w, jsonResp)
+}
+
+// HostServiceProbes shows the probes checking a host service, their latest results and the
+// quorum
+func (repo *DBRepo) HostServiceProbes(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		ClientError(w, r, http.StatusBadRequest)
+		return
+	}
+
+	probes, err := repo.DB.ProbesForHostService(id)
+	if err != nil {
+		ClientError(w, r, http.StatusBadRequest)
+		return
+	}
+
+	results, err := repo.DB.ProbeResultsForHostService(id)
+	if err != nil {
+		ClientError(w, r, http.StatusBadRequest)
+		return
+	}
+
+	var response models.HostServiceProbesResponse
+	response.OK = true
+	response.Message = "Probes retrieved"
+	response.Probes = probes
+	response.Results = results
+	if len(probes) > 0 {
+		response.Quorum = repo.probeQuorum(len(probes))
+	}
+
+	helpers.RenderJSON(w, response)
+}
+
+// PostHostServiceProbes sets the probes that check a host service; without probes it is
+// checked by the observer itself again
+func (repo *DBRepo) PostHostServiceProbes(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		ClientError(w, r, http.StatusBadRequest)
+		return
+	}
+
+	var req models.HostServiceProbesRequest
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		log.Println(err)
+		ClientError(w, r, http.StatusBadRequest)
+		return
+	}
+
+	hs, err := repo.DB.GetHostServiceByID(id)
+	if err != nil {
+		ClientError(w, r, http.StatusNotFound)
+		return
+	}
+
+	var jsonResp jsonResp
+
+	if hs.ServiceID == Heartbeat && len(req.ProbeIDs) > 0 {
+		jsonResp.Message = "Heartbeats report in themselves and are not checked by probes"
+		helpers.RenderJSON(w, jsonResp)
+		return
+	}
+
+	if hs.ServiceID == System && len(req.ProbeIDs) > 0 {
+		jsonResp.Message = "Systems are reported by their host agent and are not checked by probes"
+		helpers.RenderJSON(w, jsonResp)
+		return
+	}
+
+	err = repo.DB.SetHostServiceProbes(id, req.ProbeIDs)
+	if err != nil {
+		ClientError(w, r, http.StatusBadRequest)
+		return
+	}
+
+	jsonResp.OK = true
+	jsonResp.Message = "Probes saved"
+
+	helpers.RenderJSON(w, jsonResp)
+}
@@ -0,0 +1,232 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"github.com/robfig/cron/v3"
+	"golang-observer-project/internal/certificateutils"
+	"golang-observer-project/internal/channeldata"
+	"golang-observer-project/internal/helpers"
+	"golang-observer-project/internal/mailtemplates"
+	"golang-observer-project/internal/reports"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// slowestServicesInDigest is how many of the slowest services a digest lists
+const slowestServicesInDigest = 10
+
+var (
+	digestMu      sync.Mutex
+	digestEntryID cron.EntryID
+)
+
+type digestJob struct{}
+
+func (j digestJob) Run() {
+	if !Repo.isLeader() {
+		return
+	}
+
+	err := Repo.SendDigest()
+	if err != nil {
+		log.Println("cannot send digest:", err)
+	}
+}
+
+// ScheduleDigest (re)registers the digest job on the report scheduler from the digest_enabled
+// and digest_schedule preferences
+func (repo *DBRepo) ScheduleDigest() {
+	digestMu.Lock()
+	defer digestMu.Unlock()
+
+	if digestEntryID != 0 {
+		repo.App.ReportScheduler.Remove(digestEntryID)
+		digestEntryID = 0
+	}
+
+	if repo.App.Preferences.Get("digest_enabled") != "1" {
+		return
+	}
+
+	spec := repo.App.Preferences.Get("digest_schedule")
+	if spec == "" {
+		// 08:00 every day, or every Monday for weekly digests
+		spec = "0 8 * * *"
+		if repo.App.Preferences.Get("digest_period") == "weekly" {
+			spec = "0 8 * * 1"
+		}
+	}
+
+	entryID, err := repo.App.ReportScheduler.AddJob(spec, digestJob{})
+	if err != nil {
+		log.Printf("invalid digest schedule %q: %s\n", spec, err)
+		return
+	}
+
+	digestEntryID = entryID
+	log.Printf("Digest scheduled with %q, next run at %s\n", spec, repo.App.ReportScheduler.Entry(entryID).Next)
+}
+
+// BuildDigest gathers the digest report for the period ending at to
+func (repo *DBRepo) BuildDigest(period string, to time.Time) (reports.Digest, error) {
+	if period != "weekly" {
+		period = "daily"
+	}
+
+	from := to.Add(-reports.PeriodLength(period))
+	digest := reports.Digest{Period: period, From: from, To: to}
+
+	hosts, err := repo.DB.AllHosts()
+	if err != nil {
+		return digest, err
+	}
+
+	initial, err := repo.DB.LatestStatusEventsBefore(from)
+	if err != nil {
+		return digest, err
+	}
+
+	events, err := repo.DB.StatusEventsBetween(from, to)
+	if err != nil {
+		return digest, err
+	}
+
+	digest.Hosts = reports.Uptime(hosts, initial, events, from, to)
+	for _, hu := range digest.Hosts {
+		digest.Incidents += hu.Incidents
+		digest.Downtime += hu.Downtime
+	}
+
+	// a digest without response times is still worth sending
+	digest.SlowestServices, err = repo.ElasticClient.SlowestServices("performances", from, slowestServicesInDigest)
+	if err != nil {
+		log.Println(err)
+	}
+
+	for _, h := range hosts {
+		for _, hs := range h.HostServices {
+			if hs.ServiceID != SSLCertificate || hs.Active != 1 || h.Active != 1 {
+				continue
+			}
+
+			hostname := strings.TrimPrefix(strings.TrimPrefix(h.URL, "https://"), "http://")
+			certDetails, err := certificateutils.GetCertificateDetails(hostname, 10)
+			if err != nil {
+				log.Println(err)
+				continue
+			}
+
+			certificateutils.CheckExpirationStatus(&certDetails, 30)
+			if certDetails.ExpiringSoon || certDetails.Expired {
+				digest.ExpiringCertificates = append(digest.ExpiringCertificates, reports.CertificateExpiry{
+					HostName:            h.HostName,
+					ExpirationDate:      certDetails.ExpirationDate,
+					DaysUntilExpiration: certDetails.DaysUntilExpiration,
+				})
+			}
+		}
+	}
+
+	return digest, nil
+}
+
+// SendDigest builds the digest and mails it to the digest recipients
+func (repo *DBRepo) SendDigest() error {
+	recipients := repo.App.Preferences.Get("digest_recipients")
+	if recipients == "" {
+		recipients = repo.App.Preferences.Get("notify_email")
+	}
+
+	var to []string
+	for _, r := range strings.Split(recipients, ",") {
+		if r = strings.TrimSpace(r); r != "" {
+			to = append(to, r)
+		}
+	}
+
+	if len(to) == 0 {
+		return errors.New("no digest recipients configured")
+	}
+
+	digest, err := repo.BuildDigest(repo.App.Preferences.Get("digest_period"), time.Now())
+	if err != nil {
+		return err
+	}
+
+	et, err := repo.emailTemplate(mailtemplates.Digest)
+	if err != nil {
+		return err
+	}
+
+	subject, content, err := mailtemplates.Render(et, mailtemplates.Context{
+		PreferenceMap: repo.App.Preferences.Map(),
+		Link:          repo.hostLink(0),
+		Data: map[string]interface{}{
+			"Period": digest.Period,
+			"From":   digest.From,
+			"To":     digest.To,
+			"Report": digest,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	err = helpers.SendEmail(channeldata.MailData{
+		ToAddress:    to[0],
+		AdditionalTo: to[1:],
+		Subject:      subject,
+		Content:      content,
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Digest for %s - %s sent to %s\n", digest.From.Format("2006-01-02 15:04"),
+		digest.To.Format("2006-01-02 15:04"), strings.Join(to, ", "))
+
+	return nil
+}
+
+type digestResponse struct {
+	OK      bool           `json:"ok"`
+	Message string         `json:"message"`
+	Digest  reports.Digest `json:"digest"`
+}
+
+// Digest shows the digest report, for ?period=daily (default) or weekly
+func (repo *DBRepo) Digest(w http.ResponseWriter, r *http.Request) {
+	var response digestResponse
+	response.OK = true
+	response.Message = "Digest built"
+
+	digest, err := repo.BuildDigest(r.URL.Query().Get("period"), time.Now())
+	if err != nil {
+		log.Println(err)
+		response.OK = false
+		response.Message = err.Error()
+	}
+
+	response.Digest = digest
+
+	helpers.RenderJSON(w, response)
+}
+
+// SendDigestNow mails the digest right away
+func (repo *DBRepo) SendDigestNow(w http.ResponseWriter, r *http.Request) {
+	var jsonResp jsonResp
+	jsonResp.OK = true
+	jsonResp.Message = "Digest sent"
+
+	err := repo.SendDigest()
+	if err != nil {
+		jsonResp.OK = false
+		jsonResp.Message = fmt.Sprintf("Cannot send digest: %s", err)
+	}
+
+	helpers.RenderJSON(w, jsonResp)
+}
@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"github.com/robfig/cron/v3"
+	"golang-observer-project/internal/models"
+	"golang-observer-project/internal/scheduling"
+	"log"
+	"time"
+)
+
+// reconcileInterval is how often the scheduler is compared with the database
+const reconcileInterval = time.Minute
+
+// reconcileJob heals drift between the database and the scheduler
+type reconcileJob struct{}
+
+func (j reconcileJob) Run() {
+	Repo.ReloadPreferences()
+	Repo.ReconcileSchedules()
+}
+
+// StartReconciliation runs ReloadPreferences and ReconcileSchedules periodically on the
+// report scheduler
+func (repo *DBRepo) StartReconciliation() {
+	repo.App.ReportScheduler.Schedule(cron.Every(reconcileInterval), reconcileJob{})
+}
+
+// ReloadPreferences reads the preferences from the database again, since they may have been
+// changed through another instance sharing it, and applies the ones that changed: the
+// scheduler is started or stopped, and the digest and check limits are set again
+func (repo *DBRepo) ReloadPreferences() {
+	changed, err := repo.reloadPreferences()
+	if err != nil {
+		log.Println("cannot reload preferences:", err)
+		return
+	}
+
+	if len(changed) == 0 {
+		return
+	}
+
+	log.Printf("%d preferences changed by another instance\n", len(changed))
+
+	if _, ok := changed["monitoring_live"]; ok {
+		repo.applyMonitoringLive()
+	}
+
+	repo.ScheduleDigest()
+	repo.ApplyCheckLimits()
+}
+
+// reloadPreferences reads the preferences from the database into the site preferences, and
+// returns the ones that changed
+func (repo *DBRepo) reloadPreferences() (map[string]string, error) {
+	preferences, err := repo.DB.AllPreferences()
+	if err != nil {
+		return nil, err
+	}
+
+	current := repo.App.Preferences.Map()
+	changed := make(map[string]string)
+	for _, pref := range preferences {
+		if current[pref.Name] != pref.Preference {
+			changed[pref.Name] = pref.Preference
+		}
+	}
+
+	repo.App.Preferences.SetAll(changed)
+
+	return changed, nil
+}
+
+// syncSchedule brings the scheduler entry of a host service in line with whether it should
+// be checked: it is added, rescheduled when its schedule changed, or removed
+func (repo *DBRepo) syncSchedule(hs models.HostServices, monitored bool) {
+	if !repo.schedulesChecks() {
+		return
+	}
+
+	if !monitored {
+		repo.removeFromSchedule(hs)
+		return
+	}
+
+	key, ok := repo.App.Scheduler.Key(hs.ID)
+	switch {
+	case !ok:
+		repo.addToSchedule(hs)
+	case key != scheduling.Key(hs):
+		repo.rescheduleCheck(hs)
+	}
+}
+
+// syncHostSchedule syncs the scheduler entries of every service of a host
+func (repo *DBRepo) syncHostSchedule(hostID int) {
+	h, err := repo.DB.FindHostByID(hostID)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	for _, hs := range h.HostServices {
+		hs.HostName = h.HostName
+		repo.syncSchedule(hs, h.Active == 1 && hs.Active == 1)
+	}
+}
+
+// ReconcileSchedules compares the host services that should be monitored with the scheduler
+// entries, and adds, reschedules or removes entries that drifted
+func (repo *DBRepo) ReconcileSchedules() {
+	if !repo.schedulesChecks() {
+		return
+	}
+
+	services, err := repo.DB.GetServicesToMonitor()
+	if err != nil {
+		log.Println("cannot reconcile schedules:", err)
+		return
+	}
+
+	wanted := make(map[int]bool, len(services))
+	var added, rescheduled, removed int
+
+	for _, hs := range services {
+		wanted[hs.ID] = true
+
+		key, ok := repo.App.Scheduler.Key(hs.ID)
+		switch {
+		case !ok:
+			added++
+		case key != scheduling.Key(hs):
+			rescheduled++
+		default:
+			continue
+		}
+
+		repo.syncSchedule(hs, true)
+	}
+
+	for _, managed := range repo.App.Scheduler.List() {
+		if !wanted[managed.HostServiceID] {
+			removed++
+			repo.removeFromSchedule(models.HostServices{ID: managed.HostServiceID})
+		}
+	}
+
+	if added+rescheduled+removed > 0 {
+		log.Printf("Schedule reconciled: %d added, %d rescheduled, %d removed\n", added, rescheduled, removed)
+	}
+}
@@ -1,15 +1,23 @@
 package handlers
 
 import (
-	"fmt"
+	"encoding/json"
+	"github.com/go-chi/chi/v5"
 	"github.com/robfig/cron/v3"
 	"golang-observer-project/internal/helpers"
 	"golang-observer-project/internal/models"
+	"golang-observer-project/internal/scheduling"
 	"log"
 	"net/http"
 	"sort"
+	"strconv"
+	"strings"
+	"time"
 )
 
+// nextRunsShown is how many upcoming runs ListEntries shows per entry
+const nextRunsShown = 5
+
 type ByHost []models.ScheduleResponse
 
 func (a ByHost) Len() int           { return len(a) }
@@ -21,30 +29,37 @@ func (repo *DBRepo) ListEntries(w http.ResponseWriter, r *http.Request) {
 	var response models.ListEntriesResponse
 	var list []models.ScheduleResponse
 
-	for k, v := range repo.App.MonitorMap {
+	for _, managed := range repo.App.Scheduler.List() {
 		var item models.ScheduleResponse
-		item.ID = k
-		hs, err := repo.DB.GetHostServiceByID(k)
+		item.ID = managed.HostServiceID
+		hs, err := repo.DB.GetHostServiceByID(managed.HostServiceID)
 		if err != nil {
 			printTemplateError(w, err)
-			log.Printf("error getting host service for id %d: %s\n", k, err)
+			log.Printf("error getting host service for id %d: %s\n", managed.HostServiceID, err)
 			return
 		}
-		item.ScheduleText = fmt.Sprintf("@every %d%s", hs.SchedulerNumber, hs.SchedulerUnit)
+		item.ScheduleText = scheduling.Describe(hs)
 		item.LastRunFromHS = hs.LastCheck
 		item.HostServiceID = hs.ID
 		item.Host = hs.HostName
 		item.Service = hs.Service.ServiceName
 
-		item.EntryID = v
+		item.EntryID = managed.Entry.ID
 		entry := &cron.Entry{
-			ID:       v,
-			Schedule: app.Scheduler.Entry(v).Schedule,
-			Next:     app.Scheduler.Entry(v).Next,
-			Prev:     app.Scheduler.Entry(v).Prev,
+			ID:       managed.Entry.ID,
+			Schedule: managed.Entry.Schedule,
+			Next:     managed.Entry.Next,
+			Prev:     managed.Entry.Prev,
 		}
 		item.Entry = *entry
 
+		if entry.Schedule != nil {
+			now := time.Now()
+			for _, next := range scheduling.NextRuns(entry.Schedule, now, nextRunsShown) {
+				item.NextRuns = append(item.NextRuns, scheduling.Humanize(next, now))
+			}
+		}
+
 		list = append(list, item)
 	}
 
@@ -56,3 +71,113 @@ func (repo *DBRepo) ListEntries(w http.ResponseWriter, r *http.Request) {
 
 	helpers.RenderJSON(w, response)
 }
+
+// PostHostServiceSchedule validates and saves the schedule of a host service, and reschedules
+// its checks
+func (repo *DBRepo) PostHostServiceSchedule(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		ClientError(w, r, http.StatusBadRequest)
+		return
+	}
+
+	var req models.ScheduleRequest
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		log.Println(err)
+		ClientError(w, r, http.StatusBadRequest)
+		return
+	}
+
+	hs, err := repo.DB.GetHostServiceByID(id)
+	if err != nil {
+		ClientError(w, r, http.StatusNotFound)
+		return
+	}
+
+	hs.SchedulerNumber = req.SchedulerNumber
+	hs.SchedulerUnit = req.SchedulerUnit
+	hs.CronExpression = strings.TrimSpace(req.CronExpression)
+	hs.TimeZone = strings.TrimSpace(req.TimeZone)
+	hs.ActiveHours = strings.TrimSpace(req.ActiveHours)
+
+	var response models.HostServiceScheduleResponse
+
+	err = scheduling.Validate(hs, repo.App.Scheduler.Location())
+	if err != nil {
+		response.Message = err.Error()
+		helpers.RenderJSON(w, response)
+		return
+	}
+
+	err = repo.DB.UpdateHostServiceSchedule(hs)
+	if err != nil {
+		ClientError(w, r, http.StatusBadRequest)
+		return
+	}
+
+	h, err := repo.DB.FindHostByID(hs.HostID)
+	if err != nil {
+		ClientError(w, r, http.StatusBadRequest)
+		return
+	}
+
+	repo.syncSchedule(hs, hs.Active == 1 && h.Active == 1)
+
+	schedule, _ := scheduling.New(hs, repo.App.Scheduler.Location())
+	if entry, ok := repo.App.Scheduler.Entry(hs.ID); ok {
+		schedule = entry.Schedule
+	}
+	now := time.Now()
+	for _, next := range scheduling.NextRuns(schedule, now, nextRunsShown) {
+		response.NextRuns = append(response.NextRuns, scheduling.Humanize(next, now))
+	}
+
+	response.OK = true
+	response.Message = "Schedule saved"
+	response.ScheduleText = scheduling.Describe(hs)
+
+	helpers.RenderJSON(w, response)
+}
+
+type schedulerMetricsResponse struct {
+	OK              bool                      `json:"ok"`
+	Message         string                    `json:"message"`
+	Entries         int                       `json:"entries"`
+	DueWithinMinute int                       `json:"due_within_minute"`
+	NextRun         string                    `json:"next_run"`
+	Checks          scheduling.LimiterMetrics `json:"checks"`
+}
+
+// SchedulerMetrics shows how busy the scheduler is: entries due soon, and checks running or
+// waiting for a concurrency slot
+func (repo *DBRepo) SchedulerMetrics(w http.ResponseWriter, r *http.Request) {
+	var response schedulerMetricsResponse
+	response.OK = true
+	response.Message = "Scheduler metrics"
+
+	now := time.Now()
+	var next time.Time
+
+	for _, managed := range repo.App.Scheduler.List() {
+		entry := managed.Entry
+		response.Entries++
+		if entry.Next.IsZero() {
+			continue
+		}
+		if entry.Next.Before(now.Add(time.Minute)) {
+			response.DueWithinMinute++
+		}
+		if next.IsZero() || entry.Next.Before(next) {
+			next = entry.Next
+		}
+	}
+
+	if !next.IsZero() {
+		response.NextRun = scheduling.Humanize(next, now)
+	}
+
+	response.Checks = checkLimiter.Metrics()
+
+	helpers.RenderJSON(w, response)
+}
@@ -2,22 +2,115 @@ package handlers
 
 import (
 	"fmt"
+	"github.com/robfig/cron/v3"
+	"golang-observer-project/internal/config"
+	"golang-observer-project/internal/models"
+	"golang-observer-project/internal/scheduling"
 	"log"
 	"strconv"
 	"time"
 )
 
+const (
+	defaultMaxConcurrentChecks        = 20
+	defaultMaxConcurrentChecksPerHost = 2
+	defaultCheckSpread                = time.Minute
+)
+
+// checkLimiter caps how many scheduled checks run at once
+var checkLimiter = scheduling.NewLimiter(defaultMaxConcurrentChecks, defaultMaxConcurrentChecksPerHost)
+
 type job struct {
 	HostServiceID int
+	HostID        int
 }
 
 func (j job) Run() {
-	Repo.ScheduledCheck(j.HostServiceID)
+	if Repo.App.CheckMode == config.CheckModeScheduler {
+		Repo.queueCheck(j.HostServiceID, j.HostID)
+		return
+	}
+
+	release := checkLimiter.Acquire(j.HostID)
+	defer release()
+
+	_ = Repo.ScheduledCheck(j.HostServiceID)
+}
+
+// scheduleHostService adds the check of a host service to the scheduler, replacing the one
+// it had, on its own schedule moved by a stable offset within check_spread_seconds, so checks
+// are spread out
+func (repo *DBRepo) scheduleHostService(hs models.HostServices) (cron.Entry, error) {
+	schedule, err := repo.hostServiceSchedule(hs)
+	if err != nil {
+		return cron.Entry{}, err
+	}
+
+	repo.App.Scheduler.Add(hs.ID, scheduling.Key(hs), schedule, job{HostServiceID: hs.ID, HostID: hs.HostID})
+	entry, _ := repo.App.Scheduler.Entry(hs.ID)
+
+	return entry, nil
+}
+
+// hostServiceSchedule builds the schedule of a host service, with its jitter
+func (repo *DBRepo) hostServiceSchedule(hs models.HostServices) (cron.Schedule, error) {
+	schedule, err := scheduling.New(hs, repo.App.Scheduler.Location())
+	if err != nil {
+		return nil, err
+	}
+
+	spread := defaultCheckSpread
+	if seconds, err := strconv.Atoi(repo.App.Preferences.Get("check_spread_seconds")); err == nil && seconds >= 0 {
+		spread = time.Duration(seconds) * time.Second
+	}
+
+	return scheduling.Jitter(schedule, scheduling.Offset(hs.ID, spread), time.Now()), nil
+}
+
+// ApplyCheckLimits sets the concurrency limits of scheduled checks from the
+// max_concurrent_checks and max_concurrent_checks_per_host preferences (0 for unlimited)
+func (repo *DBRepo) ApplyCheckLimits() {
+	global := defaultMaxConcurrentChecks
+	if n, err := strconv.Atoi(repo.App.Preferences.Get("max_concurrent_checks")); err == nil && n >= 0 {
+		global = n
+	}
+
+	perHost := defaultMaxConcurrentChecksPerHost
+	if n, err := strconv.Atoi(repo.App.Preferences.Get("max_concurrent_checks_per_host")); err == nil && n >= 0 {
+		perHost = n
+	}
+
+	checkLimiter.SetLimits(global, perHost)
+}
+
+// schedulesChecks reports whether this process schedules checks: monitoring is live, it is
+// not a worker and it is the leader
+func (repo *DBRepo) schedulesChecks() bool {
+	return repo.App.CheckMode != config.CheckModeWorker && repo.isLeader() &&
+		repo.App.Preferences.Get("monitoring_live") == "1"
+}
+
+// applyMonitoringLive starts or stops the scheduler after monitoring_live changed; only the
+// leader runs it, other instances keep it stopped
+func (repo *DBRepo) applyMonitoringLive() {
+	if repo.App.Preferences.Get("monitoring_live") == "1" {
+		log.Println("Starting monitoring...")
+		repo.StartMonitoring()
+		if repo.isLeader() {
+			repo.App.Scheduler.Start()
+		}
+		return
+	}
+
+	log.Println("Stopping monitoring...")
+	// delete all entries from scheduler, be sure to stop the scheduler
+	repo.App.Scheduler.RemoveAll()
+	repo.App.Scheduler.Stop()
 }
 
 func (repo *DBRepo) StartMonitoring() {
-	log.Println(app.PreferenceMap["monitoring_live"], "monitoring live")
-	if app.PreferenceMap["monitoring_live"] == "1" {
+	log.Println(app.Preferences.Get("monitoring_live"), "monitoring live")
+	if repo.schedulesChecks() {
 		log.Println("Monitoring started..")
 		data := make(map[string]string)
 		data["message"] = "Monitoring started"
@@ -34,28 +127,19 @@ func (repo *DBRepo) StartMonitoring() {
 		}
 
 		for _, service := range servicesToMonitor {
-			var sch string
-			if service.SchedulerUnit == "d" {
-				sch = fmt.Sprintf("@every %d%s", service.SchedulerNumber*24, "h")
-			} else {
-				sch = fmt.Sprintf("@every %d%s", service.SchedulerNumber, service.SchedulerUnit)
-			}
-			var j job
-			j.HostServiceID = service.ID
-			jobID, err := app.Scheduler.AddJob(sch, j)
+			entry, err := repo.scheduleHostService(service)
 			if err != nil {
-				log.Println(err)
+				log.Printf("cannot schedule host service %d: %s\n", service.ID, err)
+				continue
 			}
 
-			app.MonitorMap[service.ID] = jobID
-
 			payload := make(map[string]string)
 			payload["message"] = fmt.Sprintf("Monitoring %s", service.Service.ServiceName)
 			payload["host_service_id"] = strconv.Itoa(service.ID)
 
 			yearOne := time.Date(0001, 11, 17, 20, 34, 58, 0, time.UTC)
-			if app.Scheduler.Entry(app.MonitorMap[service.ID]).Next.After(yearOne) {
-				payload["next_run"] = app.Scheduler.Entry(app.MonitorMap[service.ID]).Next.Format("2006-01-02 15:04:05")
+			if entry.Next.After(yearOne) {
+				payload["next_run"] = entry.Next.Format("2006-01-02 15:04:05")
 			} else {
 				payload["next_run"] = "Pending..."
 			}
@@ -68,7 +152,7 @@ func (repo *DBRepo) StartMonitoring() {
 				payload["last_run"] = "Pending..."
 			}
 
-			payload["schedule"] = fmt.Sprintf("@every %d%s", service.SchedulerNumber, service.SchedulerUnit)
+			payload["schedule"] = scheduling.Describe(service)
 
 			err = app.WsClient.Trigger("public-channel", "next-run-event", payload)
 			if err != nil {
@@ -0,0 +1,354 @@
+package handlers
+
+import (
+	"encoding/json"
+	"github.com/go-chi/chi/v5"
+	"golang-observer-project/internal/helpers"
+	"golang-observer-project/internal/models"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// contactMethodTypes are the contact methods a user can register
+var contactMethodTypes = map[string]bool{
+	"email":    true,
+	"sms":      true,
+	"telegram": true,
+}
+
+// severityRank orders statuses so subscriptions can ask for "warning or worse"
+var severityRank = map[string]int{
+	"healthy": 0,
+	"warning": 1,
+	"problem": 2,
+}
+
+// contact is one address a status change notification should be delivered to
+type contact struct {
+	Name       string
+	MethodType string
+	Address    string
+}
+
+// subscribedContacts returns the contact methods of every user subscribed to a status change
+// on host h, skipping users in their quiet hours. ok is false when no user has any
+// subscriptions, in which case the global notification recipients apply.
+func (repo *DBRepo) subscribedContacts(h models.Host, oldStatus, newStatus string) (contacts []contact, ok bool) {
+	subscriptions, err := repo.DB.AllSubscriptions()
+	if err != nil {
+		log.Println(err)
+		return nil, false
+	}
+
+	if len(subscriptions) == 0 {
+		return nil, false
+	}
+
+	matched := make(map[int]bool)
+	for _, sub := range subscriptions {
+		if !matched[sub.UserID] && subscriptionMatches(sub, h, oldStatus, newStatus) {
+			matched[sub.UserID] = true
+		}
+	}
+
+	seen := make(map[string]bool)
+	for userID := range matched {
+		u, err := repo.DB.GetUserById(userID)
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+
+		if inQuietHours(u, time.Now()) {
+			log.Printf("not notifying %s, user is in quiet hours\n", u.Email)
+			continue
+		}
+
+		methods, err := repo.DB.ContactMethodsForUser(userID)
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+
+		for _, cm := range methods {
+			key := cm.MethodType + ":" + cm.Address
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+
+			contacts = append(contacts, contact{
+				Name:       strings.TrimSpace(u.FirstName + " " + u.LastName),
+				MethodType: cm.MethodType,
+				Address:    cm.Address,
+			})
+		}
+	}
+
+	return contacts, true
+}
+
+// subscriptionMatches reports whether a subscription covers a status change on host h
+func subscriptionMatches(sub models.Subscription, h models.Host, oldStatus, newStatus string) bool {
+	if sub.HostID != 0 && sub.HostID != h.ID {
+		return false
+	}
+
+	if sub.Tag != "" {
+		found := false
+		for _, tag := range h.Tags {
+			if tag == sub.Tag {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if sub.Severity == "" {
+		return true
+	}
+
+	// a recovery matters to everyone who was told about the problem
+	rank := severityRank[newStatus]
+	if severityRank[oldStatus] > rank {
+		rank = severityRank[oldStatus]
+	}
+
+	return rank >= severityRank[sub.Severity]
+}
+
+// inQuietHours reports whether now falls within the user's quiet hours, in the user's time zone
+func inQuietHours(u models.User, now time.Time) bool {
+	if u.QuietHoursStart == "" || u.QuietHoursEnd == "" {
+		return false
+	}
+
+	start, err := time.Parse("15:04", u.QuietHoursStart)
+	if err != nil {
+		return false
+	}
+
+	end, err := time.Parse("15:04", u.QuietHoursEnd)
+	if err != nil {
+		return false
+	}
+
+	location, err := time.LoadLocation(u.TimeZone)
+	if err != nil {
+		location = time.UTC
+	}
+
+	local := now.In(location)
+	minute := local.Hour()*60 + local.Minute()
+	startMinute := start.Hour()*60 + start.Minute()
+	endMinute := end.Hour()*60 + end.Minute()
+
+	if startMinute <= endMinute {
+		return minute >= startMinute && minute < endMinute
+	}
+
+	// quiet hours span midnight, e.g. 22:00 - 07:00
+	return minute >= startMinute || minute < endMinute
+}
+
+// UserNotifications shows the notification settings, contact methods and subscriptions of a user
+func (repo *DBRepo) UserNotifications(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.Atoi(chi.URLParam(r, "id"))
+
+	u, err := repo.DB.GetUserById(id)
+	if err != nil {
+		ClientError(w, r, http.StatusNotFound)
+		return
+	}
+
+	methods, err := repo.DB.ContactMethodsForUser(id)
+	if err != nil {
+		log.Println(err)
+		ClientError(w, r, http.StatusBadRequest)
+		return
+	}
+
+	subscriptions, err := repo.DB.SubscriptionsForUser(id)
+	if err != nil {
+		log.Println(err)
+		ClientError(w, r, http.StatusBadRequest)
+		return
+	}
+
+	var response models.UserNotificationsResponse
+	response.OK = true
+	response.Message = "Notification settings retrieved"
+	response.TimeZone = u.TimeZone
+	response.QuietHoursStart = u.QuietHoursStart
+	response.QuietHoursEnd = u.QuietHoursEnd
+	response.ContactMethods = methods
+	response.Subscriptions = subscriptions
+
+	helpers.RenderJSON(w, response)
+}
+
+// PostQuietHours sets the time zone and quiet hours of a user
+func (repo *DBRepo) PostQuietHours(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.Atoi(chi.URLParam(r, "id"))
+
+	var req models.QuietHoursRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		log.Println(err)
+		ClientError(w, r, http.StatusBadRequest)
+		return
+	}
+
+	var jsonResp jsonResp
+	jsonResp.OK = true
+	jsonResp.Message = "Quiet hours updated"
+
+	if req.TimeZone == "" {
+		req.TimeZone = "UTC"
+	}
+
+	if _, err := time.LoadLocation(req.TimeZone); err != nil {
+		jsonResp.OK = false
+		jsonResp.Message = "Unknown time zone " + req.TimeZone
+		helpers.RenderJSON(w, jsonResp)
+		return
+	}
+
+	for _, v := range []string{req.QuietHoursStart, req.QuietHoursEnd} {
+		if _, err := time.Parse("15:04", v); v != "" && err != nil {
+			jsonResp.OK = false
+			jsonResp.Message = "Quiet hours must be given as HH:MM"
+			helpers.RenderJSON(w, jsonResp)
+			return
+		}
+	}
+
+	err = repo.DB.UpdateUserQuietHours(id, req.TimeZone, req.QuietHoursStart, req.QuietHoursEnd)
+	if err != nil {
+		ClientError(w, r, http.StatusBadRequest)
+		return
+	}
+
+	helpers.RenderJSON(w, jsonResp)
+}
+
+// PostContactMethod adds a contact method to a user
+func (repo *DBRepo) PostContactMethod(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.Atoi(chi.URLParam(r, "id"))
+
+	var req models.ContactMethodRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		log.Println(err)
+		ClientError(w, r, http.StatusBadRequest)
+		return
+	}
+
+	var jsonResp jsonResp
+	jsonResp.OK = true
+	jsonResp.Message = "Contact method added"
+
+	req.Address = strings.TrimSpace(req.Address)
+	if !contactMethodTypes[req.MethodType] || req.Address == "" {
+		jsonResp.OK = false
+		jsonResp.Message = "Contact method needs a type (email, sms or telegram) and an address"
+		helpers.RenderJSON(w, jsonResp)
+		return
+	}
+
+	_, err = repo.DB.InsertContactMethod(models.ContactMethod{
+		UserID:     id,
+		MethodType: req.MethodType,
+		Address:    req.Address,
+	})
+	if err != nil {
+		ClientError(w, r, http.StatusBadRequest)
+		return
+	}
+
+	helpers.RenderJSON(w, jsonResp)
+}
+
+// DeleteContactMethod removes a contact method from a user
+func (repo *DBRepo) DeleteContactMethod(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.Atoi(chi.URLParam(r, "id"))
+	methodID, _ := strconv.Atoi(chi.URLParam(r, "methodID"))
+
+	err := repo.DB.DeleteContactMethod(id, methodID)
+	if err != nil {
+		ClientError(w, r, http.StatusBadRequest)
+		return
+	}
+
+	var jsonResp jsonResp
+	jsonResp.OK = true
+	jsonResp.Message = "Contact method deleted"
+
+	helpers.RenderJSON(w, jsonResp)
+}
+
+// PostSubscription subscribes a user to a host, a tag or a severity level
+func (repo *DBRepo) PostSubscription(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.Atoi(chi.URLParam(r, "id"))
+
+	var req models.SubscriptionRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		log.Println(err)
+		ClientError(w, r, http.StatusBadRequest)
+		return
+	}
+
+	var jsonResp jsonResp
+	jsonResp.OK = true
+	jsonResp.Message = "Subscription added"
+
+	if req.Severity != "" && req.Severity != "warning" && req.Severity != "problem" {
+		jsonResp.OK = false
+		jsonResp.Message = "Severity must be empty, warning or problem"
+		helpers.RenderJSON(w, jsonResp)
+		return
+	}
+
+	_, err = repo.DB.InsertSubscription(models.Subscription{
+		UserID:   id,
+		HostID:   req.HostID,
+		Tag:      normalizeTag(req.Tag),
+		Severity: req.Severity,
+	})
+	if err != nil {
+		ClientError(w, r, http.StatusBadRequest)
+		return
+	}
+
+	helpers.RenderJSON(w, jsonResp)
+}
+
+// DeleteSubscription removes a subscription from a user
+func (repo *DBRepo) DeleteSubscription(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.Atoi(chi.URLParam(r, "id"))
+	subscriptionID, _ := strconv.Atoi(chi.URLParam(r, "subscriptionID"))
+
+	err := repo.DB.DeleteSubscription(id, subscriptionID)
+	if err != nil {
+		ClientError(w, r, http.StatusBadRequest)
+		return
+	}
+
+	var jsonResp jsonResp
+	jsonResp.OK = true
+	jsonResp.Message = "Subscription deleted"
+
+	helpers.RenderJSON(w, jsonResp)
+}
+
+// normalizeTag trims and lower cases a tag so matching is predictable
+func normalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimSpace(tag))
+}
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	mail "github.com/xhit/go-simple-mail/v2"
+	"golang-observer-project/internal/helpers"
+	"golang-observer-project/internal/mailer"
+	"io"
+	"log"
+	"net/http"
+	"time"
+)
+
+type testEmailRequest struct {
+	To string `json:"to"`
+	// UpdatePreferences overrides saved smtp_* settings, to try them before saving
+	UpdatePreferences []settingUpdateObj `json:"UpdatePreferences"`
+}
+
+// SendTestEmail sends a test email straight to the SMTP server, bypassing the mail outbox,
+// and reports the SMTP error as is
+func (repo *DBRepo) SendTestEmail(w http.ResponseWriter, r *http.Request) {
+	var req testEmailRequest
+	err := helpers.ReadJSONBody(r, &req)
+	if err != nil && !errors.Is(err, io.EOF) {
+		log.Println(err)
+		ClientError(w, r, http.StatusBadRequest)
+		return
+	}
+
+	prefs := repo.App.Preferences.Map()
+	for _, v := range req.UpdatePreferences {
+		prefs[v.Name] = v.Preference
+	}
+
+	if req.To == "" {
+		req.To = prefs["notify_email"]
+	}
+
+	var jsonResp jsonResp
+	jsonResp.OK = true
+	jsonResp.Message = fmt.Sprintf("Test email sent to %s", req.To)
+
+	from := prefs["smtp_from_email"]
+	if prefs["smtp_from_name"] != "" {
+		from = fmt.Sprintf("%s <%s>", prefs["smtp_from_name"], from)
+	}
+
+	email := mail.NewMSG()
+	email.SetFrom(from).
+		AddTo(req.To).
+		SetSubject("Observer test email").
+		SetBody(mail.TextPlain, fmt.Sprintf("SMTP settings work: this email was sent through %s:%s at %s.",
+			prefs["smtp_server"], prefs["smtp_port"], time.Now().Format(time.RFC1123)))
+
+	err = mailer.SendOnce(prefs, email)
+	if err != nil {
+		jsonResp.OK = false
+		jsonResp.Message = err.Error()
+	}
+
+	helpers.RenderJSON(w, jsonResp)
+}
@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"golang-observer-project/internal/checks"
+	"golang-observer-project/internal/helpers"
+	"golang-observer-project/internal/models"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// stepTimesIndex is the Elastic index of the timings of transaction steps
+const stepTimesIndex = "transaction-steps"
+
+// addStepTimes stores the timings of the steps of a transaction run from location in Elastic
+func (repo *DBRepo) addStepTimes(steps []models.StepTimes, location string, h models.Host, hs models.HostServices) {
+	for _, step := range steps {
+		step.ID = uuid.New().String()
+		step.Location = location
+		step.Host = h
+		step.HostServices = hs
+		step.CreatedAt = time.Now()
+		step.UpdatedAt = time.Now()
+
+		err := repo.ElasticClient.AddStepTimes(stepTimesIndex, step.ID, step)
+		if err != nil {
+			log.Println(err)
+		}
+	}
+}
+
+// HostServiceTransaction shows the steps of a transaction host service
+func (repo *DBRepo) HostServiceTransaction(w http.ResponseWriter, r *http.Request) {
+	hs, ok := repo.transactionHostService(w, r)
+	if !ok {
+		return
+	}
+
+	var response models.TransactionResponse
+
+	t, err := repo.DB.Transaction(hs.ID)
+	if errors.Is(err, models.ErrNoRecord) {
+		response.OK = true
+		response.Message = "No steps set"
+		response.Transaction = models.Transaction{HostServiceID: hs.ID, Steps: []models.TransactionStep{},
+			TimeoutSeconds: int(checks.DefaultTransactionTimeout / time.Second)}
+		helpers.RenderJSON(w, response)
+		return
+	} else if err != nil {
+		ClientError(w, r, http.StatusInternalServerError)
+		return
+	}
+
+	response.OK = true
+	response.Message = "Transaction retrieved"
+	response.Transaction = t
+
+	helpers.RenderJSON(w, response)
+}
+
+// PostHostServiceTransaction sets the steps of a transaction host service and its timeout
+func (repo *DBRepo) PostHostServiceTransaction(w http.ResponseWriter, r *http.Request) {
+	hs, ok := repo.transactionHostService(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.TransactionPostRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		log.Println(err)
+		ClientError(w, r, http.StatusBadRequest)
+		return
+	}
+
+	var response models.TransactionResponse
+
+	if req.TimeoutSeconds == 0 {
+		req.TimeoutSeconds = int(checks.DefaultTransactionTimeout / time.Second)
+	}
+	if req.TimeoutSeconds < 1 || time.Duration(req.TimeoutSeconds)*time.Second > checks.MaxTransactionTimeout {
+		response.Message = "The timeout must be between a second and " + checks.MaxTransactionTimeout.String()
+		helpers.RenderJSON(w, response)
+		return
+	}
+
+	t := models.Transaction{
+		HostServiceID:  hs.ID,
+		Steps:          req.Steps,
+		TimeoutSeconds: req.TimeoutSeconds,
+	}
+
+	err = checks.ValidateTransaction(t)
+	if err != nil {
+		response.Message = err.Error()
+		helpers.RenderJSON(w, response)
+		return
+	}
+
+	err = repo.DB.UpsertTransaction(t)
+	if err != nil {
+		ClientError(w, r, http.StatusBadRequest)
+		return
+	}
+
+	t, err = repo.DB.Transaction(hs.ID)
+	if err != nil {
+		ClientError(w, r, http.StatusInternalServerError)
+		return
+	}
+
+	response.OK = true
+	response.Message = "Transaction saved"
+	response.Transaction = t
+
+	helpers.RenderJSON(w, response)
+}
+
+// HostServiceTransactionSteps returns the timings of the steps of a transaction host service
+// in the last minutes
+func (repo *DBRepo) HostServiceTransactionSteps(w http.ResponseWriter, r *http.Request) {
+	hs, ok := repo.transactionHostService(w, r)
+	if !ok {
+		return
+	}
+
+	minutes, err := strconv.Atoi(chi.URLParam(r, "minutes"))
+	if err != nil || minutes < 1 {
+		ClientError(w, r, http.StatusBadRequest)
+		return
+	}
+
+	steps, err := repo.ElasticClient.StepTimesInLastXMinutes(stepTimesIndex, minutes, hs.ID)
+	if err != nil {
+		ClientError(w, r, http.StatusInternalServerError)
+		return
+	}
+
+	var response models.StepTimesResponse
+	response.OK = true
+	response.Message = "Step timings retrieved"
+	response.Steps = steps
+
+	helpers.RenderJSON(w, response)
+}
+
+// transactionHostService returns the transaction host service in the URL, or answers with an
+// error
+func (repo *DBRepo) transactionHostService(w http.ResponseWriter, r *http.Request) (models.HostServices, bool) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		ClientError(w, r, http.StatusBadRequest)
+		return models.HostServices{}, false
+	}
+
+	hs, err := repo.DB.GetHostServiceByID(id)
+	if err != nil {
+		ClientError(w, r, http.StatusNotFound)
+		return hs, false
+	}
+
+	if hs.ServiceID != Transaction {
+		ClientError(w, r, http.StatusBadRequest)
+		return hs, false
+	}
+
+	return hs, true
+}
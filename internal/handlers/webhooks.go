@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"encoding/json"
+	"github.com/go-chi/chi/v5"
+	"golang-observer-project/internal/helpers"
+	"golang-observer-project/internal/models"
+	"golang-observer-project/internal/notifiers"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// AllWebhooks lists all webhooks; their secrets are not shown, only whether they have one
+func (repo *DBRepo) AllWebhooks(w http.ResponseWriter, r *http.Request) {
+	webhooks, err := repo.DB.AllWebhooks()
+	if err != nil {
+		log.Println(err)
+		ClientError(w, r, http.StatusBadRequest)
+		return
+	}
+
+	var response models.WebhooksJsonResponse
+	response.OK = true
+	response.Message = "Webhooks retrieved"
+	response.Webhooks = make([]models.Webhook, 0, len(webhooks))
+	for _, wh := range webhooks {
+		response.Webhooks = append(response.Webhooks, hideWebhookSecret(wh))
+	}
+
+	helpers.RenderJSON(w, response)
+}
+
+// Webhook shows one webhook without its secret
+func (repo *DBRepo) Webhook(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.Atoi(chi.URLParam(r, "id"))
+
+	var response models.WebhookJsonResponse
+	response.OK = true
+	response.Message = "Webhook retrieved"
+
+	if id > 0 {
+		wh, err := repo.DB.GetWebhookByID(id)
+		if err != nil {
+			log.Println(err)
+			ClientError(w, r, http.StatusNotFound)
+			return
+		}
+		response.Webhook = hideWebhookSecret(wh)
+	}
+
+	helpers.RenderJSON(w, response)
+}
+
+// PostWebhook adds or updates a webhook; an empty secret keeps the one saved before
+func (repo *DBRepo) PostWebhook(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.Atoi(chi.URLParam(r, "id"))
+
+	var req models.WebhookPostRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		log.Println(err)
+		ClientError(w, r, http.StatusBadRequest)
+		return
+	}
+
+	wh := models.Webhook{
+		ID:              id,
+		Name:            req.Name,
+		URL:             req.URL,
+		Secret:          req.Secret,
+		PayloadTemplate: req.PayloadTemplate,
+		Active:          req.Active,
+	}
+
+	var response models.WebhookJsonResponse
+	response.OK = true
+
+	// make sure the template is usable before saving it
+	_, err = notifiers.RenderWebhookPayload(wh, sampleStatusChange())
+	if wh.URL == "" || err != nil {
+		response.OK = false
+		response.Message = "Webhook needs a URL and a valid payload template"
+		if err != nil {
+			response.Message = err.Error()
+		}
+		helpers.RenderJSON(w, response)
+		return
+	}
+
+	if id > 0 && wh.Secret == "" {
+		saved, err := repo.DB.GetWebhookByID(id)
+		if err == nil {
+			wh.Secret = saved.Secret
+		}
+	}
+
+	if id > 0 {
+		err = repo.DB.UpdateWebhook(wh)
+		response.Message = "Webhook updated"
+	} else {
+		wh.ID, err = repo.DB.InsertWebhook(wh)
+		response.Message = "Webhook added"
+	}
+	if err != nil {
+		log.Println(err)
+		ClientError(w, r, http.StatusBadRequest)
+		return
+	}
+
+	response.Webhook = hideWebhookSecret(wh)
+
+	helpers.RenderJSON(w, response)
+}
+
+// hideWebhookSecret blanks the secret of a webhook, keeping whether it has one
+func hideWebhookSecret(wh models.Webhook) models.Webhook {
+	wh.SecretSet = wh.Secret != ""
+	wh.Secret = ""
+	return wh
+}
+
+// DeleteWebhook deletes a webhook
+func (repo *DBRepo) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.Atoi(chi.URLParam(r, "id"))
+	err := repo.DB.DeleteWebhook(id)
+	if err != nil {
+		ClientError(w, r, http.StatusBadRequest)
+		return
+	}
+
+	var jsonResp jsonResp
+	jsonResp.OK = true
+	jsonResp.Message = "Webhook deleted"
+
+	helpers.RenderJSON(w, jsonResp)
+}
+
+// SendTestWebhook delivers a sample status change to a webhook and reports the result
+func (repo *DBRepo) SendTestWebhook(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.Atoi(chi.URLParam(r, "id"))
+	wh, err := repo.DB.GetWebhookByID(id)
+	if err != nil {
+		log.Println(err)
+		ClientError(w, r, http.StatusNotFound)
+		return
+	}
+
+	var jsonResp jsonResp
+	jsonResp.OK = true
+	jsonResp.Message = "Test webhook delivered"
+
+	// a single attempt, so the caller sees the error right away
+	err = repo.deliverWebhook(r.Context(), wh, sampleStatusChange(), 1)
+	if err != nil {
+		jsonResp.OK = false
+		jsonResp.Message = err.Error()
+	}
+
+	helpers.RenderJSON(w, jsonResp)
+}
+
+// sampleStatusChange returns a made up status change used to test notification channels
+func sampleStatusChange() notifiers.StatusChange {
+	return notifiers.StatusChange{
+		HostName:    "example.com",
+		ServiceName: "HTTP",
+		OldStatus:   "healthy",
+		NewStatus:   "problem",
+		Message:     "This is a test notification from Observer",
+		CheckedAt:   time.Now(),
+	}
+}
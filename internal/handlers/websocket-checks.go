@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"golang-observer-project/internal/checks"
+	"golang-observer-project/internal/helpers"
+	"golang-observer-project/internal/models"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// HostServiceWebSocket shows the endpoint and message of a WebSocket host service; header
+// values often carry credentials, so only the names of the headers set are shown
+func (repo *DBRepo) HostServiceWebSocket(w http.ResponseWriter, r *http.Request) {
+	hs, ok := repo.webSocketHostService(w, r)
+	if !ok {
+		return
+	}
+
+	var response models.WebSocketCheckResponse
+
+	wc, err := repo.DB.WebSocketCheck(hs.ID)
+	if errors.Is(err, models.ErrNoRecord) {
+		response.OK = true
+		response.Message = "No WebSocket check set"
+		response.WebSocketCheck = models.WebSocketCheck{HostServiceID: hs.ID, Headers: map[string]string{},
+			TimeoutSeconds: int(checks.DefaultWebSocketTimeout / time.Second)}
+		response.HeadersSet = []string{}
+		helpers.RenderJSON(w, response)
+		return
+	} else if err != nil {
+		ClientError(w, r, http.StatusInternalServerError)
+		return
+	}
+
+	response.OK = true
+	response.Message = "WebSocket check retrieved"
+	response.WebSocketCheck, response.HeadersSet = hideHeaders(wc)
+
+	helpers.RenderJSON(w, response)
+}
+
+// PostHostServiceWebSocket saves the endpoint and message of a WebSocket host service; an empty
+// header value keeps the one saved before
+func (repo *DBRepo) PostHostServiceWebSocket(w http.ResponseWriter, r *http.Request) {
+	hs, ok := repo.webSocketHostService(w, r)
+	if !ok {
+		return
+	}
+
+	var wc models.WebSocketCheck
+	err := json.NewDecoder(r.Body).Decode(&wc)
+	if err != nil {
+		log.Println(err)
+		ClientError(w, r, http.StatusBadRequest)
+		return
+	}
+
+	saved, err := repo.DB.WebSocketCheck(hs.ID)
+	if err == nil {
+		keepHeaders(wc.Headers, saved.Headers)
+	}
+
+	var response models.WebSocketCheckResponse
+
+	err = checks.ValidateWebSocketCheck(wc)
+	if err != nil {
+		response.Message = err.Error()
+		helpers.RenderJSON(w, response)
+		return
+	}
+
+	wc.HostServiceID = hs.ID
+	if wc.TimeoutSeconds == 0 {
+		wc.TimeoutSeconds = int(checks.DefaultWebSocketTimeout / time.Second)
+	}
+
+	err = repo.DB.UpsertWebSocketCheck(wc)
+	if err != nil {
+		ClientError(w, r, http.StatusBadRequest)
+		return
+	}
+
+	wc, err = repo.DB.WebSocketCheck(hs.ID)
+	if err != nil {
+		ClientError(w, r, http.StatusInternalServerError)
+		return
+	}
+
+	response.OK = true
+	response.Message = "WebSocket check saved"
+	response.WebSocketCheck, response.HeadersSet = hideHeaders(wc)
+
+	helpers.RenderJSON(w, response)
+}
+
+// webSocketHostService returns the WebSocket host service in the URL, or answers with an error
+func (repo *DBRepo) webSocketHostService(w http.ResponseWriter, r *http.Request) (models.HostServices, bool) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		ClientError(w, r, http.StatusBadRequest)
+		return models.HostServices{}, false
+	}
+
+	hs, err := repo.DB.GetHostServiceByID(id)
+	if err != nil {
+		ClientError(w, r, http.StatusNotFound)
+		return hs, false
+	}
+
+	if hs.ServiceID != WebSocket {
+		ClientError(w, r, http.StatusBadRequest)
+		return hs, false
+	}
+
+	return hs, true
+}
+
+// hideHeaders blanks the header values of a WebSocket check, and returns the names of the
+// headers that had one
+func hideHeaders(wc models.WebSocketCheck) (models.WebSocketCheck, []string) {
+	headers := make(map[string]string, len(wc.Headers))
+	headersSet := make([]string, 0, len(wc.Headers))
+	for name, value := range wc.Headers {
+		headers[name] = ""
+		if value != "" {
+			headersSet = append(headersSet, name)
+		}
+	}
+	sort.Strings(headersSet)
+
+	wc.Headers = headers
+	return wc, headersSet
+}
+
+// keepHeaders fills the empty header values of headers with the saved ones
+func keepHeaders(headers, saved map[string]string) {
+	for name, value := range headers {
+		if value == "" && saved[name] != "" {
+			headers[name] = saved[name]
+		}
+	}
+}
@@ -1,15 +1,24 @@
 package helpers
 
-import "golang-observer-project/internal/channeldata"
+import (
+	"golang-observer-project/internal/channeldata"
+	"log"
+)
 
-// SendEmail sends an email
-func SendEmail(mailMessage channeldata.MailData) {
+// SendEmail stores an email in the mail outbox, from which it is sent
+func SendEmail(mailMessage channeldata.MailData) error {
 	// if no sender specified, use defaults
 	if mailMessage.FromAddress == "" {
-		mailMessage.FromAddress = app.PreferenceMap["smtp_from_email"]
-		mailMessage.FromName = app.PreferenceMap["smtp_from_name"]
+		mailMessage.FromAddress = app.Preferences.Get("smtp_from_email")
+		mailMessage.FromName = app.Preferences.Get("smtp_from_name")
 	}
 
-	job := channeldata.MailJob{MailMessage: mailMessage}
-	app.MailQueue <- job
+	id, err := app.MailOutbox.Queue(mailMessage)
+	if err != nil {
+		log.Printf("cannot store email to %s in the outbox: %s\n", mailMessage.ToAddress, err)
+		return err
+	}
+
+	log.Printf("Email %d queued\n", id)
+	return nil
 }
@@ -0,0 +1,164 @@
+package mailer
+
+import (
+	"errors"
+	"fmt"
+	mail "github.com/xhit/go-simple-mail/v2"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Encryption modes for the smtp_encryption preference
+const (
+	EncryptionNone     = "none"
+	EncryptionSTARTTLS = "starttls"
+	EncryptionTLS      = "tls"
+)
+
+// Auth mechanisms for the smtp_auth preference
+const (
+	AuthNone    = "none"
+	AuthPlain   = "plain"
+	AuthLogin   = "login"
+	AuthCRAMMD5 = "cram-md5"
+)
+
+// settings are the preferences a connection is built from; a change to any of them
+// means the connection has to be made again
+var settings = []string{"smtp_server", "smtp_port", "smtp_user", "smtp_password", "smtp_encryption", "smtp_auth"}
+
+// NewServer builds the SMTP server settings from the smtp_* preferences
+func NewServer(prefs map[string]string) (*mail.SMTPServer, error) {
+	server := mail.NewSMTPClient()
+	server.Host = prefs["smtp_server"]
+	server.Username = prefs["smtp_user"]
+	server.Password = prefs["smtp_password"]
+	server.ConnectTimeout = 10 * time.Second
+	server.SendTimeout = 10 * time.Second
+
+	if server.Host == "" {
+		return nil, errors.New("no SMTP server configured")
+	}
+
+	port, err := strconv.Atoi(prefs["smtp_port"])
+	if err != nil {
+		return nil, fmt.Errorf("invalid SMTP port %q", prefs["smtp_port"])
+	}
+	server.Port = port
+
+	switch strings.ToLower(prefs["smtp_encryption"]) {
+	case EncryptionNone:
+		server.Encryption = mail.EncryptionNone
+	case EncryptionTLS, "ssl":
+		server.Encryption = mail.EncryptionSSL
+	case EncryptionSTARTTLS, "":
+		// go-simple-mail calls STARTTLS "TLS"
+		server.Encryption = mail.EncryptionTLS
+	default:
+		return nil, fmt.Errorf("unknown SMTP encryption %q", prefs["smtp_encryption"])
+	}
+
+	switch strings.ToLower(prefs["smtp_auth"]) {
+	case AuthNone:
+		server.Username = ""
+		server.Password = ""
+	case AuthPlain:
+		server.Authentication = mail.AuthPlain
+	case AuthLogin:
+		server.Authentication = mail.AuthLogin
+	case AuthCRAMMD5:
+		server.Authentication = mail.AuthCRAMMD5
+	case "":
+		// what was used before the smtp_auth preference existed
+		if server.Host == "localhost" {
+			server.Authentication = mail.AuthPlain
+		} else {
+			server.Authentication = mail.AuthLogin
+		}
+	default:
+		return nil, fmt.Errorf("unknown SMTP auth mechanism %q", prefs["smtp_auth"])
+	}
+
+	return server, nil
+}
+
+// Conn is a kept-alive SMTP connection, made on first use and again whenever it has been
+// dropped or the SMTP preferences change. A Conn is not safe for concurrent use; every mail
+// worker has its own.
+type Conn struct {
+	client   *mail.SMTPClient
+	settings string
+}
+
+// Send sends email over the connection, connecting first when needed. After a failed send
+// the connection is dropped, so the next send starts from a fresh one.
+func (c *Conn) Send(prefs map[string]string, email *mail.Email) error {
+	key := fingerprint(prefs)
+
+	if c.client != nil && (c.settings != key || c.client.Noop() != nil) {
+		c.Close()
+	}
+
+	if c.client == nil {
+		server, err := NewServer(prefs)
+		if err != nil {
+			return err
+		}
+		server.KeepAlive = true
+
+		client, err := server.Connect()
+		if err != nil {
+			return err
+		}
+
+		c.client = client
+		c.settings = key
+	}
+
+	err := email.Send(c.client)
+	if err != nil {
+		c.Close()
+		return err
+	}
+
+	return nil
+}
+
+// Close quits and closes the connection, if there is one
+func (c *Conn) Close() {
+	if c.client == nil {
+		return
+	}
+
+	_ = c.client.Quit()
+	_ = c.client.Close()
+	c.client = nil
+}
+
+// SendOnce sends email over a connection of its own, returning the SMTP error as is
+func SendOnce(prefs map[string]string, email *mail.Email) error {
+	server, err := NewServer(prefs)
+	if err != nil {
+		return err
+	}
+
+	client, err := server.Connect()
+	if err != nil {
+		return err
+	}
+	defer func(client *mail.SMTPClient) {
+		_ = client.Close()
+	}(client)
+
+	return email.Send(client)
+}
+
+// fingerprint identifies the SMTP preferences a connection was made with
+func fingerprint(prefs map[string]string) string {
+	values := make([]string, len(settings))
+	for i, name := range settings {
+		values[i] = prefs[name]
+	}
+	return strings.Join(values, "\x00")
+}
@@ -0,0 +1,117 @@
+package mailtemplates
+
+import (
+	"bytes"
+	"fmt"
+	"golang-observer-project/internal/models"
+	htmltemplate "html/template"
+	"os"
+	"path/filepath"
+	"strings"
+	texttemplate "text/template"
+	"time"
+)
+
+// Event types that have an email template
+const (
+	Problem             = "problem"
+	Warning             = "warning"
+	Recovery            = "recovery"
+	Digest              = "digest"
+	CertificateExpiring = "certificate-expiring"
+)
+
+// Names lists every template name, in the order they are shown to the admin
+var Names = []string{Problem, Warning, Recovery, CertificateExpiring, Digest}
+
+// DefaultDir holds the default templates, as <name>.subject.tmpl and <name>.body.tmpl
+const DefaultDir = "./views/email"
+
+// Context is the data available to email templates
+type Context struct {
+	Host          models.Host
+	HostService   models.HostServices
+	OldStatus     string
+	NewStatus     string
+	Message       string
+	DownSince     time.Time
+	Duration      time.Duration
+	ComputeTimes  *models.ComputeTimes
+	Link          string
+	PreferenceMap map[string]string
+	Data          map[string]interface{}
+}
+
+// IsValidName reports whether name is a known template name
+func IsValidName(name string) bool {
+	for _, n := range Names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// ForStatusChange returns the template name used for a status transition, or "" for a
+// transition into pending, e.g. an exec check exiting UNKNOWN, which is not emailed
+func ForStatusChange(serviceID int, newStatus string, certificateServiceID int) string {
+	switch {
+	case newStatus == "pending":
+		return ""
+	case serviceID == certificateServiceID && newStatus != "healthy":
+		return CertificateExpiring
+	case newStatus == "healthy":
+		return Recovery
+	case newStatus == "warning":
+		return Warning
+	default:
+		return Problem
+	}
+}
+
+// LoadDefault reads the default template for name from dir
+func LoadDefault(dir, name string) (models.EmailTemplate, error) {
+	et := models.EmailTemplate{Name: name}
+
+	subject, err := os.ReadFile(filepath.Join(dir, name+".subject.tmpl"))
+	if err != nil {
+		return et, err
+	}
+
+	body, err := os.ReadFile(filepath.Join(dir, name+".body.tmpl"))
+	if err != nil {
+		return et, err
+	}
+
+	et.Subject = strings.TrimSpace(string(subject))
+	et.Body = string(body)
+
+	return et, nil
+}
+
+// Render executes an email template, returning the subject line and the html content
+// that goes into the mail layout
+func Render(et models.EmailTemplate, ctx Context) (string, htmltemplate.HTML, error) {
+	subjectTemplate, err := texttemplate.New(et.Name + " subject").Parse(et.Subject)
+	if err != nil {
+		return "", "", fmt.Errorf("parsing subject: %w", err)
+	}
+
+	bodyTemplate, err := htmltemplate.New(et.Name + " body").Parse(et.Body)
+	if err != nil {
+		return "", "", fmt.Errorf("parsing body: %w", err)
+	}
+
+	var subject, body bytes.Buffer
+
+	if err := subjectTemplate.Execute(&subject, ctx); err != nil {
+		return "", "", fmt.Errorf("rendering subject: %w", err)
+	}
+
+	if err := bodyTemplate.Execute(&body, ctx); err != nil {
+		return "", "", fmt.Errorf("rendering body: %w", err)
+	}
+
+	// subjects are a single line, whatever the template looks like
+	return strings.Join(strings.Fields(subject.String()), " "), htmltemplate.HTML(body.String()), nil
+}
@@ -1,10 +1,12 @@
 package models
 
 import (
+	"encoding/json"
 	"errors"
 	"github.com/google/uuid"
 	"github.com/jackc/pgtype"
 	"github.com/robfig/cron/v3"
+	"golang-observer-project/internal/channeldata"
 	"time"
 )
 
@@ -31,6 +33,11 @@ type User struct {
 	CreatedAt   time.Time
 	UpdatedAt   time.Time
 	DeletedAt   time.Time
+
+	// notification settings; quiet hours are "HH:MM" in TimeZone
+	TimeZone        string
+	QuietHoursStart string
+	QuietHoursEnd   string
 }
 
 // User model
@@ -67,6 +74,7 @@ type Host struct {
 	CreatedAt     time.Time
 	UpdatedAt     time.Time
 	HostServices  []HostServices
+	Tags          []string
 }
 
 // Services model
@@ -87,13 +95,19 @@ type HostServices struct {
 	Active          int
 	SchedulerNumber int
 	SchedulerUnit   string
-	Status          string
-	LastCheck       time.Time
-	CreatedAt       time.Time
-	UpdatedAt       time.Time
-	Service         Services
-	HostName        string
-	LastMessage     string
+	// CronExpression, when set, is used instead of SchedulerNumber and SchedulerUnit
+	CronExpression string
+	// TimeZone the cron expression and active hours are in; empty for the scheduler's own
+	TimeZone string
+	// ActiveHours limits checks to windows such as "Mon-Fri 09:00-17:00"; empty for always
+	ActiveHours string
+	Status      string
+	LastCheck   time.Time
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	Service     Services
+	HostName    string
+	LastMessage string
 }
 
 // Schedule model
@@ -120,6 +134,459 @@ type Event struct {
 	UpdatedAt     time.Time
 }
 
+// Webhook model; SecretSet tells whether there is a secret when it is hidden from responses
+type Webhook struct {
+	ID              int
+	Name            string
+	URL             string
+	Secret          string
+	SecretSet       bool `json:"secret_set"`
+	PayloadTemplate string
+	Active          int
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+}
+
+// Probe model, a remote agent that checks host services from its own location
+type Probe struct {
+	ID         int
+	Name       string
+	Location   string
+	Active     int
+	LastSeenAt time.Time
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+// ProbeResult model, the latest result of a probe for a host service
+type ProbeResult struct {
+	HostServiceID int
+	ProbeID       int
+	ProbeName     string
+	Location      string
+	Status        string
+	Message       string
+	CheckedAt     time.Time
+}
+
+// ProbeAssignment is a host service a probe checks, with the URL of its host
+type ProbeAssignment struct {
+	HostService HostServices `json:"host_service"`
+	URL         string       `json:"url"`
+	// Settings are the settings of the check, such as the plugin of an exec check or the
+	// steps of a transaction; null when none are set
+	Settings json.RawMessage `json:"settings"`
+}
+
+// ProbeCheckResult is the result of a check posted by a probe
+type ProbeCheckResult struct {
+	HostServiceID int           `json:"host_service_id"`
+	Status        string        `json:"status"`
+	Message       string        `json:"message"`
+	ComputeTimes  *ComputeTimes `json:"compute_times"`
+	Perfdata      []Perfdata    `json:"perfdata"`
+	Steps         []StepTimes   `json:"steps"`
+}
+
+// Heartbeat signals a job can send
+const (
+	HeartbeatStart   = "start"
+	HeartbeatSuccess = "success"
+	HeartbeatFail    = "fail"
+)
+
+// Heartbeat model, the ping URL and expected period of a host service whose job reports in
+// instead of being checked
+type Heartbeat struct {
+	HostServiceID int
+	Token         string
+	PeriodSeconds int
+	GraceSeconds  int
+	LastSignal    string
+	LastSignalAt  time.Time
+	LastSuccessAt time.Time
+	LastStartAt   time.Time
+	LastLog       string
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+// HostAgent model, the token and latest metrics of the agent reporting the system of a host
+type HostAgent struct {
+	HostServiceID   int
+	Token           string
+	IntervalSeconds int
+	LastSeenAt      time.Time
+	LastMetrics     HostMetrics
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+}
+
+// HostMetrics are the system metrics a host agent reads at one time; they are stored in Elastic
+// as they are
+type HostMetrics struct {
+	ID               string      `json:"id"`
+	HostID           int         `json:"host_id"`
+	HostName         string      `json:"host_name"`
+	HostServiceID    int         `json:"host_service_id"`
+	CPUs             int         `json:"cpus"`
+	CPUPercent       float64     `json:"cpu_percent"`
+	MemoryTotalBytes uint64      `json:"memory_total_bytes"`
+	MemoryUsedBytes  uint64      `json:"memory_used_bytes"`
+	MemoryPercent    float64     `json:"memory_percent"`
+	SwapTotalBytes   uint64      `json:"swap_total_bytes"`
+	SwapUsedBytes    uint64      `json:"swap_used_bytes"`
+	Load1            float64     `json:"load1"`
+	Load5            float64     `json:"load5"`
+	Load15           float64     `json:"load15"`
+	UptimeSeconds    float64     `json:"uptime_seconds"`
+	Disks            []DiskUsage `json:"disks"`
+	CollectedAt      time.Time   `json:"collected_at"`
+	CreatedAt        time.Time   `json:"created_at"`
+}
+
+// DiskUsage is the usage of one mounted file system
+type DiskUsage struct {
+	Path        string  `json:"path"`
+	TotalBytes  uint64  `json:"total_bytes"`
+	UsedBytes   uint64  `json:"used_bytes"`
+	UsedPercent float64 `json:"used_percent"`
+}
+
+// MetricThreshold model, the values of a system metric at which a host becomes a warning or a
+// problem
+type MetricThreshold struct {
+	ID            int     `json:"id"`
+	HostServiceID int     `json:"host_service_id"`
+	Metric        string  `json:"metric"`
+	Warning       float64 `json:"warning"`
+	Problem       float64 `json:"problem"`
+}
+
+// ExecCheck model, the plugin an exec host service runs, with its arguments and timeout
+type ExecCheck struct {
+	HostServiceID  int       `json:"host_service_id"`
+	Command        string    `json:"command"`
+	Arguments      []string  `json:"arguments"`
+	TimeoutSeconds int       `json:"timeout_seconds"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// Perfdata is one value of the performance data of a Nagios plugin; the thresholds are kept as
+// the plugin wrote them
+type Perfdata struct {
+	Label    string   `json:"label"`
+	Value    float64  `json:"value"`
+	UOM      string   `json:"uom"`
+	Warning  string   `json:"warning"`
+	Critical string   `json:"critical"`
+	Min      *float64 `json:"min,omitempty"`
+	Max      *float64 `json:"max,omitempty"`
+}
+
+// CheckPerfdata is the performance data of one check, stored in Elastic
+type CheckPerfdata struct {
+	ID            string     `json:"id"`
+	Location      string     `json:"location"`
+	HostID        int        `json:"host_id"`
+	HostName      string     `json:"host_name"`
+	HostServiceID int        `json:"host_service_id"`
+	Perfdata      []Perfdata `json:"perfdata"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// Transaction model, the HTTP steps a transaction host service runs in order, sharing cookies
+// and the variables extracted from earlier steps
+type Transaction struct {
+	HostServiceID  int               `json:"host_service_id"`
+	Steps          []TransactionStep `json:"steps"`
+	TimeoutSeconds int               `json:"timeout_seconds"`
+	CreatedAt      time.Time         `json:"created_at"`
+	UpdatedAt      time.Time         `json:"updated_at"`
+}
+
+// TransactionStep is one request of a transaction; {{name}} in its URL, headers and body is
+// replaced with an extracted variable, and a URL starting with / is relative to the host URL
+type TransactionStep struct {
+	Name       string                 `json:"name"`
+	Method     string                 `json:"method"`
+	URL        string                 `json:"url"`
+	Headers    map[string]string      `json:"headers"`
+	Body       string                 `json:"body"`
+	Extract    []TransactionExtract   `json:"extract"`
+	Assertions []TransactionAssertion `json:"assertions"`
+}
+
+// TransactionExtract takes a variable from a response: From is json (a JSONPath), regex (the
+// first group, or the whole match) or header (a header name)
+type TransactionExtract struct {
+	Name       string `json:"name"`
+	From       string `json:"from"`
+	Expression string `json:"expression"`
+}
+
+// TransactionAssertion is a condition on a response: Type is status, body_contains,
+// body_regex, header (Target contains Value), json (the value at the JSONPath Target is Value)
+// or max_time_ms
+type TransactionAssertion struct {
+	Type   string `json:"type"`
+	Target string `json:"target"`
+	Value  string `json:"value"`
+}
+
+// StepTimes are the timings of one step of a transaction; the ComputeTimes fields are stored
+// in Elastic at the top level, next to the step
+type StepTimes struct {
+	ComputeTimes
+	Step     int    `json:"Step"`
+	StepName string `json:"StepName"`
+	Passed   bool   `json:"Passed"`
+	Error    string `json:"Error"`
+}
+
+// DatabaseCheck model, how a PostgreSQL, MySQL or Redis host service is connected to and the
+// query or command it runs
+type DatabaseCheck struct {
+	HostServiceID int `json:"host_service_id"`
+	// Address is host:port; without it the host of the URL and the default port are used
+	Address  string `json:"address"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+	// Database is the database name, or the database number for Redis
+	Database string `json:"database"`
+	// Query is the probe query or command, SELECT 1 or PING by default
+	Query string `json:"query"`
+	// TLSMode is disable, require (without verifying the certificate) or verify
+	TLSMode        string `json:"tls_mode"`
+	TimeoutSeconds int    `json:"timeout_seconds"`
+	// LagWarningSeconds and LagProblemSeconds are thresholds on the replication lag of a
+	// PostgreSQL standby; 0 leaves them off
+	LagWarningSeconds float64   `json:"lag_warning_seconds"`
+	LagProblemSeconds float64   `json:"lag_problem_seconds"`
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+// GRPCCheck model, how a gRPC host service is connected to and the service whose health it asks
+type GRPCCheck struct {
+	HostServiceID int `json:"host_service_id"`
+	// Address is host:port; without it the host and port of the URL are used
+	Address string `json:"address"`
+	// ServiceName is the service asked about, empty for the whole server
+	ServiceName string `json:"service_name"`
+	// TLSMode is disable, require (without verifying the certificate) or verify
+	TLSMode string `json:"tls_mode"`
+	// ServerName is the name the certificate is verified against, the host by default
+	ServerName string `json:"server_name"`
+	// CACert replaces the system roots; ClientCert and ClientKey are for mutual TLS, all PEM
+	CACert         string    `json:"ca_cert"`
+	ClientCert     string    `json:"client_cert"`
+	ClientKey      string    `json:"client_key"`
+	TimeoutSeconds int       `json:"timeout_seconds"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// WebSocketCheck model, the endpoint of a WebSocket host service and the message it exchanges
+type WebSocketCheck struct {
+	HostServiceID int `json:"host_service_id"`
+	// URL is a ws:// or wss:// URL or a path on the host; without it the host URL is used
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers"`
+	// Subprotocol is asked for in the handshake and must be accepted
+	Subprotocol string `json:"subprotocol"`
+	// Send is a text message sent after the handshake, and Expect a regular expression a
+	// reply must match; without them only the handshake is checked
+	Send           string    `json:"send"`
+	Expect         string    `json:"expect"`
+	TimeoutSeconds int       `json:"timeout_seconds"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// CheckSettings model, the settings of a check kept as JSON, for checks without a table of
+// their own
+type CheckSettings struct {
+	HostServiceID int             `json:"host_service_id"`
+	Settings      json.RawMessage `json:"settings"`
+	CreatedAt     time.Time       `json:"created_at"`
+	UpdatedAt     time.Time       `json:"updated_at"`
+}
+
+// NTPCheck is the settings of an NTP check
+type NTPCheck struct {
+	// Address is host:port; without it the host of the URL and port 123 are used
+	Address string `json:"address"`
+	// OffsetWarningMS and OffsetProblemMS are thresholds on the clock offset in either
+	// direction, StratumWarning and StratumProblem on the stratum; 0 leaves them off
+	OffsetWarningMS float64 `json:"offset_warning_ms"`
+	OffsetProblemMS float64 `json:"offset_problem_ms"`
+	StratumWarning  int     `json:"stratum_warning"`
+	StratumProblem  int     `json:"stratum_problem"`
+	TimeoutSeconds  int     `json:"timeout_seconds"`
+}
+
+// SNMPCheck is the settings of an SNMP GET check
+type SNMPCheck struct {
+	// Address is host:port; without it the host of the URL and port 161 are used
+	Address string `json:"address"`
+	// Version is 2c or 3
+	Version   string `json:"version"`
+	Community string `json:"community"`
+	// The SNMPv3 user based security settings
+	Username       string `json:"username"`
+	SecurityLevel  string `json:"security_level"`
+	AuthProtocol   string `json:"auth_protocol"`
+	AuthPassphrase string `json:"auth_passphrase"`
+	PrivProtocol   string `json:"priv_protocol"`
+	PrivPassphrase string `json:"priv_passphrase"`
+	ContextName    string `json:"context_name"`
+	// OIDs are the values to get and their thresholds
+	OIDs           []SNMPOID `json:"oids"`
+	TimeoutSeconds int       `json:"timeout_seconds"`
+}
+
+// SNMPOID is a value an SNMP check gets, and the thresholds it is held to
+type SNMPOID struct {
+	OID string `json:"oid"`
+	// Label names the value in messages and perfdata, the OID by default
+	Label string `json:"label"`
+	// Warning and Problem are thresholds on a numeric value, reached from below, or from above
+	// with Below
+	Warning *float64 `json:"warning,omitempty"`
+	Problem *float64 `json:"problem,omitempty"`
+	Below   bool     `json:"below"`
+	// Expect is a value a string value must equal, a problem otherwise
+	Expect string `json:"expect"`
+}
+
+// UDPCheck is the settings of a generic UDP request/response check
+type UDPCheck struct {
+	// Address is host:port; without a host the host of the URL is used
+	Address string `json:"address"`
+	// Send is the request, as text or, with Hex, as hexadecimal bytes
+	Send string `json:"send"`
+	Hex  bool   `json:"hex"`
+	// Expect is a regular expression the reply must match; with Hex the reply is matched as
+	// hexadecimal text
+	Expect         string `json:"expect"`
+	TimeoutSeconds int    `json:"timeout_seconds"`
+}
+
+// ContactMethod model, a way of reaching a user (email address, phone number or chat id)
+type ContactMethod struct {
+	ID         int
+	UserID     int
+	MethodType string
+	Address    string
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+// Subscription model. A zero HostID or empty Tag matches any host, and Severity is the
+// lowest status ("warning" or "problem") the user wants to hear about; empty means all.
+type Subscription struct {
+	ID        int
+	UserID    int
+	HostID    int
+	Tag       string
+	Severity  string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// EmailTemplate model. Templates saved in the database override the defaults in views/email.
+type EmailTemplate struct {
+	ID         int
+	Name       string
+	Subject    string
+	Body       string
+	Customised bool
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+// Mail outbox statuses
+const (
+	MailPending = "pending"
+	MailSending = "sending"
+	MailSent    = "sent"
+	MailDead    = "dead"
+)
+
+// OutboxMail is an email waiting in, or sent from, the mail outbox
+type OutboxMail struct {
+	ID            int                  `json:"id"`
+	Message       channeldata.MailData `json:"message"`
+	Status        string               `json:"status"`
+	Attempts      int                  `json:"attempts"`
+	NextAttemptAt time.Time            `json:"next_attempt_at"`
+	LastError     string               `json:"last_error"`
+	CreatedAt     time.Time            `json:"created_at"`
+	UpdatedAt     time.Time            `json:"updated_at"`
+}
+
+// MailQueueStats counts the mail outbox by status
+type MailQueueStats struct {
+	Pending  int `json:"pending"`
+	Sending  int `json:"sending"`
+	Sent     int `json:"sent"`
+	Dead     int `json:"dead"`
+	Retrying int `json:"retrying"`
+}
+
+// Check job statuses
+const (
+	CheckQueued  = "queued"
+	CheckRunning = "running"
+	CheckDone    = "done"
+	CheckFailed  = "failed"
+)
+
+// CheckJob is a due check of a host service in the check queue, run by whichever worker
+// claims it
+type CheckJob struct {
+	ID            int       `json:"id"`
+	HostServiceID int       `json:"host_service_id"`
+	HostID        int       `json:"host_id"`
+	Status        string    `json:"status"`
+	Attempts      int       `json:"attempts"`
+	LockedBy      string    `json:"locked_by"`
+	LockedUntil   time.Time `json:"locked_until"`
+	LastError     string    `json:"last_error"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// CheckQueueStats counts the check queue by status
+type CheckQueueStats struct {
+	Queued  int `json:"queued"`
+	Running int `json:"running"`
+	Done    int `json:"done"`
+	Failed  int `json:"failed"`
+	// Expired counts running checks whose worker let the lease run out
+	Expired int `json:"expired"`
+	// OldestQueuedSeconds is how long the oldest queued check has been waiting
+	OldestQueuedSeconds int `json:"oldest_queued_seconds"`
+	// Workers are the workers holding a lease on a check right now
+	Workers []string `json:"workers"`
+}
+
+// NotificationLog model
+type NotificationLog struct {
+	ID            int
+	Channel       string
+	Target        string
+	HostServiceID int
+	EventType     string
+	Status        string
+	Attempts      int
+	Error         string
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
 type HostJsonResponse struct {
 	OK      bool   `json:"ok"`
 	Message string `json:"message"`
@@ -162,15 +629,260 @@ type ToggleMonitoringRequest struct {
 }
 
 type HostPostRequest struct {
-	HostID        int    `json:"ID"`
-	HostName      string `json:"HostName"`
-	CanonicalName string `json:"CanonicalName"`
-	URL           string `json:"URL"`
-	IP            string `json:"IP"`
-	IPV6          string `json:"IPV6"`
-	Location      string `json:"Location"`
-	OS            string `json:"OS"`
-	Active        int    `json:"Active"`
+	HostID        int      `json:"ID"`
+	HostName      string   `json:"HostName"`
+	CanonicalName string   `json:"CanonicalName"`
+	URL           string   `json:"URL"`
+	IP            string   `json:"IP"`
+	IPV6          string   `json:"IPV6"`
+	Location      string   `json:"Location"`
+	OS            string   `json:"OS"`
+	Active        int      `json:"Active"`
+	Tags          []string `json:"Tags"`
+}
+
+type WebhookJsonResponse struct {
+	OK      bool    `json:"ok"`
+	Message string  `json:"message"`
+	Webhook Webhook `json:"webhook"`
+}
+
+type ProbesJsonResponse struct {
+	OK      bool    `json:"ok"`
+	Message string  `json:"message"`
+	Probes  []Probe `json:"probes"`
+}
+
+type ProbePostRequest struct {
+	Active int `json:"Active"`
+}
+
+type ProbeRegisterRequest struct {
+	Name     string `json:"name"`
+	Location string `json:"location"`
+	Key      string `json:"key"`
+}
+
+type ProbeRegisterResponse struct {
+	OK      bool   `json:"ok"`
+	Message string `json:"message"`
+	ProbeID int    `json:"probe_id"`
+	Token   string `json:"token"`
+}
+
+type ProbeAssignmentsResponse struct {
+	OK          bool              `json:"ok"`
+	Message     string            `json:"message"`
+	Assignments []ProbeAssignment `json:"assignments"`
+}
+
+type ProbeResultsRequest struct {
+	Results []ProbeCheckResult `json:"results"`
+}
+
+type HostServiceProbesRequest struct {
+	ProbeIDs []int `json:"probe_ids"`
+}
+
+type HostServiceProbesResponse struct {
+	OK      bool          `json:"ok"`
+	Message string        `json:"message"`
+	Probes  []Probe       `json:"probes"`
+	Results []ProbeResult `json:"results"`
+	Quorum  int           `json:"quorum"`
+}
+
+type HeartbeatPostRequest struct {
+	PeriodSeconds int  `json:"period_seconds"`
+	GraceSeconds  int  `json:"grace_seconds"`
+	NewToken      bool `json:"new_token"`
+}
+
+type HeartbeatResponse struct {
+	OK            bool      `json:"ok"`
+	Message       string    `json:"message"`
+	Heartbeat     Heartbeat `json:"heartbeat"`
+	PingURL       string    `json:"ping_url"`
+	Status        string    `json:"status"`
+	StatusMessage string    `json:"status_message"`
+}
+
+type HostMetricsRequest struct {
+	IntervalSeconds int         `json:"interval_seconds"`
+	Metrics         HostMetrics `json:"metrics"`
+}
+
+type HostAgentPostRequest struct {
+	Thresholds []MetricThreshold `json:"thresholds"`
+	NewToken   bool              `json:"new_token"`
+}
+
+type HostAgentResponse struct {
+	OK            bool              `json:"ok"`
+	Message       string            `json:"message"`
+	Agent         HostAgent         `json:"agent"`
+	Thresholds    []MetricThreshold `json:"thresholds"`
+	Status        string            `json:"status"`
+	StatusMessage string            `json:"status_message"`
+}
+
+type HostMetricsResponse struct {
+	OK      bool          `json:"ok"`
+	Message string        `json:"message"`
+	Metrics []HostMetrics `json:"metrics"`
+}
+
+type ExecCheckPostRequest struct {
+	Command        string   `json:"command"`
+	Arguments      []string `json:"arguments"`
+	TimeoutSeconds int      `json:"timeout_seconds"`
+}
+
+type ExecCheckResponse struct {
+	OK        bool      `json:"ok"`
+	Message   string    `json:"message"`
+	ExecCheck ExecCheck `json:"exec_check"`
+}
+
+type PerfdataResponse struct {
+	OK       bool            `json:"ok"`
+	Message  string          `json:"message"`
+	Perfdata []CheckPerfdata `json:"perfdata"`
+}
+
+type TransactionPostRequest struct {
+	Steps          []TransactionStep `json:"steps"`
+	TimeoutSeconds int               `json:"timeout_seconds"`
+}
+
+type TransactionResponse struct {
+	OK          bool        `json:"ok"`
+	Message     string      `json:"message"`
+	Transaction Transaction `json:"transaction"`
+}
+
+type StepTimesResponse struct {
+	OK      bool        `json:"ok"`
+	Message string      `json:"message"`
+	Steps   []StepTimes `json:"steps"`
+}
+
+type DatabaseCheckResponse struct {
+	OK            bool          `json:"ok"`
+	Message       string        `json:"message"`
+	DatabaseCheck DatabaseCheck `json:"database_check"`
+	PasswordSet   bool          `json:"password_set"`
+}
+
+type GRPCCheckResponse struct {
+	OK           bool      `json:"ok"`
+	Message      string    `json:"message"`
+	GRPCCheck    GRPCCheck `json:"grpc_check"`
+	ClientKeySet bool      `json:"client_key_set"`
+}
+
+type CheckSettingsResponse struct {
+	OK       bool            `json:"ok"`
+	Message  string          `json:"message"`
+	Settings json.RawMessage `json:"settings"`
+	// SecretsSet are the secret settings that are set but not shown
+	SecretsSet []string `json:"secrets_set"`
+}
+
+type WebSocketCheckResponse struct {
+	OK             bool           `json:"ok"`
+	Message        string         `json:"message"`
+	WebSocketCheck WebSocketCheck `json:"websocket_check"`
+	// HeadersSet names the headers with a value; the values are not shown
+	HeadersSet []string `json:"headers_set"`
+}
+
+type WebhooksJsonResponse struct {
+	OK       bool      `json:"ok"`
+	Message  string    `json:"message"`
+	Webhooks []Webhook `json:"webhooks"`
+}
+
+type WebhookPostRequest struct {
+	Name            string `json:"Name"`
+	URL             string `json:"URL"`
+	Secret          string `json:"Secret"`
+	PayloadTemplate string `json:"PayloadTemplate"`
+	Active          int    `json:"Active"`
+}
+
+type MailQueueResponse struct {
+	OK          bool           `json:"ok"`
+	Message     string         `json:"message"`
+	Stats       MailQueueStats `json:"stats"`
+	Failing     []OutboxMail   `json:"failing"`
+	DeadLetters []OutboxMail   `json:"dead_letters"`
+}
+
+type CheckQueueResponse struct {
+	OK      bool            `json:"ok"`
+	Message string          `json:"message"`
+	Mode    string          `json:"mode"`
+	Worker  string          `json:"worker"`
+	Stats   CheckQueueStats `json:"stats"`
+	Failed  []CheckJob      `json:"failed"`
+}
+
+type NotificationLogResponse struct {
+	OK      bool              `json:"ok"`
+	Message string            `json:"message"`
+	Entries []NotificationLog `json:"entries"`
+}
+
+type UserNotificationsResponse struct {
+	OK              bool            `json:"ok"`
+	Message         string          `json:"message"`
+	TimeZone        string          `json:"time_zone"`
+	QuietHoursStart string          `json:"quiet_hours_start"`
+	QuietHoursEnd   string          `json:"quiet_hours_end"`
+	ContactMethods  []ContactMethod `json:"contact_methods"`
+	Subscriptions   []Subscription  `json:"subscriptions"`
+}
+
+type QuietHoursRequest struct {
+	TimeZone        string `json:"time_zone"`
+	QuietHoursStart string `json:"quiet_hours_start"`
+	QuietHoursEnd   string `json:"quiet_hours_end"`
+}
+
+type ContactMethodRequest struct {
+	MethodType string `json:"method_type"`
+	Address    string `json:"address"`
+}
+
+type SubscriptionRequest struct {
+	HostID   int    `json:"host_id"`
+	Tag      string `json:"tag"`
+	Severity string `json:"severity"`
+}
+
+type EmailTemplateResponse struct {
+	OK       bool          `json:"ok"`
+	Message  string        `json:"message"`
+	Template EmailTemplate `json:"template"`
+}
+
+type EmailTemplatesResponse struct {
+	OK        bool            `json:"ok"`
+	Message   string          `json:"message"`
+	Templates []EmailTemplate `json:"templates"`
+}
+
+type EmailTemplateRequest struct {
+	Subject string `json:"subject"`
+	Body    string `json:"body"`
+}
+
+type EmailPreviewResponse struct {
+	OK      bool   `json:"ok"`
+	Message string `json:"message"`
+	Subject string `json:"subject"`
+	Body    string `json:"body"`
 }
 
 type ToggleServiceRequest struct {
@@ -186,11 +898,28 @@ type ScheduleResponse struct {
 	LastRunFromHS time.Time
 	HostServiceID int
 	ScheduleText  string
+	// NextRuns are the upcoming checks, human-readable
+	NextRuns []string
 
 	EntryID cron.EntryID
 	Entry   cron.Entry
 }
 
+type ScheduleRequest struct {
+	SchedulerNumber int    `json:"scheduler_number"`
+	SchedulerUnit   string `json:"scheduler_unit"`
+	CronExpression  string `json:"cron_expression"`
+	TimeZone        string `json:"time_zone"`
+	ActiveHours     string `json:"active_hours"`
+}
+
+type HostServiceScheduleResponse struct {
+	OK           bool     `json:"ok"`
+	Message      string   `json:"message"`
+	ScheduleText string   `json:"schedule_text"`
+	NextRuns     []string `json:"next_runs"`
+}
+
 type ListEntriesResponse struct {
 	OK      bool               `json:"ok"`
 	Message string             `json:"message"`
@@ -232,6 +961,16 @@ type Session struct {
 	CreatedAt    pgtype.Timestamptz `json:"created_at"`
 }
 
+// ServicePerformance summarises the response times of a host service over a period
+type ServicePerformance struct {
+	HostServiceID int           `json:"host_service_id"`
+	HostName      string        `json:"host_name"`
+	ServiceName   string        `json:"service_name"`
+	Checks        int           `json:"checks"`
+	AvgTotalTime  time.Duration `json:"avg_total_time"`
+	MaxTotalTime  time.Duration `json:"max_total_time"`
+}
+
 type ComputeTimes struct {
 	ID             string        `json:"id"`
 	DNSDone        time.Duration `json:"DNSDone"`
@@ -240,8 +979,10 @@ type ComputeTimes struct {
 	FirstByte      time.Duration `json:"FirstByte"`
 	TotalTime      time.Duration `json:"TotalTime"`
 	ResponseStatus int           `json:"ResponseStatus"`
-	Host           Host          `json:"Host"`
-	HostServices   HostServices  `json:"HostServices"`
-	CreatedAt      time.Time     `json:"CreatedAt"`
-	UpdatedAt      time.Time     `json:"UpdatedAt"`
+	// Location the check was made from: the identifier of the observer or the probe location
+	Location     string       `json:"Location"`
+	Host         Host         `json:"Host"`
+	HostServices HostServices `json:"HostServices"`
+	CreatedAt    time.Time    `json:"CreatedAt"`
+	UpdatedAt    time.Time    `json:"UpdatedAt"`
 }
@@ -0,0 +1,36 @@
+package notifiers
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SendDiscord posts a status change to a Discord webhook as an embed
+func SendDiscord(webhookURL string, sc StatusChange) error {
+	// Discord wants the embed color as a decimal number
+	color, _ := strconv.ParseInt(strings.TrimPrefix(statusColor(sc.NewStatus), "#"), 16, 32)
+
+	embed := map[string]interface{}{
+		"title":       sc.Title(),
+		"description": sc.Message,
+		"color":       color,
+		"fields": []map[string]interface{}{
+			{"name": "Host", "value": sc.HostName, "inline": true},
+			{"name": "Service", "value": sc.ServiceName, "inline": true},
+			{"name": "Previous status", "value": sc.OldStatus, "inline": true},
+		},
+		"timestamp": sc.CheckedAt.Format(time.RFC3339),
+	}
+
+	if sc.Link != "" {
+		embed["url"] = sc.Link
+	}
+
+	payload := map[string]interface{}{
+		"username": "Observer",
+		"embeds":   []map[string]interface{}{embed},
+	}
+
+	return postJSON(webhookURL, payload)
+}
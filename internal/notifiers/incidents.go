@@ -0,0 +1,28 @@
+package notifiers
+
+import "fmt"
+
+// Incident actions understood by the paging integrations
+const (
+	IncidentTrigger     = "trigger"
+	IncidentAcknowledge = "acknowledge"
+	IncidentResolve     = "resolve"
+)
+
+// DedupKey returns the key that ties every incident action for a host service together
+func DedupKey(hostServiceID int) string {
+	return fmt.Sprintf("observer-host-service-%d", hostServiceID)
+}
+
+// IncidentAction returns the paging action for a status transition, or an empty
+// string when the transition should not touch the paging tools
+func IncidentAction(oldStatus, newStatus string) string {
+	switch {
+	case newStatus == "problem":
+		return IncidentTrigger
+	case newStatus == "healthy" && (oldStatus == "problem" || oldStatus == "warning"):
+		return IncidentResolve
+	default:
+		return ""
+	}
+}
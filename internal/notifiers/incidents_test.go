@@ -0,0 +1,132 @@
+package notifiers
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestSendPagerDuty(t *testing.T) {
+	s := newStub(t)
+	sc := sampleChange()
+
+	for _, action := range []string{IncidentTrigger, IncidentAcknowledge, IncidentResolve} {
+		// a trailing slash on the base URL is fine
+		if err := SendPagerDuty(s.URL+"/", "routing-key", action, sc); err != nil {
+			t.Fatalf("%s: %s", action, err)
+		}
+	}
+
+	requests := s.received()
+	if len(requests) != 3 {
+		t.Fatalf("got %d requests, want 3", len(requests))
+	}
+
+	for i, action := range []string{IncidentTrigger, IncidentAcknowledge, IncidentResolve} {
+		req := requests[i]
+		if req.Method != http.MethodPost || req.Path != "/v2/enqueue" {
+			t.Errorf("%s: got %s %s, want POST /v2/enqueue", action, req.Method, req.Path)
+		}
+
+		event := decode(t, req.Body)
+		if got := event["event_action"]; got != action {
+			t.Errorf("event_action = %v, want %s", got, action)
+		}
+		if got := event["routing_key"]; got != "routing-key" {
+			t.Errorf("%s: routing_key = %v", action, got)
+		}
+		// every action of a host service shares one dedup key
+		if got := event["dedup_key"]; got != "observer-host-service-42" {
+			t.Errorf("%s: dedup_key = %v", action, got)
+		}
+
+		_, hasPayload := event["payload"]
+		if hasPayload != (action == IncidentTrigger) {
+			t.Errorf("%s: payload present = %v", action, hasPayload)
+		}
+	}
+
+	trigger := decode(t, requests[0].Body)
+	if got := path(t, trigger, "payload", "summary"); got != "PROBLEM: service HTTP on host web-1" {
+		t.Errorf("summary = %v", got)
+	}
+	if got := path(t, trigger, "payload", "severity"); got != "critical" {
+		t.Errorf("severity = %v", got)
+	}
+	if got := path(t, trigger, "links", 0, "href"); got != sampleChange().Link {
+		t.Errorf("link = %v", got)
+	}
+}
+
+func TestSendOpsgenie(t *testing.T) {
+	s := newStub(t)
+	sc := sampleChange()
+
+	for _, action := range []string{IncidentTrigger, IncidentAcknowledge, IncidentResolve} {
+		if err := SendOpsgenie(s.URL, "api-key", action, sc); err != nil {
+			t.Fatalf("%s: %s", action, err)
+		}
+	}
+
+	requests := s.received()
+	if len(requests) != 3 {
+		t.Fatalf("got %d requests, want 3", len(requests))
+	}
+
+	for _, req := range requests {
+		if got := req.Header.Get("Authorization"); got != "GenieKey api-key" {
+			t.Errorf("%s: Authorization = %q", req.Path, got)
+		}
+	}
+
+	trigger := requests[0]
+	if trigger.Path != "/v2/alerts" || trigger.Query != "" {
+		t.Errorf("trigger went to %s?%s, want /v2/alerts", trigger.Path, trigger.Query)
+	}
+
+	alert := decode(t, trigger.Body)
+	if got := alert["alias"]; got != "observer-host-service-42" {
+		t.Errorf("alias = %v", got)
+	}
+	if got := alert["priority"]; got != "P1" {
+		t.Errorf("priority = %v", got)
+	}
+	if got := path(t, alert, "details", "service"); got != "HTTP" {
+		t.Errorf("service detail = %v", got)
+	}
+
+	// acknowledge and close find the alert by its alias
+	for i, endpoint := range []string{"acknowledge", "close"} {
+		req := requests[i+1]
+		want := "/v2/alerts/observer-host-service-42/" + endpoint
+		if req.Path != want || req.Query != "identifierType=alias" {
+			t.Errorf("%s went to %s?%s, want %s?identifierType=alias", endpoint, req.Path, req.Query, want)
+		}
+		if got := decode(t, req.Body)["note"]; got != "connection refused" {
+			t.Errorf("%s note = %v", endpoint, got)
+		}
+	}
+
+	if err := SendOpsgenie(s.URL, "api-key", "snooze", sc); err == nil {
+		t.Error("an unknown action was accepted")
+	}
+}
+
+func TestIncidentAction(t *testing.T) {
+	tests := []struct {
+		old, new, want string
+	}{
+		{"healthy", "problem", IncidentTrigger},
+		{"warning", "problem", IncidentTrigger},
+		{"problem", "healthy", IncidentResolve},
+		{"warning", "healthy", IncidentResolve},
+		{"pending", "healthy", ""},
+		{"healthy", "warning", ""},
+		{"problem", "pending", ""},
+	}
+
+	for _, tt := range tests {
+		if got := IncidentAction(tt.old, tt.new); got != tt.want {
+			t.Errorf("IncidentAction(%q, %q) = %q, want %q", tt.old, tt.new, got, tt.want)
+		}
+	}
+}
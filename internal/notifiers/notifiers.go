@@ -0,0 +1,82 @@
+package notifiers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// StatusChange holds everything a notifier needs to describe a host service transition
+type StatusChange struct {
+	IncidentID    int       `json:"incident_id"`
+	HostID        int       `json:"host_id"`
+	HostServiceID int       `json:"host_service_id"`
+	ServiceID     int       `json:"service_id"`
+	HostName      string    `json:"host_name"`
+	ServiceName   string    `json:"service_name"`
+	OldStatus     string    `json:"old_status"`
+	NewStatus     string    `json:"new_status"`
+	Message       string    `json:"message"`
+	Link          string    `json:"link"`
+	CheckedAt     time.Time `json:"checked_at"`
+}
+
+// Title returns a one line summary of the status change
+func (sc StatusChange) Title() string {
+	return fmt.Sprintf("%s: service %s on host %s", strings.ToUpper(sc.NewStatus), sc.ServiceName, sc.HostName)
+}
+
+// client is shared by all notifiers so connections to the same endpoint are reused
+var client = &http.Client{Timeout: 10 * time.Second}
+
+// statusColor returns the hex color used for a status in chat messages
+func statusColor(status string) string {
+	switch status {
+	case "healthy":
+		return "#2eb886"
+	case "warning":
+		return "#daa038"
+	case "problem":
+		return "#a30200"
+	default:
+		return "#9e9e9e"
+	}
+}
+
+// postJSON posts payload as JSON to url and fails on any non 2xx response
+func postJSON(url string, payload interface{}) error {
+	return postJSONWithHeaders(url, nil, payload)
+}
+
+// postJSONWithHeaders is postJSON with extra request headers, e.g. for authentication
+func postJSONWithHeaders(url string, headers map[string]string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return fmt.Errorf("%s responded with %s", url, res.Status)
+	}
+
+	return nil
+}
@@ -0,0 +1,236 @@
+package notifiers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// stubRequest is a request received by a stub
+type stubRequest struct {
+	Method string
+	Path   string
+	Query  string
+	Header http.Header
+	Body   []byte
+}
+
+// stub is a local stand-in for a notification service, answering with the statuses given,
+// then 200
+type stub struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	requests []stubRequest
+	statuses []int
+}
+
+func newStub(t *testing.T, statuses ...int) *stub {
+	t.Helper()
+
+	s := &stub{statuses: statuses}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		s.mu.Lock()
+		s.requests = append(s.requests, stubRequest{
+			Method: r.Method,
+			Path:   r.URL.Path,
+			Query:  r.URL.RawQuery,
+			Header: r.Header.Clone(),
+			Body:   body,
+		})
+		status := http.StatusOK
+		if len(s.statuses) > 0 {
+			status, s.statuses = s.statuses[0], s.statuses[1:]
+		}
+		s.mu.Unlock()
+
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+// received returns the requests received so far
+func (s *stub) received() []stubRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]stubRequest(nil), s.requests...)
+}
+
+// only returns the one request received, failing the test otherwise
+func (s *stub) only(t *testing.T) stubRequest {
+	t.Helper()
+
+	requests := s.received()
+	if len(requests) != 1 {
+		t.Fatalf("got %d requests, want 1", len(requests))
+	}
+
+	return requests[0]
+}
+
+// decode decodes a JSON body into a generic value
+func decode(t *testing.T, body []byte) map[string]interface{} {
+	t.Helper()
+
+	var v map[string]interface{}
+	if err := json.Unmarshal(body, &v); err != nil {
+		t.Fatalf("body is not JSON: %s\n%s", err, body)
+	}
+
+	return v
+}
+
+// path follows keys and slice indexes through a decoded JSON value
+func path(t *testing.T, v interface{}, keys ...interface{}) interface{} {
+	t.Helper()
+
+	for _, key := range keys {
+		switch k := key.(type) {
+		case string:
+			m, ok := v.(map[string]interface{})
+			if !ok {
+				t.Fatalf("no object at %v", key)
+			}
+			v = m[k]
+		case int:
+			s, ok := v.([]interface{})
+			if !ok || k >= len(s) {
+				t.Fatalf("no element %d", k)
+			}
+			v = s[k]
+		}
+	}
+
+	return v
+}
+
+func sampleChange() StatusChange {
+	return StatusChange{
+		IncidentID:    7,
+		HostID:        3,
+		HostServiceID: 42,
+		ServiceID:     1,
+		HostName:      "web-1",
+		ServiceName:   "HTTP",
+		OldStatus:     "healthy",
+		NewStatus:     "problem",
+		Message:       "connection refused",
+		Link:          "https://observer.example.com/admin/host/3",
+		CheckedAt:     time.Date(2023, 12, 1, 10, 30, 0, 0, time.UTC),
+	}
+}
+
+func TestSendSlack(t *testing.T) {
+	s := newStub(t)
+
+	if err := SendSlack(s.URL, sampleChange()); err != nil {
+		t.Fatal(err)
+	}
+
+	req := s.only(t)
+	if req.Method != http.MethodPost || req.Header.Get("Content-Type") != "application/json" {
+		t.Errorf("got %s with %q, want a JSON POST", req.Method, req.Header.Get("Content-Type"))
+	}
+
+	payload := decode(t, req.Body)
+	if got := payload["text"]; got != "PROBLEM: service HTTP on host web-1" {
+		t.Errorf("text = %v", got)
+	}
+
+	attachment := path(t, payload, "attachments", 0)
+	if got := path(t, attachment, "color"); got != "#a30200" {
+		t.Errorf("color = %v, want the problem color", got)
+	}
+
+	headline := path(t, attachment, "blocks", 0, "text", "text").(string)
+	if !strings.Contains(headline, "<https://observer.example.com/admin/host/3|HTTP on web-1>") {
+		t.Errorf("headline %q does not link to the host", headline)
+	}
+	if got := path(t, attachment, "blocks", 1, "fields", 3, "text"); got != "*Previous status*\nhealthy" {
+		t.Errorf("previous status field = %v", got)
+	}
+	if got := path(t, attachment, "blocks", 2, "elements", 0, "text"); got != "connection refused" {
+		t.Errorf("message = %v", got)
+	}
+}
+
+func TestSendTeams(t *testing.T) {
+	s := newStub(t)
+
+	if err := SendTeams(s.URL, sampleChange()); err != nil {
+		t.Fatal(err)
+	}
+
+	payload := decode(t, s.only(t).Body)
+	if got := payload["type"]; got != "message" {
+		t.Errorf("type = %v, want message", got)
+	}
+
+	attachment := path(t, payload, "attachments", 0)
+	if got := path(t, attachment, "contentType"); got != "application/vnd.microsoft.card.adaptive" {
+		t.Errorf("contentType = %v", got)
+	}
+
+	card := path(t, attachment, "content")
+	if got := path(t, card, "type"); got != "AdaptiveCard" {
+		t.Errorf("card type = %v", got)
+	}
+	if got := path(t, card, "body", 0, "color"); got != "Attention" {
+		t.Errorf("title color = %v, want Attention", got)
+	}
+	if got := path(t, card, "body", 1, "facts", 0, "value"); got != "web-1" {
+		t.Errorf("host fact = %v", got)
+	}
+	if got := path(t, card, "body", 2, "text"); got != "connection refused" {
+		t.Errorf("message = %v", got)
+	}
+	if got := path(t, card, "actions", 0, "url"); got != "https://observer.example.com/admin/host/3" {
+		t.Errorf("action url = %v", got)
+	}
+}
+
+func TestSendDiscord(t *testing.T) {
+	s := newStub(t)
+
+	if err := SendDiscord(s.URL, sampleChange()); err != nil {
+		t.Fatal(err)
+	}
+
+	payload := decode(t, s.only(t).Body)
+	if got := payload["username"]; got != "Observer" {
+		t.Errorf("username = %v", got)
+	}
+
+	embed := path(t, payload, "embeds", 0)
+	// #a30200 as a decimal number
+	if got := path(t, embed, "color"); got != float64(0xa30200) {
+		t.Errorf("color = %v, want %d", got, 0xa30200)
+	}
+	if got := path(t, embed, "description"); got != "connection refused" {
+		t.Errorf("description = %v", got)
+	}
+	if got := path(t, embed, "timestamp"); got != "2023-12-01T10:30:00Z" {
+		t.Errorf("timestamp = %v", got)
+	}
+	if got := path(t, embed, "url"); got != "https://observer.example.com/admin/host/3" {
+		t.Errorf("url = %v", got)
+	}
+}
+
+func TestChatErrorStatus(t *testing.T) {
+	s := newStub(t, http.StatusBadRequest)
+
+	if err := SendSlack(s.URL, sampleChange()); err == nil {
+		t.Error("a 400 response was not reported as an error")
+	}
+}
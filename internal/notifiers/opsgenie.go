@@ -0,0 +1,54 @@
+package notifiers
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// OpsgenieAPIURL is the default base URL of the Opsgenie Alert API
+const OpsgenieAPIURL = "https://api.opsgenie.com"
+
+// SendOpsgenie sends an incident action for a status change to the Opsgenie Alert API.
+// The dedup key is used as the alert alias, so later actions find the same alert.
+func SendOpsgenie(baseURL, apiKey, action string, sc StatusChange) error {
+	if baseURL == "" {
+		baseURL = OpsgenieAPIURL
+	}
+	baseURL = strings.TrimSuffix(baseURL, "/") + "/v2/alerts"
+
+	alias := DedupKey(sc.HostServiceID)
+	headers := map[string]string{"Authorization": "GenieKey " + apiKey}
+
+	switch action {
+	case IncidentTrigger:
+		alert := map[string]interface{}{
+			"message":     sc.Title(),
+			"alias":       alias,
+			"description": sc.Message,
+			"entity":      sc.HostName,
+			"source":      "Observer",
+			"priority":    "P1",
+			"details": map[string]string{
+				"host":       sc.HostName,
+				"service":    sc.ServiceName,
+				"old_status": sc.OldStatus,
+				"new_status": sc.NewStatus,
+				"link":       sc.Link,
+			},
+		}
+		return postJSONWithHeaders(baseURL, headers, alert)
+	case IncidentAcknowledge, IncidentResolve:
+		endpoint := "acknowledge"
+		if action == IncidentResolve {
+			endpoint = "close"
+		}
+		actionURL := fmt.Sprintf("%s/%s/%s?identifierType=alias", baseURL, url.PathEscape(alias), endpoint)
+		return postJSONWithHeaders(actionURL, headers, map[string]string{
+			"source": "Observer",
+			"note":   sc.Message,
+		})
+	default:
+		return fmt.Errorf("unknown incident action %q", action)
+	}
+}
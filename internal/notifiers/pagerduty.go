@@ -0,0 +1,42 @@
+package notifiers
+
+import "strings"
+
+// PagerDutyAPIURL is the default base URL of the PagerDuty Events API v2
+const PagerDutyAPIURL = "https://events.pagerduty.com"
+
+// SendPagerDuty sends an incident action for a status change to the PagerDuty Events API v2
+func SendPagerDuty(baseURL, routingKey, action string, sc StatusChange) error {
+	if baseURL == "" {
+		baseURL = PagerDutyAPIURL
+	}
+
+	event := map[string]interface{}{
+		"routing_key":  routingKey,
+		"event_action": action,
+		"dedup_key":    DedupKey(sc.HostServiceID),
+	}
+
+	// only trigger events carry a payload, acknowledge and resolve just need the dedup key
+	if action == IncidentTrigger {
+		event["payload"] = map[string]interface{}{
+			"summary":   sc.Title(),
+			"source":    sc.HostName,
+			"severity":  "critical",
+			"component": sc.ServiceName,
+			"timestamp": sc.CheckedAt,
+			"custom_details": map[string]interface{}{
+				"message":    sc.Message,
+				"old_status": sc.OldStatus,
+				"new_status": sc.NewStatus,
+			},
+		}
+		if sc.Link != "" {
+			event["links"] = []map[string]interface{}{
+				{"href": sc.Link, "text": "View host in Observer"},
+			}
+		}
+	}
+
+	return postJSON(strings.TrimSuffix(baseURL, "/")+"/v2/enqueue", event)
+}
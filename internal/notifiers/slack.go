@@ -0,0 +1,46 @@
+package notifiers
+
+import "fmt"
+
+// SendSlack posts a status change to a Slack incoming webhook using Block Kit
+func SendSlack(webhookURL string, sc StatusChange) error {
+	headline := fmt.Sprintf("*%s* on *%s* is now *%s*", sc.ServiceName, sc.HostName, sc.NewStatus)
+	if sc.Link != "" {
+		headline = fmt.Sprintf("*<%s|%s on %s>* is now *%s*", sc.Link, sc.ServiceName, sc.HostName, sc.NewStatus)
+	}
+
+	blocks := []map[string]interface{}{
+		{
+			"type": "section",
+			"text": map[string]interface{}{"type": "mrkdwn", "text": headline},
+		},
+		{
+			"type": "section",
+			"fields": []map[string]interface{}{
+				{"type": "mrkdwn", "text": "*Host*\n" + sc.HostName},
+				{"type": "mrkdwn", "text": "*Service*\n" + sc.ServiceName},
+				{"type": "mrkdwn", "text": "*Status*\n" + sc.NewStatus},
+				{"type": "mrkdwn", "text": "*Previous status*\n" + sc.OldStatus},
+			},
+		},
+	}
+
+	if sc.Message != "" {
+		blocks = append(blocks, map[string]interface{}{
+			"type": "context",
+			"elements": []map[string]interface{}{
+				{"type": "mrkdwn", "text": sc.Message},
+			},
+		})
+	}
+
+	// blocks go inside an attachment so the message gets a status colored bar
+	payload := map[string]interface{}{
+		"text": sc.Title(),
+		"attachments": []map[string]interface{}{
+			{"color": statusColor(sc.NewStatus), "blocks": blocks},
+		},
+	}
+
+	return postJSON(webhookURL, payload)
+}
@@ -0,0 +1,69 @@
+package notifiers
+
+// SendTeams posts a status change to a Microsoft Teams incoming webhook as an Adaptive Card
+func SendTeams(webhookURL string, sc StatusChange) error {
+	body := []map[string]interface{}{
+		{
+			"type":   "TextBlock",
+			"text":   sc.Title(),
+			"weight": "Bolder",
+			"size":   "Medium",
+			"color":  teamsColor(sc.NewStatus),
+			"wrap":   true,
+		},
+		{
+			"type": "FactSet",
+			"facts": []map[string]interface{}{
+				{"title": "Host", "value": sc.HostName},
+				{"title": "Service", "value": sc.ServiceName},
+				{"title": "Status", "value": sc.NewStatus},
+				{"title": "Previous status", "value": sc.OldStatus},
+			},
+		},
+	}
+
+	if sc.Message != "" {
+		body = append(body, map[string]interface{}{
+			"type":     "TextBlock",
+			"text":     sc.Message,
+			"isSubtle": true,
+			"wrap":     true,
+		})
+	}
+
+	card := map[string]interface{}{
+		"$schema": "http://adaptivecards.io/schemas/adaptive-card.json",
+		"type":    "AdaptiveCard",
+		"version": "1.4",
+		"body":    body,
+	}
+
+	if sc.Link != "" {
+		card["actions"] = []map[string]interface{}{
+			{"type": "Action.OpenUrl", "title": "View host", "url": sc.Link},
+		}
+	}
+
+	payload := map[string]interface{}{
+		"type": "message",
+		"attachments": []map[string]interface{}{
+			{"contentType": "application/vnd.microsoft.card.adaptive", "content": card},
+		},
+	}
+
+	return postJSON(webhookURL, payload)
+}
+
+// teamsColor maps a status to one of the named Adaptive Card colors
+func teamsColor(status string) string {
+	switch status {
+	case "healthy":
+		return "Good"
+	case "warning":
+		return "Warning"
+	case "problem":
+		return "Attention"
+	default:
+		return "Default"
+	}
+}
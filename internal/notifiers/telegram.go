@@ -0,0 +1,66 @@
+package notifiers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"strings"
+)
+
+// TelegramAPIURL is the default base URL of the Telegram Bot API
+const TelegramAPIURL = "https://api.telegram.org"
+
+// SendTelegram sends a status change to a Telegram chat through the Bot API
+func SendTelegram(baseURL, botToken, chatID string, sc StatusChange) error {
+	if botToken == "" || chatID == "" {
+		return errors.New("telegram bot token and chat id are required")
+	}
+
+	if baseURL == "" {
+		baseURL = TelegramAPIURL
+	}
+
+	var text bytes.Buffer
+	text.WriteString("<b>" + html.EscapeString(sc.Title()) + "</b>\n")
+	text.WriteString(fmt.Sprintf("Previous status: %s\n", html.EscapeString(sc.OldStatus)))
+	if sc.Message != "" {
+		text.WriteString("<i>" + html.EscapeString(sc.Message) + "</i>\n")
+	}
+	if sc.Link != "" {
+		text.WriteString(fmt.Sprintf(`<a href="%s">View host</a>`, html.EscapeString(sc.Link)))
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"chat_id":                  chatID,
+		"text":                     text.String(),
+		"parse_mode":               "HTML",
+		"disable_web_page_preview": true,
+	})
+	if err != nil {
+		return err
+	}
+
+	res, err := client.Post(fmt.Sprintf("%s/bot%s/sendMessage", strings.TrimSuffix(baseURL, "/"), botToken),
+		"application/json", bytes.NewReader(body))
+	if err != nil {
+		// the request url contains the bot token, so don't let it leak into logs
+		return errors.New(strings.ReplaceAll(err.Error(), botToken, "<token>"))
+	}
+	defer res.Body.Close()
+
+	var data struct {
+		OK          bool   `json:"ok"`
+		Description string `json:"description"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&data); err != nil {
+		return fmt.Errorf("decoding telegram response (%s): %w", res.Status, err)
+	}
+
+	if !data.OK {
+		return fmt.Errorf("telegram rejected message: %s", data.Description)
+	}
+
+	return nil
+}
@@ -0,0 +1,127 @@
+package notifiers
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"golang-observer-project/internal/models"
+	"net/http"
+	"text/template"
+	"time"
+)
+
+const (
+	// SignatureHeader carries the hex encoded HMAC-SHA256 of the request body
+	SignatureHeader = "X-Observer-Signature"
+	// EventHeader carries the new status of the host service
+	EventHeader = "X-Observer-Event"
+)
+
+// WebhookMaxAttempts is the number of delivery attempts before a webhook is given up on
+const WebhookMaxAttempts = 5
+
+// WebhookRetryDelay is the delay before the first retry; it doubles after every failed attempt
+var WebhookRetryDelay = 2 * time.Second
+
+var templateFuncs = template.FuncMap{
+	// json renders a value as a JSON literal, so strings are quoted and escaped
+	"json": func(v interface{}) (string, error) {
+		out, err := json.Marshal(v)
+		return string(out), err
+	},
+}
+
+// RenderWebhookPayload builds the request body for a webhook. When the webhook has no
+// payload template, the status change is encoded as JSON.
+func RenderWebhookPayload(wh models.Webhook, sc StatusChange) ([]byte, error) {
+	if wh.PayloadTemplate == "" {
+		return json.Marshal(sc)
+	}
+
+	t, err := template.New("webhook").Funcs(templateFuncs).Parse(wh.PayloadTemplate)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, sc); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// SignPayload returns the hex encoded HMAC-SHA256 of body using secret
+func SignPayload(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// SendWebhook posts a status change to a webhook, retrying failed deliveries with
+// exponential backoff up to maxAttempts, or until ctx is done. It returns the number of
+// attempts made.
+func SendWebhook(ctx context.Context, wh models.Webhook, sc StatusChange, maxAttempts int) (int, error) {
+	body, err := RenderWebhookPayload(wh, sc)
+	if err != nil {
+		return 0, fmt.Errorf("rendering payload: %w", err)
+	}
+
+	delay := WebhookRetryDelay
+	attempts := 0
+
+	for {
+		attempts++
+
+		retry, err := postWebhook(ctx, wh, sc, body)
+		if err == nil {
+			return attempts, nil
+		}
+
+		if !retry || attempts >= maxAttempts {
+			return attempts, err
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return attempts, fmt.Errorf("%w; not retried: %w", err, ctx.Err())
+		}
+		delay *= 2
+	}
+}
+
+// postWebhook makes a single delivery attempt and reports whether a failure is worth retrying
+func postWebhook(ctx context.Context, wh models.Webhook, sc StatusChange, body []byte) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", wh.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Observer-Webhook")
+	req.Header.Set(EventHeader, sc.NewStatus)
+	if wh.Secret != "" {
+		req.Header.Set(SignatureHeader, "sha256="+SignPayload(wh.Secret, body))
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 200 && res.StatusCode < 300 {
+		return false, nil
+	}
+
+	// client errors won't fix themselves, except for rate limiting
+	retry := res.StatusCode >= 500 || res.StatusCode == http.StatusTooManyRequests
+	return retry, fmt.Errorf("webhook %s responded with %s", wh.Name, res.Status)
+}
@@ -0,0 +1,146 @@
+package notifiers
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"golang-observer-project/internal/models"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// fastRetries shortens the retry delay of webhooks for a test
+func fastRetries(t *testing.T) {
+	delay := WebhookRetryDelay
+	WebhookRetryDelay = 5 * time.Millisecond
+	t.Cleanup(func() { WebhookRetryDelay = delay })
+}
+
+func TestSendWebhookSignature(t *testing.T) {
+	s := newStub(t)
+	wh := models.Webhook{Name: "automation", URL: s.URL, Secret: "s3cret"}
+
+	attempts, err := SendWebhook(context.Background(), wh, sampleChange(), WebhookMaxAttempts)
+	if err != nil || attempts != 1 {
+		t.Fatalf("SendWebhook = %d, %v; want 1 attempt", attempts, err)
+	}
+
+	req := s.only(t)
+
+	mac := hmac.New(sha256.New, []byte("s3cret"))
+	mac.Write(req.Body)
+	want := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+
+	if got := req.Header.Get(SignatureHeader); got != want {
+		t.Errorf("%s = %q, want %q", SignatureHeader, got, want)
+	}
+	if got := req.Header.Get(EventHeader); got != "problem" {
+		t.Errorf("%s = %q, want problem", EventHeader, got)
+	}
+
+	// without a template, the body is the status change
+	if got := decode(t, req.Body)["host_service_id"]; got != float64(42) {
+		t.Errorf("host_service_id = %v", got)
+	}
+}
+
+func TestSendWebhookWithoutSecret(t *testing.T) {
+	s := newStub(t)
+	wh := models.Webhook{Name: "automation", URL: s.URL,
+		PayloadTemplate: `{"text": {{ json (printf "%s is %s" .ServiceName .NewStatus) }}}`}
+
+	if _, err := SendWebhook(context.Background(), wh, sampleChange(), 1); err != nil {
+		t.Fatal(err)
+	}
+
+	req := s.only(t)
+	if got := req.Header.Get(SignatureHeader); got != "" {
+		t.Errorf("unsigned webhook has %s %q", SignatureHeader, got)
+	}
+	if got := decode(t, req.Body)["text"]; got != "HTTP is problem" {
+		t.Errorf("templated text = %v", got)
+	}
+}
+
+func TestSendWebhookRetries(t *testing.T) {
+	fastRetries(t)
+
+	s := newStub(t, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusTooManyRequests)
+	wh := models.Webhook{Name: "automation", URL: s.URL, Secret: "s3cret"}
+
+	start := time.Now()
+	attempts, err := SendWebhook(context.Background(), wh, sampleChange(), WebhookMaxAttempts)
+	if err != nil || attempts != 4 {
+		t.Fatalf("SendWebhook = %d, %v; want success on attempt 4", attempts, err)
+	}
+
+	// the delay doubles: 5, 10 and 20 ms
+	if elapsed := time.Since(start); elapsed < 35*time.Millisecond {
+		t.Errorf("retries took %s, want at least 35ms of backoff", elapsed)
+	}
+
+	// every attempt carries the same signed body
+	requests := s.received()
+	for _, req := range requests[1:] {
+		if string(req.Body) != string(requests[0].Body) ||
+			req.Header.Get(SignatureHeader) != requests[0].Header.Get(SignatureHeader) {
+			t.Error("a retry sent a different body or signature")
+		}
+	}
+}
+
+func TestSendWebhookGivesUp(t *testing.T) {
+	fastRetries(t)
+
+	s := newStub(t, 500, 500, 500, 500, 500, 500)
+	wh := models.Webhook{Name: "automation", URL: s.URL}
+
+	attempts, err := SendWebhook(context.Background(), wh, sampleChange(), 3)
+	if err == nil || attempts != 3 {
+		t.Fatalf("SendWebhook = %d, %v; want an error after 3 attempts", attempts, err)
+	}
+	if got := len(s.received()); got != 3 {
+		t.Errorf("%d requests, want 3", got)
+	}
+}
+
+func TestSendWebhookClientError(t *testing.T) {
+	fastRetries(t)
+
+	s := newStub(t, http.StatusNotFound)
+	wh := models.Webhook{Name: "automation", URL: s.URL}
+
+	// a client error is not retried
+	attempts, err := SendWebhook(context.Background(), wh, sampleChange(), WebhookMaxAttempts)
+	if err == nil || attempts != 1 {
+		t.Fatalf("SendWebhook = %d, %v; want an error after 1 attempt", attempts, err)
+	}
+}
+
+func TestSendWebhookCancelled(t *testing.T) {
+	delay := WebhookRetryDelay
+	WebhookRetryDelay = time.Minute
+	t.Cleanup(func() { WebhookRetryDelay = delay })
+
+	s := newStub(t, http.StatusServiceUnavailable, http.StatusServiceUnavailable)
+	wh := models.Webhook{Name: "automation", URL: s.URL}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+
+	// cancelling stops the backoff rather than waiting for the next attempt
+	start := time.Now()
+	attempts, err := SendWebhook(ctx, wh, sampleChange(), WebhookMaxAttempts)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("SendWebhook = %d, %v; want it cancelled", attempts, err)
+	}
+	if attempts != 1 {
+		t.Errorf("%d attempts, want 1", attempts)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("cancelled delivery took %s", elapsed)
+	}
+}
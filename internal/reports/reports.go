@@ -0,0 +1,104 @@
+package reports
+
+import (
+	"golang-observer-project/internal/models"
+	"sort"
+	"time"
+)
+
+// HostUptime summarises the availability of one host over a report period
+type HostUptime struct {
+	HostID    int           `json:"host_id"`
+	HostName  string        `json:"host_name"`
+	Uptime    float64       `json:"uptime"`
+	Incidents int           `json:"incidents"`
+	Downtime  time.Duration `json:"downtime"`
+}
+
+// CertificateExpiry describes a certificate that is about to expire
+type CertificateExpiry struct {
+	HostName            string `json:"host_name"`
+	ExpirationDate      string `json:"expiration_date"`
+	DaysUntilExpiration int    `json:"days_until_expiration"`
+}
+
+// Digest is the periodic summary report sent to managers
+type Digest struct {
+	Period               string                      `json:"period"`
+	From                 time.Time                   `json:"from"`
+	To                   time.Time                   `json:"to"`
+	Hosts                []HostUptime                `json:"hosts"`
+	Incidents            int                         `json:"incidents"`
+	Downtime             time.Duration               `json:"downtime"`
+	SlowestServices      []models.ServicePerformance `json:"slowest_services"`
+	ExpiringCertificates []CertificateExpiry         `json:"expiring_certificates"`
+}
+
+// PeriodLength returns how far back a digest period reaches
+func PeriodLength(period string) time.Duration {
+	if period == "weekly" {
+		return 7 * 24 * time.Hour
+	}
+	return 24 * time.Hour
+}
+
+// Uptime computes uptime, incident count and downtime for every host from status change events.
+// A host service counts as down while it is in the problem state. initial holds the latest
+// event of each host service from before the period, events those within it, oldest first.
+func Uptime(hosts []models.Host, initial, events []models.Event, from, to time.Time) []HostUptime {
+	state := make(map[int]string)
+	for _, e := range initial {
+		state[e.HostServiceID] = e.EventType
+	}
+
+	byService := make(map[int][]models.Event)
+	for _, e := range events {
+		byService[e.HostServiceID] = append(byService[e.HostServiceID], e)
+	}
+
+	var result []HostUptime
+
+	for _, h := range hosts {
+		hu := HostUptime{HostID: h.ID, HostName: h.HostName}
+		services := 0
+
+		for _, hs := range h.HostServices {
+			if hs.Active != 1 {
+				continue
+			}
+			services++
+
+			current := state[hs.ID]
+			cursor := from
+			for _, e := range byService[hs.ID] {
+				if current == "problem" {
+					hu.Downtime += e.CreatedAt.Sub(cursor)
+				} else if e.EventType == "problem" {
+					hu.Incidents++
+				}
+				current = e.EventType
+				cursor = e.CreatedAt
+			}
+
+			if current == "problem" {
+				hu.Downtime += to.Sub(cursor)
+			}
+		}
+
+		if services == 0 {
+			continue
+		}
+
+		total := time.Duration(services) * to.Sub(from)
+		hu.Uptime = 100 * (1 - float64(hu.Downtime)/float64(total))
+		hu.Downtime = hu.Downtime.Round(time.Second)
+		result = append(result, hu)
+	}
+
+	// least available hosts first, that's what the reader is looking for
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Uptime < result[j].Uptime
+	})
+
+	return result
+}
@@ -0,0 +1,278 @@
+package dbrepo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"golang-observer-project/internal/models"
+	"log"
+	"time"
+)
+
+// checkMaxAttempts is how many times a check is claimed before a check whose worker keeps
+// losing its lease (e.g. dying mid-check) is given up
+const checkMaxAttempts = 3
+
+// Leases are compared with the database clock (NOW()) rather than the clock of each
+// process, so workers on different machines agree on when a lease has run out.
+
+// EnqueueCheck queues the check of a host service, unless it is already queued or running;
+// it returns whether the check was queued
+func (m *postgresDBRepo) EnqueueCheck(hostServiceID, hostID int) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `
+		INSERT INTO check_jobs (host_service_id, host_id, status, created_at, updated_at)
+		VALUES ($1, $2, $3, NOW(), NOW())
+		ON CONFLICT (host_service_id) WHERE status IN ('queued', 'running') DO NOTHING
+		RETURNING id`
+
+	var newID int
+	err := m.DB.QueryRowContext(ctx, query, hostServiceID, hostID, models.CheckQueued).Scan(&newID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		log.Println(err)
+		return false, err
+	}
+
+	return true, nil
+}
+
+// ClaimChecks leases up to limit queued checks to a worker for the lease duration, counts
+// the attempt and returns them. Checks whose lease ran out are claimed again, so the checks
+// of a worker that died are picked up by another one. Rows locked by another claimer are
+// skipped, so two workers never claim the same check.
+func (m *postgresDBRepo) ClaimChecks(workerID string, lease time.Duration, limit int) ([]models.CheckJob, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `
+		UPDATE check_jobs SET status = $1, attempts = attempts + 1, locked_by = $2,
+			locked_until = NOW() + $3 * interval '1 second', updated_at = NOW()
+		WHERE id IN (
+			SELECT id FROM check_jobs
+			WHERE status = $4 OR (status = $1 AND locked_until < NOW() AND attempts < $5)
+			ORDER BY created_at
+			LIMIT $6
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, host_service_id, host_id, status, attempts, locked_by, locked_until,
+			last_error, created_at, updated_at`
+
+	rows, err := m.DB.QueryContext(ctx, query, models.CheckRunning, workerID, lease.Seconds(),
+		models.CheckQueued, checkMaxAttempts, limit)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	defer func(rows *sql.Rows) {
+		_ = rows.Close()
+	}(rows)
+
+	return scanCheckJobs(rows)
+}
+
+// ExtendCheckLease renews the lease of a worker on a running check; it returns false when the
+// worker no longer holds the lease
+func (m *postgresDBRepo) ExtendCheckLease(id int, workerID string, lease time.Duration) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `
+		UPDATE check_jobs SET locked_until = NOW() + $1 * interval '1 second', updated_at = NOW()
+		WHERE id = $2 AND status = $3 AND locked_by = $4`
+
+	result, err := m.DB.ExecContext(ctx, query, lease.Seconds(), id, models.CheckRunning, workerID)
+	if err != nil {
+		log.Println(err)
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected > 0, nil
+}
+
+// FinishCheck records that a worker finished a check, as failed when lastError is set. A
+// worker that lost its lease to another one changes nothing.
+func (m *postgresDBRepo) FinishCheck(id int, workerID, lastError string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	status := models.CheckDone
+	if lastError != "" {
+		status = models.CheckFailed
+	}
+
+	query := `
+		UPDATE check_jobs SET status = $1, last_error = $2, updated_at = NOW()
+		WHERE id = $3 AND status = $4 AND locked_by = $5`
+
+	_, err := m.DB.ExecContext(ctx, query, status, lastError, id, models.CheckRunning, workerID)
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	return nil
+}
+
+// FailAbandonedChecks fails running checks whose lease ran out after the last attempt, so the
+// host service can be queued again; it returns how many were failed
+func (m *postgresDBRepo) FailAbandonedChecks() (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `
+		UPDATE check_jobs SET status = $1, last_error = 'lease expired after the last attempt',
+			updated_at = NOW()
+		WHERE status = $2 AND locked_until < NOW() AND attempts >= $3`
+
+	result, err := m.DB.ExecContext(ctx, query, models.CheckFailed, models.CheckRunning, checkMaxAttempts)
+	if err != nil {
+		log.Println(err)
+		return 0, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(affected), nil
+}
+
+// PurgeFinishedChecks deletes checks that were done or failed before the given time
+func (m *postgresDBRepo) PurgeFinishedChecks(before time.Time) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := m.DB.ExecContext(ctx, `DELETE FROM check_jobs WHERE status IN ($1, $2) AND updated_at < $3`,
+		models.CheckDone, models.CheckFailed, before)
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	return nil
+}
+
+// CheckQueueStats counts the check queue by status, and lists the workers holding a lease
+func (m *postgresDBRepo) CheckQueueStats() (models.CheckQueueStats, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var stats models.CheckQueueStats
+
+	query := `
+		SELECT
+			COUNT(*) FILTER (WHERE status = $1),
+			COUNT(*) FILTER (WHERE status = $2),
+			COUNT(*) FILTER (WHERE status = $3),
+			COUNT(*) FILTER (WHERE status = $4),
+			COUNT(*) FILTER (WHERE status = $2 AND locked_until < NOW()),
+			COALESCE(EXTRACT(EPOCH FROM NOW() - MIN(created_at) FILTER (WHERE status = $1)), 0)::integer
+		FROM check_jobs`
+
+	err := m.DB.QueryRowContext(ctx, query, models.CheckQueued, models.CheckRunning, models.CheckDone,
+		models.CheckFailed).Scan(&stats.Queued, &stats.Running, &stats.Done, &stats.Failed, &stats.Expired,
+		&stats.OldestQueuedSeconds)
+	if err != nil {
+		log.Println(err)
+		return stats, err
+	}
+
+	query = `
+		SELECT DISTINCT locked_by FROM check_jobs
+		WHERE status = $1 AND locked_until >= NOW()
+		ORDER BY locked_by`
+
+	rows, err := m.DB.QueryContext(ctx, query, models.CheckRunning)
+	if err != nil {
+		log.Println(err)
+		return stats, err
+	}
+
+	defer func(rows *sql.Rows) {
+		_ = rows.Close()
+	}(rows)
+
+	for rows.Next() {
+		var worker string
+		if err := rows.Scan(&worker); err != nil {
+			return stats, err
+		}
+		stats.Workers = append(stats.Workers, worker)
+	}
+
+	if err := rows.Err(); err != nil {
+		return stats, err
+	}
+
+	return stats, nil
+}
+
+// FailedChecks returns the most recently failed checks
+func (m *postgresDBRepo) FailedChecks(limit int) ([]models.CheckJob, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT id, host_service_id, host_id, status, attempts, locked_by, locked_until,
+			last_error, created_at, updated_at
+		FROM check_jobs
+		WHERE status = $1
+		ORDER BY updated_at DESC LIMIT $2`
+
+	rows, err := m.DB.QueryContext(ctx, query, models.CheckFailed, limit)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	defer func(rows *sql.Rows) {
+		_ = rows.Close()
+	}(rows)
+
+	return scanCheckJobs(rows)
+}
+
+// scanCheckJobs reads check_jobs rows
+func scanCheckJobs(rows *sql.Rows) ([]models.CheckJob, error) {
+	var jobs []models.CheckJob
+
+	for rows.Next() {
+		var cj models.CheckJob
+
+		err := rows.Scan(
+			&cj.ID,
+			&cj.HostServiceID,
+			&cj.HostID,
+			&cj.Status,
+			&cj.Attempts,
+			&cj.LockedBy,
+			&cj.LockedUntil,
+			&cj.LastError,
+			&cj.CreatedAt,
+			&cj.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		jobs = append(jobs, cj)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return jobs, nil
+}
@@ -0,0 +1,324 @@
+package dbrepo
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgconn"
+	_ "github.com/jackc/pgx/v4/stdlib"
+	"golang-observer-project/internal/models"
+	"net/url"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// The check queue tests need a Postgres database, e.g.
+//
+//	OBSERVER_TEST_DSN="host=localhost port=5432 dbname=observer_test user=postgres" go test ./internal/repository/dbrepo
+//
+// They run in a schema of their own, dropped afterwards, with the check_jobs migration applied
+// to a bare host_services table, so the database does not need the other migrations.
+const testDSNEnv = "OBSERVER_TEST_DSN"
+
+// queueSchema creates a schema holding the check queue and returns its DSN
+func queueSchema(t *testing.T) string {
+	t.Helper()
+
+	dsn := os.Getenv(testDSNEnv)
+	if dsn == "" {
+		t.Skipf("set %s to run the check queue against Postgres", testDSNEnv)
+	}
+
+	admin, err := sql.Open("pgx", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = admin.Close() })
+
+	schema := fmt.Sprintf("observer_test_%d", time.Now().UnixNano())
+	if _, err := admin.Exec(`CREATE SCHEMA ` + schema); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if _, err := admin.Exec(`DROP SCHEMA ` + schema + ` CASCADE`); err != nil {
+			t.Error(err)
+		}
+	})
+
+	schemaDSN := withSearchPath(dsn, schema)
+
+	db := openQueue(t, schemaDSN)
+
+	migration, err := os.ReadFile("../../../migrations/20231208_create_check_jobs_table.up.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	setup := []string{
+		`CREATE TABLE host_services (id serial PRIMARY KEY)`,
+		`INSERT INTO host_services (id) SELECT generate_series(1, 500)`,
+		`CREATE FUNCTION trigger_set_timestamp() RETURNS TRIGGER AS $$
+		BEGIN
+			NEW.updated_at = NOW();
+			RETURN NEW;
+		END;
+		$$ LANGUAGE plpgsql`,
+		string(migration),
+	}
+	for _, stmt := range setup {
+		if _, err := db.DB.Exec(stmt); err != nil {
+			t.Fatalf("setting up the schema: %s", err)
+		}
+	}
+
+	return schemaDSN
+}
+
+// withSearchPath adds a search_path to a URL or key/value DSN
+func withSearchPath(dsn, schema string) string {
+	if strings.Contains(dsn, "://") {
+		if u, err := url.Parse(dsn); err == nil {
+			q := u.Query()
+			q.Set("search_path", schema)
+			u.RawQuery = q.Encode()
+			return u.String()
+		}
+	}
+
+	return dsn + " search_path=" + schema
+}
+
+// openQueue opens a connection pool of its own, like a separate process would have
+func openQueue(t *testing.T, dsn string) *postgresDBRepo {
+	t.Helper()
+
+	db, err := sql.Open("pgx", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	if err := db.Ping(); err != nil {
+		t.Fatal(err)
+	}
+
+	return &postgresDBRepo{DB: db}
+}
+
+// claimerEnv makes the test binary run as a claimer process, see TestClaimerProcess
+const claimerEnv = "OBSERVER_TEST_CLAIMER"
+
+func TestClaimChecksNeverTwice(t *testing.T) {
+	dsn := queueSchema(t)
+
+	const jobs = 300
+	const processes = 4
+
+	queue := openQueue(t, dsn)
+	for id := 1; id <= jobs; id++ {
+		queued, err := queue.EnqueueCheck(id, id%10)
+		if err != nil || !queued {
+			t.Fatalf("EnqueueCheck(%d) = %v, %v", id, queued, err)
+		}
+	}
+
+	// every claimer is a process of its own, running this test binary
+	cmds := make([]*exec.Cmd, processes)
+	outputs := make([]*bytes.Buffer, processes)
+	for p := range cmds {
+		outputs[p] = &bytes.Buffer{}
+		cmds[p] = exec.Command(os.Args[0], "-test.run=^TestClaimerProcess$")
+		cmds[p].Env = append(os.Environ(), claimerEnv+"=claimer-"+strconv.Itoa(p), testDSNEnv+"="+dsn)
+		cmds[p].Stdout = outputs[p]
+		cmds[p].Stderr = os.Stderr
+		if err := cmds[p].Start(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	claimed := make(map[int][]string)
+	for p, cmd := range cmds {
+		if err := cmd.Wait(); err != nil {
+			t.Fatalf("claimer %d: %s", p, err)
+		}
+
+		for _, line := range strings.Split(outputs[p].String(), "\n") {
+			var id int
+			var workerID string
+			if _, err := fmt.Sscanf(line, "claimed %d by %s", &id, &workerID); err == nil {
+				claimed[id] = append(claimed[id], workerID)
+			}
+		}
+	}
+
+	if len(claimed) != jobs {
+		t.Errorf("%d checks claimed, want %d", len(claimed), jobs)
+	}
+	for id, workers := range claimed {
+		if len(workers) != 1 {
+			t.Errorf("check %d claimed by %v", id, workers)
+		}
+	}
+
+	stats, err := queue.CheckQueueStats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Done != jobs || stats.Queued != 0 || stats.Running != 0 {
+		t.Errorf("queue after all checks finished: %+v", stats)
+	}
+}
+
+// TestClaimerProcess is a claimer process started by TestClaimChecksNeverTwice: a few workers
+// sharing one connection pool claim and finish checks until the queue is empty, and print
+// what they claimed
+func TestClaimerProcess(t *testing.T) {
+	process := os.Getenv(claimerEnv)
+	if process == "" {
+		t.Skip("only run as a claimer process")
+	}
+
+	queue := openQueue(t, os.Getenv(testDSNEnv))
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func(workerID string) {
+			defer wg.Done()
+
+			for {
+				batch, err := queue.ClaimChecks(workerID, time.Minute, 3)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				if len(batch) == 0 {
+					return
+				}
+
+				for _, cj := range batch {
+					if cj.LockedBy != workerID || cj.Status != models.CheckRunning || cj.Attempts != 1 {
+						t.Errorf("claimed check %+v", cj)
+					}
+
+					mu.Lock()
+					fmt.Printf("claimed %d by %s\n", cj.ID, workerID)
+					mu.Unlock()
+
+					if err := queue.FinishCheck(cj.ID, workerID, ""); err != nil {
+						t.Error(err)
+					}
+				}
+			}
+		}(fmt.Sprintf("%s/worker-%d", process, w))
+	}
+
+	wg.Wait()
+}
+
+func TestClaimChecksExpiredLease(t *testing.T) {
+	dsn := queueSchema(t)
+
+	first := openQueue(t, dsn)
+	second := openQueue(t, dsn)
+
+	if _, err := first.EnqueueCheck(1, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	const lease = 500 * time.Millisecond
+
+	batch, err := first.ClaimChecks("first", lease, 10)
+	if err != nil || len(batch) != 1 {
+		t.Fatalf("first claim = %v, %v", batch, err)
+	}
+	id := batch[0].ID
+
+	// the lease is held, so there is nothing to claim
+	batch, err = second.ClaimChecks("second", lease, 10)
+	if err != nil || len(batch) != 0 {
+		t.Fatalf("claim during the lease = %v, %v", batch, err)
+	}
+
+	// the first worker dies; once its lease ran out the check is claimed again
+	time.Sleep(lease + 200*time.Millisecond)
+
+	batch, err = second.ClaimChecks("second", time.Minute, 10)
+	if err != nil || len(batch) != 1 {
+		t.Fatalf("claim after the lease = %v, %v", batch, err)
+	}
+	if cj := batch[0]; cj.ID != id || cj.LockedBy != "second" || cj.Attempts != 2 {
+		t.Errorf("reclaimed check %+v, want check %d by second on attempt 2", cj, id)
+	}
+
+	// the first worker coming back finds it lost the lease, and changes nothing
+	if held, err := first.ExtendCheckLease(id, "first", lease); err != nil || held {
+		t.Errorf("ExtendCheckLease by the old holder = %v, %v", held, err)
+	}
+	if err := first.FinishCheck(id, "first", "too late"); err != nil {
+		t.Fatal(err)
+	}
+
+	stats, err := first.CheckQueueStats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Running != 1 || stats.Failed != 0 {
+		t.Errorf("queue after the old holder finished: %+v", stats)
+	}
+
+	if held, err := second.ExtendCheckLease(id, "second", time.Minute); err != nil || !held {
+		t.Errorf("ExtendCheckLease by the new holder = %v, %v", held, err)
+	}
+	if err := second.FinishCheck(id, "second", ""); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestEnqueueCheckOnlyOncePending(t *testing.T) {
+	dsn := queueSchema(t)
+	queue := openQueue(t, dsn)
+
+	queued, err := queue.EnqueueCheck(7, 1)
+	if err != nil || !queued {
+		t.Fatalf("first EnqueueCheck = %v, %v", queued, err)
+	}
+
+	queued, err = queue.EnqueueCheck(7, 1)
+	if err != nil || queued {
+		t.Fatalf("EnqueueCheck of a queued check = %v, %v; want false", queued, err)
+	}
+
+	// the partial unique index rejects a second pending row written any other way
+	_, err = queue.DB.Exec(`INSERT INTO check_jobs (host_service_id, host_id, status) VALUES (7, 1, $1)`,
+		models.CheckQueued)
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) || pgErr.Code != "23505" {
+		t.Fatalf("duplicate pending insert = %v, want a unique violation", err)
+	}
+
+	// a running check still blocks a new one
+	batch, err := queue.ClaimChecks("worker", time.Minute, 1)
+	if err != nil || len(batch) != 1 {
+		t.Fatalf("claim = %v, %v", batch, err)
+	}
+	if queued, err = queue.EnqueueCheck(7, 1); err != nil || queued {
+		t.Fatalf("EnqueueCheck of a running check = %v, %v; want false", queued, err)
+	}
+
+	// once finished, the host service can be queued again
+	if err := queue.FinishCheck(batch[0].ID, "worker", ""); err != nil {
+		t.Fatal(err)
+	}
+	if queued, err = queue.EnqueueCheck(7, 1); err != nil || !queued {
+		t.Fatalf("EnqueueCheck after the check finished = %v, %v; want true", queued, err)
+	}
+}
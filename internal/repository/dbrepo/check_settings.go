@@ -0,0 +1,62 @@
+package dbrepo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"golang-observer-project/internal/models"
+	"log"
+	"time"
+)
+
+// CheckSettings returns the settings of the check of a host service
+func (m *postgresDBRepo) CheckSettings(hostServiceID int) (models.CheckSettings, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT host_service_id, settings, created_at, updated_at
+		FROM check_settings WHERE host_service_id = $1`
+
+	var cs models.CheckSettings
+	var settings []byte
+
+	err := m.DB.QueryRowContext(ctx, query, hostServiceID).Scan(
+		&cs.HostServiceID,
+		&settings,
+		&cs.CreatedAt,
+		&cs.UpdatedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return cs, models.ErrNoRecord
+	}
+	if err != nil {
+		log.Println(err)
+		return cs, err
+	}
+
+	cs.Settings = settings
+
+	return cs, nil
+}
+
+// UpsertCheckSettings saves the settings of the check of a host service
+func (m *postgresDBRepo) UpsertCheckSettings(cs models.CheckSettings) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `
+		INSERT INTO check_settings (host_service_id, settings, created_at, updated_at)
+		VALUES ($1, $2, $3, $3)
+		ON CONFLICT (host_service_id) DO UPDATE SET
+			settings = EXCLUDED.settings,
+			updated_at = EXCLUDED.updated_at`
+
+	_, err := m.DB.ExecContext(ctx, query, cs.HostServiceID, []byte(cs.Settings), time.Now())
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	return nil
+}
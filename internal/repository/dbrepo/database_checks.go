@@ -0,0 +1,88 @@
+package dbrepo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"golang-observer-project/internal/models"
+	"log"
+	"time"
+)
+
+// DatabaseCheck returns the connection and query of a database host service
+func (m *postgresDBRepo) DatabaseCheck(hostServiceID int) (models.DatabaseCheck, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT host_service_id, address, username, password, database_name, query, tls_mode,
+			timeout_seconds, lag_warning_seconds, lag_problem_seconds, created_at, updated_at
+		FROM database_checks WHERE host_service_id = $1`
+
+	var dc models.DatabaseCheck
+	err := m.DB.QueryRowContext(ctx, query, hostServiceID).Scan(
+		&dc.HostServiceID,
+		&dc.Address,
+		&dc.Username,
+		&dc.Password,
+		&dc.Database,
+		&dc.Query,
+		&dc.TLSMode,
+		&dc.TimeoutSeconds,
+		&dc.LagWarningSeconds,
+		&dc.LagProblemSeconds,
+		&dc.CreatedAt,
+		&dc.UpdatedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return dc, models.ErrNoRecord
+	}
+	if err != nil {
+		log.Println(err)
+		return dc, err
+	}
+
+	return dc, nil
+}
+
+// UpsertDatabaseCheck saves the connection and query of a database host service
+func (m *postgresDBRepo) UpsertDatabaseCheck(dc models.DatabaseCheck) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `
+		INSERT INTO database_checks (host_service_id, address, username, password, database_name, query,
+			tls_mode, timeout_seconds, lag_warning_seconds, lag_problem_seconds, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $11)
+		ON CONFLICT (host_service_id) DO UPDATE SET
+			address = EXCLUDED.address,
+			username = EXCLUDED.username,
+			password = EXCLUDED.password,
+			database_name = EXCLUDED.database_name,
+			query = EXCLUDED.query,
+			tls_mode = EXCLUDED.tls_mode,
+			timeout_seconds = EXCLUDED.timeout_seconds,
+			lag_warning_seconds = EXCLUDED.lag_warning_seconds,
+			lag_problem_seconds = EXCLUDED.lag_problem_seconds,
+			updated_at = EXCLUDED.updated_at`
+
+	_, err := m.DB.ExecContext(ctx, query,
+		dc.HostServiceID,
+		dc.Address,
+		dc.Username,
+		dc.Password,
+		dc.Database,
+		dc.Query,
+		dc.TLSMode,
+		dc.TimeoutSeconds,
+		dc.LagWarningSeconds,
+		dc.LagProblemSeconds,
+		time.Now(),
+	)
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,68 @@
+package dbrepo
+
+import (
+	"context"
+	"golang-observer-project/internal/models"
+	"log"
+	"time"
+)
+
+// GetEmailTemplate returns the customised email template with the given name
+func (m *postgresDBRepo) GetEmailTemplate(name string) (models.EmailTemplate, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT id, name, subject, body, created_at, updated_at
+		FROM email_templates WHERE name = $1`
+
+	var et models.EmailTemplate
+	err := m.DB.QueryRowContext(ctx, query, name).Scan(
+		&et.ID,
+		&et.Name,
+		&et.Subject,
+		&et.Body,
+		&et.CreatedAt,
+		&et.UpdatedAt,
+	)
+	if err != nil {
+		return et, err
+	}
+
+	et.Customised = true
+
+	return et, nil
+}
+
+// UpsertEmailTemplate saves a customised email template
+func (m *postgresDBRepo) UpsertEmailTemplate(et models.EmailTemplate) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `
+		INSERT INTO email_templates (name, subject, body, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (name) DO UPDATE SET subject = $2, body = $3, updated_at = $5`
+
+	_, err := m.DB.ExecContext(ctx, query, et.Name, et.Subject, et.Body, time.Now(), time.Now())
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	return nil
+}
+
+// DeleteEmailTemplate removes a customised email template, so the default applies again
+func (m *postgresDBRepo) DeleteEmailTemplate(name string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := m.DB.ExecContext(ctx, `DELETE FROM email_templates WHERE name = $1`, name)
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,79 @@
+package dbrepo
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"golang-observer-project/internal/models"
+	"log"
+	"time"
+)
+
+// ExecCheck returns the plugin an exec host service runs
+func (m *postgresDBRepo) ExecCheck(hostServiceID int) (models.ExecCheck, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT host_service_id, command, arguments, timeout_seconds, created_at, updated_at
+		FROM exec_checks WHERE host_service_id = $1`
+
+	var ec models.ExecCheck
+	var arguments []byte
+
+	err := m.DB.QueryRowContext(ctx, query, hostServiceID).Scan(
+		&ec.HostServiceID,
+		&ec.Command,
+		&arguments,
+		&ec.TimeoutSeconds,
+		&ec.CreatedAt,
+		&ec.UpdatedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ec, models.ErrNoRecord
+	}
+	if err != nil {
+		log.Println(err)
+		return ec, err
+	}
+
+	err = json.Unmarshal(arguments, &ec.Arguments)
+	if err != nil {
+		log.Printf("cannot decode arguments of host service %d: %s\n", hostServiceID, err)
+	}
+
+	return ec, nil
+}
+
+// UpsertExecCheck saves the plugin, arguments and timeout of an exec host service
+func (m *postgresDBRepo) UpsertExecCheck(ec models.ExecCheck) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	if ec.Arguments == nil {
+		ec.Arguments = []string{}
+	}
+
+	arguments, err := json.Marshal(ec.Arguments)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO exec_checks (host_service_id, command, arguments, timeout_seconds, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $5)
+		ON CONFLICT (host_service_id) DO UPDATE SET
+			command = EXCLUDED.command,
+			arguments = EXCLUDED.arguments,
+			timeout_seconds = EXCLUDED.timeout_seconds,
+			updated_at = EXCLUDED.updated_at`
+
+	_, err = m.DB.ExecContext(ctx, query, ec.HostServiceID, ec.Command, arguments, ec.TimeoutSeconds, time.Now())
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,85 @@
+package dbrepo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"golang-observer-project/internal/models"
+	"log"
+	"time"
+)
+
+// GRPCCheck returns the address and TLS settings of a gRPC host service
+func (m *postgresDBRepo) GRPCCheck(hostServiceID int) (models.GRPCCheck, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT host_service_id, address, service_name, tls_mode, server_name, ca_cert, client_cert,
+			client_key, timeout_seconds, created_at, updated_at
+		FROM grpc_checks WHERE host_service_id = $1`
+
+	var gc models.GRPCCheck
+	err := m.DB.QueryRowContext(ctx, query, hostServiceID).Scan(
+		&gc.HostServiceID,
+		&gc.Address,
+		&gc.ServiceName,
+		&gc.TLSMode,
+		&gc.ServerName,
+		&gc.CACert,
+		&gc.ClientCert,
+		&gc.ClientKey,
+		&gc.TimeoutSeconds,
+		&gc.CreatedAt,
+		&gc.UpdatedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return gc, models.ErrNoRecord
+	}
+	if err != nil {
+		log.Println(err)
+		return gc, err
+	}
+
+	return gc, nil
+}
+
+// UpsertGRPCCheck saves the address and TLS settings of a gRPC host service
+func (m *postgresDBRepo) UpsertGRPCCheck(gc models.GRPCCheck) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `
+		INSERT INTO grpc_checks (host_service_id, address, service_name, tls_mode, server_name, ca_cert,
+			client_cert, client_key, timeout_seconds, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $10)
+		ON CONFLICT (host_service_id) DO UPDATE SET
+			address = EXCLUDED.address,
+			service_name = EXCLUDED.service_name,
+			tls_mode = EXCLUDED.tls_mode,
+			server_name = EXCLUDED.server_name,
+			ca_cert = EXCLUDED.ca_cert,
+			client_cert = EXCLUDED.client_cert,
+			client_key = EXCLUDED.client_key,
+			timeout_seconds = EXCLUDED.timeout_seconds,
+			updated_at = EXCLUDED.updated_at`
+
+	_, err := m.DB.ExecContext(ctx, query,
+		gc.HostServiceID,
+		gc.Address,
+		gc.ServiceName,
+		gc.TLSMode,
+		gc.ServerName,
+		gc.CACert,
+		gc.ClientCert,
+		gc.ClientKey,
+		gc.TimeoutSeconds,
+		time.Now(),
+	)
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,119 @@
+package dbrepo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"golang-observer-project/internal/models"
+	"log"
+	"time"
+)
+
+// heartbeatColumns are the columns scanHeartbeat reads
+const heartbeatColumns = `host_service_id, token, period_seconds, grace_seconds, last_signal, last_signal_at,
+	last_success_at, last_start_at, last_log, created_at, updated_at`
+
+// EnsureHeartbeat returns the heartbeat of a host service, setting it up with the given token
+// and the default period and grace time when it has none yet
+func (m *postgresDBRepo) EnsureHeartbeat(hostServiceID int, token string) (models.Heartbeat, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := m.DB.ExecContext(ctx, `
+		INSERT INTO heartbeats (host_service_id, token, created_at, updated_at) VALUES ($1, $2, $3, $3)
+		ON CONFLICT (host_service_id) DO NOTHING`,
+		hostServiceID, token, time.Now())
+	if err != nil {
+		log.Println(err)
+		return models.Heartbeat{}, err
+	}
+
+	row := m.DB.QueryRowContext(ctx, `SELECT `+heartbeatColumns+` FROM heartbeats WHERE host_service_id = $1`,
+		hostServiceID)
+
+	return scanHeartbeat(row)
+}
+
+// HeartbeatByToken returns the heartbeat with a ping token
+func (m *postgresDBRepo) HeartbeatByToken(token string) (models.Heartbeat, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	row := m.DB.QueryRowContext(ctx, `SELECT `+heartbeatColumns+` FROM heartbeats WHERE token = $1`, token)
+
+	return scanHeartbeat(row)
+}
+
+// UpdateHeartbeat saves the token, period and grace time of a heartbeat
+func (m *postgresDBRepo) UpdateHeartbeat(hb models.Heartbeat) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `
+		UPDATE heartbeats SET token = $1, period_seconds = $2, grace_seconds = $3, updated_at = $4
+		WHERE host_service_id = $5`
+
+	_, err := m.DB.ExecContext(ctx, query, hb.Token, hb.PeriodSeconds, hb.GraceSeconds, time.Now(), hb.HostServiceID)
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	return nil
+}
+
+// RecordHeartbeatSignal records a start, success or fail signal of a job, with the log it sent
+// along, and returns the updated heartbeat
+func (m *postgresDBRepo) RecordHeartbeatSignal(hostServiceID int, signal, logPayload string) (models.Heartbeat, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `
+		UPDATE heartbeats SET
+			last_signal = $1,
+			last_signal_at = $2,
+			last_success_at = CASE WHEN $1 = $3 THEN $2 ELSE last_success_at END,
+			last_start_at = CASE WHEN $1 = $4 THEN $2 ELSE last_start_at END,
+			last_log = CASE WHEN $1 = $4 AND $5 = '' THEN last_log ELSE $5 END,
+			updated_at = $2
+		WHERE host_service_id = $6
+		RETURNING ` + heartbeatColumns
+
+	row := m.DB.QueryRowContext(ctx, query, signal, time.Now(), models.HeartbeatSuccess, models.HeartbeatStart,
+		logPayload, hostServiceID)
+
+	return scanHeartbeat(row)
+}
+
+// scanHeartbeat reads a heartbeats row; times never set are left zero
+func scanHeartbeat(row *sql.Row) (models.Heartbeat, error) {
+	var hb models.Heartbeat
+	var lastSignalAt, lastSuccessAt, lastStartAt sql.NullTime
+
+	err := row.Scan(
+		&hb.HostServiceID,
+		&hb.Token,
+		&hb.PeriodSeconds,
+		&hb.GraceSeconds,
+		&hb.LastSignal,
+		&lastSignalAt,
+		&lastSuccessAt,
+		&lastStartAt,
+		&hb.LastLog,
+		&hb.CreatedAt,
+		&hb.UpdatedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return hb, models.ErrNoRecord
+	}
+	if err != nil {
+		log.Println(err)
+		return hb, err
+	}
+
+	hb.LastSignalAt = lastSignalAt.Time
+	hb.LastSuccessAt = lastSuccessAt.Time
+	hb.LastStartAt = lastStartAt.Time
+
+	return hb, nil
+}
@@ -0,0 +1,191 @@
+package dbrepo
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"golang-observer-project/internal/models"
+	"log"
+	"time"
+)
+
+// hostAgentColumns are the columns scanHostAgent reads
+const hostAgentColumns = `host_service_id, token, interval_seconds, last_seen_at, last_metrics, created_at, updated_at`
+
+// EnsureHostAgent returns the host agent of a host service, setting it up with the given token
+// when it has none yet
+func (m *postgresDBRepo) EnsureHostAgent(hostServiceID int, token string) (models.HostAgent, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := m.DB.ExecContext(ctx, `
+		INSERT INTO host_agents (host_service_id, token, created_at, updated_at) VALUES ($1, $2, $3, $3)
+		ON CONFLICT (host_service_id) DO NOTHING`,
+		hostServiceID, token, time.Now())
+	if err != nil {
+		log.Println(err)
+		return models.HostAgent{}, err
+	}
+
+	row := m.DB.QueryRowContext(ctx, `SELECT `+hostAgentColumns+` FROM host_agents WHERE host_service_id = $1`,
+		hostServiceID)
+
+	return scanHostAgent(row)
+}
+
+// HostAgentByToken returns the host agent with a token
+func (m *postgresDBRepo) HostAgentByToken(token string) (models.HostAgent, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	row := m.DB.QueryRowContext(ctx, `SELECT `+hostAgentColumns+` FROM host_agents WHERE token = $1`, token)
+
+	return scanHostAgent(row)
+}
+
+// UpdateHostAgentToken gives a host agent a new token
+func (m *postgresDBRepo) UpdateHostAgentToken(hostServiceID int, token string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := m.DB.ExecContext(ctx, `UPDATE host_agents SET token = $1, updated_at = $2 WHERE host_service_id = $3`,
+		token, time.Now(), hostServiceID)
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	return nil
+}
+
+// RecordHostMetrics stores the latest metrics of a host agent and how often it reports, and
+// returns the updated agent
+func (m *postgresDBRepo) RecordHostMetrics(hostServiceID, intervalSeconds int, metrics models.HostMetrics) (models.HostAgent, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	payload, err := json.Marshal(metrics)
+	if err != nil {
+		return models.HostAgent{}, err
+	}
+
+	query := `
+		UPDATE host_agents SET interval_seconds = $1, last_seen_at = $2, last_metrics = $3, updated_at = $2
+		WHERE host_service_id = $4
+		RETURNING ` + hostAgentColumns
+
+	row := m.DB.QueryRowContext(ctx, query, intervalSeconds, time.Now(), payload, hostServiceID)
+
+	return scanHostAgent(row)
+}
+
+// MetricThresholds returns the thresholds set for a host service
+func (m *postgresDBRepo) MetricThresholds(hostServiceID int) ([]models.MetricThreshold, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT id, host_service_id, metric, warning, problem
+		FROM metric_thresholds WHERE host_service_id = $1 ORDER BY metric`
+
+	rows, err := m.DB.QueryContext(ctx, query, hostServiceID)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	defer func(rows *sql.Rows) {
+		_ = rows.Close()
+	}(rows)
+
+	var thresholds []models.MetricThreshold
+
+	for rows.Next() {
+		var t models.MetricThreshold
+		err = rows.Scan(
+			&t.ID,
+			&t.HostServiceID,
+			&t.Metric,
+			&t.Warning,
+			&t.Problem,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		thresholds = append(thresholds, t)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return thresholds, nil
+}
+
+// SetMetricThresholds replaces the thresholds of a host service
+func (m *postgresDBRepo) SetMetricThresholds(hostServiceID int, thresholds []models.MetricThreshold) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM metric_thresholds WHERE host_service_id = $1`, hostServiceID)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	for _, t := range thresholds {
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO metric_thresholds (host_service_id, metric, warning, problem, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $5)
+			ON CONFLICT (host_service_id, metric) DO UPDATE SET
+				warning = EXCLUDED.warning, problem = EXCLUDED.problem, updated_at = EXCLUDED.updated_at`,
+			hostServiceID, t.Metric, t.Warning, t.Problem, time.Now())
+		if err != nil {
+			log.Println(err)
+			_ = tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// scanHostAgent reads a host_agents row; an agent that never reported has a zero LastSeenAt
+func scanHostAgent(row *sql.Row) (models.HostAgent, error) {
+	var a models.HostAgent
+	var lastSeenAt sql.NullTime
+	var lastMetrics []byte
+
+	err := row.Scan(
+		&a.HostServiceID,
+		&a.Token,
+		&a.IntervalSeconds,
+		&lastSeenAt,
+		&lastMetrics,
+		&a.CreatedAt,
+		&a.UpdatedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return a, models.ErrNoRecord
+	}
+	if err != nil {
+		log.Println(err)
+		return a, err
+	}
+
+	a.LastSeenAt = lastSeenAt.Time
+
+	err = json.Unmarshal(lastMetrics, &a.LastMetrics)
+	if err != nil {
+		log.Printf("cannot decode metrics of host service %d: %s\n", a.HostServiceID, err)
+	}
+
+	return a, nil
+}
@@ -101,6 +101,7 @@ func (m *postgresDBRepo) FindHostByID(id int) (models.Host, error) {
 	// get all services for host
 	query = `
 		SELECT hs.id, hs.host_id, hs.service_id, hs.active, hs.scheduler_number, hs.scheduler_unit,
+		       hs.cron_expression, hs.time_zone, hs.active_hours,
 		       hs.last_check, hs.status, hs.created_at, hs.updated_at,
 		       s.id, s.service_name, s.active, s.icon, s.created_at, s.updated_at
 		FROM host_services hs
@@ -132,6 +133,9 @@ func (m *postgresDBRepo) FindHostByID(id int) (models.Host, error) {
 			&s.Active,
 			&s.SchedulerNumber,
 			&s.SchedulerUnit,
+			&s.CronExpression,
+			&s.TimeZone,
+			&s.ActiveHours,
 			&s.LastCheck,
 			&s.Status,
 			&s.CreatedAt,
@@ -228,9 +232,63 @@ func (m *postgresDBRepo) FindHostByID(id int) (models.Host, error) {
 
 	host.HostServices = services
 
+	tagRows, err := m.DB.QueryContext(ctx, `SELECT tag FROM host_tags WHERE host_id = $1 ORDER BY tag`, host.ID)
+	if err != nil {
+		log.Println(err)
+		return host, err
+	}
+
+	defer func(rows *sql.Rows) {
+		_ = rows.Close()
+	}(tagRows)
+
+	for tagRows.Next() {
+		var tag string
+		err = tagRows.Scan(&tag)
+		if err != nil {
+			return host, err
+		}
+		host.Tags = append(host.Tags, tag)
+	}
+
+	if err = tagRows.Err(); err != nil {
+		return host, err
+	}
+
 	return host, nil
 }
 
+// UpdateHostTags replaces the tags of a host
+func (m *postgresDBRepo) UpdateHostTags(hostID int, tags []string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM host_tags WHERE host_id = $1`, hostID)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	for _, tag := range tags {
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO host_tags (host_id, tag, created_at, updated_at) VALUES ($1, $2, $3, $4)
+			ON CONFLICT (host_id, tag) DO NOTHING`,
+			hostID, tag, time.Now(), time.Now())
+		if err != nil {
+			log.Println(err)
+			_ = tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
 // UpdateHost updates a host in the database
 func (m *postgresDBRepo) UpdateHost(h models.Host) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
@@ -307,6 +365,7 @@ func (m *postgresDBRepo) AllHosts() ([]models.Host, error) {
 
 	query = `
 		SELECT hs.id, hs.host_id, hs.service_id, hs.active, hs.scheduler_number, hs.scheduler_unit,
+		       hs.cron_expression, hs.time_zone, hs.active_hours,
 		       hs.last_check, hs.status, hs.created_at, hs.updated_at,
 		       s.id, s.service_name, s.active, s.icon, s.created_at, s.updated_at
 		FROM host_services hs
@@ -337,6 +396,9 @@ func (m *postgresDBRepo) AllHosts() ([]models.Host, error) {
 			&s.Active,
 			&s.SchedulerNumber,
 			&s.SchedulerUnit,
+			&s.CronExpression,
+			&s.TimeZone,
+			&s.ActiveHours,
 			&s.LastCheck,
 			&s.Status,
 			&s.CreatedAt,
@@ -391,6 +453,26 @@ func (m *postgresDBRepo) UpdateHostService(hs models.HostServices) error {
 	return nil
 }
 
+// UpdateHostServiceSchedule updates when a host service is checked
+func (m *postgresDBRepo) UpdateHostServiceSchedule(hs models.HostServices) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	stmt := `
+		UPDATE host_services SET scheduler_number = $1, scheduler_unit = $2, cron_expression = $3,
+		                         time_zone = $4, active_hours = $5, updated_at = $6
+		WHERE id = $7`
+
+	_, err := m.DB.ExecContext(ctx, stmt, hs.SchedulerNumber, hs.SchedulerUnit, hs.CronExpression,
+		hs.TimeZone, hs.ActiveHours, time.Now(), hs.ID)
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	return nil
+}
+
 // UpdateHostServiceStatus updates the active status of a host service
 func (m *postgresDBRepo) UpdateHostServiceStatus(hostID, serviceID, active int) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
@@ -435,6 +517,9 @@ func (m *postgresDBRepo) GetServicesByStatus(status string) ([]models.HostServic
 			   hs.active,
 			   hs.scheduler_number,
 			   hs.scheduler_unit,
+			   hs.cron_expression,
+			   hs.time_zone,
+			   hs.active_hours,
 			   hs.last_check,
 			   hs.status,
 			   hs.created_at,
@@ -472,6 +557,9 @@ func (m *postgresDBRepo) GetServicesByStatus(status string) ([]models.HostServic
 			&hs.Active,
 			&hs.SchedulerNumber,
 			&hs.SchedulerUnit,
+			&hs.CronExpression,
+			&hs.TimeZone,
+			&hs.ActiveHours,
 			&hs.LastCheck,
 			&hs.Status,
 			&hs.CreatedAt,
@@ -502,6 +590,9 @@ func (m *postgresDBRepo) GetHostServiceByID(id int) (models.HostServices, error)
 				hs.active,
 				hs.scheduler_number,
 				hs.scheduler_unit,
+				hs.cron_expression,
+				hs.time_zone,
+				hs.active_hours,
 				hs.last_check,
 				hs.status,
 				hs.created_at,
@@ -530,6 +621,9 @@ func (m *postgresDBRepo) GetHostServiceByID(id int) (models.HostServices, error)
 		&hs.Active,
 		&hs.SchedulerNumber,
 		&hs.SchedulerUnit,
+		&hs.CronExpression,
+		&hs.TimeZone,
+		&hs.ActiveHours,
 		&hs.LastCheck,
 		&hs.Status,
 		&hs.CreatedAt,
@@ -558,6 +652,9 @@ func (m *postgresDBRepo) GetServicesToMonitor() ([]models.HostServices, error) {
 			   hs.active,
 			   hs.scheduler_number,
 			   hs.scheduler_unit,
+			   hs.cron_expression,
+			   hs.time_zone,
+			   hs.active_hours,
 			   hs.last_check,
 			   hs.status,
 			   hs.created_at,
@@ -600,6 +697,9 @@ func (m *postgresDBRepo) GetServicesToMonitor() ([]models.HostServices, error) {
 			&hs.Active,
 			&hs.SchedulerNumber,
 			&hs.SchedulerUnit,
+			&hs.CronExpression,
+			&hs.TimeZone,
+			&hs.ActiveHours,
 			&hs.LastCheck,
 			&hs.Status,
 			&hs.CreatedAt,
@@ -638,6 +738,9 @@ func (m *postgresDBRepo) GetHostServiceByHostIDServiceID(hostID, serviceID int)
 			   hs.active,
 			   hs.scheduler_number,
 			   hs.scheduler_unit,
+			   hs.cron_expression,
+			   hs.time_zone,
+			   hs.active_hours,
 			   hs.last_check,
 			   hs.status,
 			   hs.created_at,
@@ -664,6 +767,9 @@ func (m *postgresDBRepo) GetHostServiceByHostIDServiceID(hostID, serviceID int)
 		&hs.Active,
 		&hs.SchedulerNumber,
 		&hs.SchedulerUnit,
+		&hs.CronExpression,
+		&hs.TimeZone,
+		&hs.ActiveHours,
 		&hs.LastCheck,
 		&hs.Status,
 		&hs.CreatedAt,
@@ -684,17 +790,18 @@ func (m *postgresDBRepo) GetHostServiceByHostIDServiceID(hostID, serviceID int)
 	return hs, nil
 }
 
-// InsertEvent inserts an event into the database
-func (m *postgresDBRepo) InsertEvent(event models.Event) error {
+// InsertEvent inserts an event into the database and returns its id
+func (m *postgresDBRepo) InsertEvent(event models.Event) (int, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
 	query := `
 		insert into events (host_service_id, event_type
 		,host_id, service_name, host_name, message, created_at, updated_at) VALUES 
-		($1, $2, $3, $4, $5, $6, $7, $8)`
+		($1, $2, $3, $4, $5, $6, $7, $8) returning id`
 
-	_, err := m.DB.ExecContext(ctx, query,
+	var newID int
+	err := m.DB.QueryRowContext(ctx, query,
 		event.HostServiceID,
 		event.EventType,
 		event.HostID,
@@ -703,12 +810,13 @@ func (m *postgresDBRepo) InsertEvent(event models.Event) error {
 		event.Message,
 		time.Now(),
 		time.Now(),
-	)
+	).Scan(&newID)
 	if err != nil {
 		log.Println(err)
+		return 0, err
 	}
 
-	return nil
+	return newID, nil
 }
 
 // AllEvents returns a slice of all events
@@ -756,3 +864,94 @@ func (m *postgresDBRepo) AllEvents() ([]models.Event, error) {
 
 	return events, nil
 }
+
+// ProblemStartedAt returns when a host service last left the healthy state, looking at
+// events older than beforeEventID. It returns the zero time if there is no such event.
+func (m *postgresDBRepo) ProblemStartedAt(hostServiceID, beforeEventID int) (time.Time, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `
+		select coalesce(min(created_at), '0001-01-01 00:00:00')
+		from events
+		where host_service_id = $1
+		  and id < $2
+		  and event_type in ('problem', 'warning')
+		  and id > coalesce((select max(id) from events
+		                     where host_service_id = $1 and id < $2 and event_type = 'healthy'), 0)`
+
+	var startedAt time.Time
+	err := m.DB.QueryRowContext(ctx, query, hostServiceID, beforeEventID).Scan(&startedAt)
+	if err != nil {
+		return startedAt, err
+	}
+
+	return startedAt, nil
+}
+
+// StatusEventsBetween returns the status change events in a period, oldest first
+func (m *postgresDBRepo) StatusEventsBetween(from, to time.Time) ([]models.Event, error) {
+	query := `
+		select id, host_service_id, event_type, host_id, service_name, host_name, message, created_at, updated_at
+		from events
+		where created_at >= $1 and created_at < $2
+		  and event_type in ('pending', 'healthy', 'warning', 'problem')
+		order by created_at, id`
+
+	return m.queryEvents(query, from, to)
+}
+
+// LatestStatusEventsBefore returns the last status change event of every host service before t
+func (m *postgresDBRepo) LatestStatusEventsBefore(t time.Time) ([]models.Event, error) {
+	query := `
+		select distinct on (host_service_id)
+		       id, host_service_id, event_type, host_id, service_name, host_name, message, created_at, updated_at
+		from events
+		where created_at < $1
+		  and event_type in ('pending', 'healthy', 'warning', 'problem')
+		order by host_service_id, created_at desc, id desc`
+
+	return m.queryEvents(query, t)
+}
+
+// queryEvents runs a query that selects event rows
+func (m *postgresDBRepo) queryEvents(query string, args ...interface{}) ([]models.Event, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func(rows *sql.Rows) {
+		_ = rows.Close()
+	}(rows)
+
+	var events []models.Event
+
+	for rows.Next() {
+		var e models.Event
+		err = rows.Scan(
+			&e.ID,
+			&e.HostServiceID,
+			&e.EventType,
+			&e.HostID,
+			&e.ServiceName,
+			&e.HostName,
+			&e.Message,
+			&e.CreatedAt,
+			&e.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
@@ -0,0 +1,242 @@
+package dbrepo
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"golang-observer-project/internal/channeldata"
+	"golang-observer-project/internal/models"
+	"log"
+	"time"
+)
+
+// mailSendingTimeout is how long a claimed email may stay in sending before it is
+// considered abandoned (e.g. the process died mid-send) and claimed again
+const mailSendingTimeout = 10 * time.Minute
+
+// InsertMail adds an email to the outbox, due right away
+func (m *postgresDBRepo) InsertMail(msg channeldata.MailData) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	message, err := json.Marshal(msg)
+	if err != nil {
+		return 0, err
+	}
+
+	query := `
+		INSERT INTO mail_outbox (message, status, attempts, next_attempt_at, created_at, updated_at)
+		VALUES ($1, $2, 0, $3, $3, $3) RETURNING id`
+
+	var newID int
+	err = m.DB.QueryRowContext(ctx, query, message, models.MailPending, time.Now()).Scan(&newID)
+	if err != nil {
+		log.Println(err)
+		return 0, err
+	}
+
+	return newID, nil
+}
+
+// ClaimDueMail marks up to limit due emails as sending, counts the attempt and returns them.
+// Rows locked by another claimer are skipped, so several senders never pick the same email.
+func (m *postgresDBRepo) ClaimDueMail(limit int) ([]models.OutboxMail, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	now := time.Now()
+
+	query := `
+		UPDATE mail_outbox SET status = $1, attempts = attempts + 1, updated_at = $2
+		WHERE id IN (
+			SELECT id FROM mail_outbox
+			WHERE (status = $3 AND next_attempt_at <= $2) OR (status = $1 AND updated_at < $4)
+			ORDER BY next_attempt_at
+			LIMIT $5
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, message, status, attempts, next_attempt_at, last_error, created_at, updated_at`
+
+	rows, err := m.DB.QueryContext(ctx, query, models.MailSending, now, models.MailPending,
+		now.Add(-mailSendingTimeout), limit)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	defer func(rows *sql.Rows) {
+		_ = rows.Close()
+	}(rows)
+
+	return scanOutboxMail(rows)
+}
+
+// MarkMailSent records a successful delivery
+func (m *postgresDBRepo) MarkMailSent(id int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `UPDATE mail_outbox SET status = $1, last_error = '', updated_at = $2 WHERE id = $3`
+
+	_, err := m.DB.ExecContext(ctx, query, models.MailSent, time.Now(), id)
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	return nil
+}
+
+// MarkMailFailed records a failed delivery, either to be retried at nextAttemptAt or,
+// when dead is true, moved to the dead letters
+func (m *postgresDBRepo) MarkMailFailed(id int, nextAttemptAt time.Time, lastError string, dead bool) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	status := models.MailPending
+	if dead {
+		status = models.MailDead
+	}
+
+	query := `
+		UPDATE mail_outbox SET status = $1, next_attempt_at = $2, last_error = $3, updated_at = $4
+		WHERE id = $5`
+
+	_, err := m.DB.ExecContext(ctx, query, status, nextAttemptAt, lastError, time.Now(), id)
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	return nil
+}
+
+// RequeueMail gives a dead letter a fresh set of attempts
+func (m *postgresDBRepo) RequeueMail(id int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `
+		UPDATE mail_outbox SET status = $1, attempts = 0, next_attempt_at = $2, updated_at = $2
+		WHERE id = $3 AND status = $4`
+
+	result, err := m.DB.ExecContext(ctx, query, models.MailPending, time.Now(), id, models.MailDead)
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return models.ErrNoRecord
+	}
+
+	return nil
+}
+
+// PurgeSentMail deletes emails sent before the given time
+func (m *postgresDBRepo) PurgeSentMail(before time.Time) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := m.DB.ExecContext(ctx, `DELETE FROM mail_outbox WHERE status = $1 AND updated_at < $2`,
+		models.MailSent, before)
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	return nil
+}
+
+// MailQueueStats counts the outbox by status; Retrying counts pending emails that failed before
+func (m *postgresDBRepo) MailQueueStats() (models.MailQueueStats, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var stats models.MailQueueStats
+
+	query := `
+		SELECT
+			COUNT(*) FILTER (WHERE status = $1),
+			COUNT(*) FILTER (WHERE status = $2),
+			COUNT(*) FILTER (WHERE status = $3),
+			COUNT(*) FILTER (WHERE status = $4),
+			COUNT(*) FILTER (WHERE status = $1 AND attempts > 0)
+		FROM mail_outbox`
+
+	err := m.DB.QueryRowContext(ctx, query, models.MailPending, models.MailSending, models.MailSent,
+		models.MailDead).Scan(&stats.Pending, &stats.Sending, &stats.Sent, &stats.Dead, &stats.Retrying)
+	if err != nil {
+		log.Println(err)
+		return stats, err
+	}
+
+	return stats, nil
+}
+
+// OutboxMailByStatus returns the most recently updated emails with a status, optionally
+// only those that have failed at least once
+func (m *postgresDBRepo) OutboxMailByStatus(status string, failedOnly bool, limit int) ([]models.OutboxMail, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT id, message, status, attempts, next_attempt_at, last_error, created_at, updated_at
+		FROM mail_outbox
+		WHERE status = $1 AND ($2 = false OR last_error <> '')
+		ORDER BY updated_at DESC LIMIT $3`
+
+	rows, err := m.DB.QueryContext(ctx, query, status, failedOnly, limit)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	defer func(rows *sql.Rows) {
+		_ = rows.Close()
+	}(rows)
+
+	return scanOutboxMail(rows)
+}
+
+// scanOutboxMail reads mail_outbox rows
+func scanOutboxMail(rows *sql.Rows) ([]models.OutboxMail, error) {
+	var mails []models.OutboxMail
+
+	for rows.Next() {
+		var om models.OutboxMail
+		var message []byte
+
+		err := rows.Scan(
+			&om.ID,
+			&message,
+			&om.Status,
+			&om.Attempts,
+			&om.NextAttemptAt,
+			&om.LastError,
+			&om.CreatedAt,
+			&om.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		err = json.Unmarshal(message, &om.Message)
+		if err != nil {
+			log.Printf("cannot decode outbox mail %d: %s\n", om.ID, err)
+		}
+
+		mails = append(mails, om)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return mails, nil
+}
@@ -0,0 +1,86 @@
+package dbrepo
+
+import (
+	"context"
+	"database/sql"
+	"golang-observer-project/internal/models"
+	"log"
+	"time"
+)
+
+// InsertNotificationLog records the outcome of a notification delivery
+func (m *postgresDBRepo) InsertNotificationLog(l models.NotificationLog) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `
+		INSERT INTO notification_log (channel, target, host_service_id, event_type, status,
+		                              attempts, error, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`
+
+	_, err := m.DB.ExecContext(ctx, query,
+		l.Channel,
+		l.Target,
+		l.HostServiceID,
+		l.EventType,
+		l.Status,
+		l.Attempts,
+		l.Error,
+		time.Now(),
+		time.Now(),
+	)
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	return nil
+}
+
+// RecentNotificationLogs returns the latest notification log entries, newest first
+func (m *postgresDBRepo) RecentNotificationLogs(limit int) ([]models.NotificationLog, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT id, channel, target, host_service_id, event_type, status, attempts, error,
+		       created_at, updated_at
+		FROM notification_log ORDER BY created_at DESC LIMIT $1`
+
+	rows, err := m.DB.QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func(rows *sql.Rows) {
+		_ = rows.Close()
+	}(rows)
+
+	var entries []models.NotificationLog
+
+	for rows.Next() {
+		var l models.NotificationLog
+		err = rows.Scan(
+			&l.ID,
+			&l.Channel,
+			&l.Target,
+			&l.HostServiceID,
+			&l.EventType,
+			&l.Status,
+			&l.Attempts,
+			&l.Error,
+			&l.CreatedAt,
+			&l.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, l)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
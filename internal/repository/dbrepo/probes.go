@@ -0,0 +1,381 @@
+package dbrepo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"golang-observer-project/internal/models"
+	"log"
+	"time"
+)
+
+// RegisterProbe adds a probe, or updates the location and token of the probe with that name.
+// An inactive probe keeps its token, so it cannot register itself back in.
+func (m *postgresDBRepo) RegisterProbe(name, location, tokenHash string) (models.Probe, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `
+		INSERT INTO probes (name, location, token_hash, active, last_seen_at, created_at, updated_at)
+		VALUES ($1, $2, $3, 1, $4, $4, $4)
+		ON CONFLICT (name) DO UPDATE SET
+			location = EXCLUDED.location,
+			token_hash = CASE WHEN probes.active = 1 THEN EXCLUDED.token_hash ELSE probes.token_hash END,
+			last_seen_at = EXCLUDED.last_seen_at,
+			updated_at = EXCLUDED.updated_at
+		RETURNING id, name, location, active, last_seen_at, created_at, updated_at`
+
+	var p models.Probe
+	err := m.DB.QueryRowContext(ctx, query, name, location, tokenHash, time.Now()).Scan(
+		&p.ID,
+		&p.Name,
+		&p.Location,
+		&p.Active,
+		&p.LastSeenAt,
+		&p.CreatedAt,
+		&p.UpdatedAt,
+	)
+	if err != nil {
+		log.Println(err)
+		return p, err
+	}
+
+	return p, nil
+}
+
+// AuthenticateProbe returns the active probe with the token, and records that it was seen
+func (m *postgresDBRepo) AuthenticateProbe(tokenHash string) (models.Probe, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `
+		UPDATE probes SET last_seen_at = $1
+		WHERE token_hash = $2 AND token_hash <> '' AND active = 1
+		RETURNING id, name, location, active, last_seen_at, created_at, updated_at`
+
+	var p models.Probe
+	err := m.DB.QueryRowContext(ctx, query, time.Now(), tokenHash).Scan(
+		&p.ID,
+		&p.Name,
+		&p.Location,
+		&p.Active,
+		&p.LastSeenAt,
+		&p.CreatedAt,
+		&p.UpdatedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return p, models.ErrNoRecord
+	}
+	if err != nil {
+		log.Println(err)
+		return p, err
+	}
+
+	return p, nil
+}
+
+// AllProbes returns all probes
+func (m *postgresDBRepo) AllProbes() ([]models.Probe, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT id, name, location, active, last_seen_at, created_at, updated_at
+		FROM probes ORDER BY name`
+
+	rows, err := m.DB.QueryContext(ctx, query)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	defer func(rows *sql.Rows) {
+		_ = rows.Close()
+	}(rows)
+
+	return scanProbes(rows)
+}
+
+// UpdateProbeActive switches a probe on or off
+func (m *postgresDBRepo) UpdateProbeActive(id, active int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := m.DB.ExecContext(ctx, `UPDATE probes SET active = $1, updated_at = $2 WHERE id = $3`,
+		active, time.Now(), id)
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	return nil
+}
+
+// DeleteProbe deletes a probe, with its assignments and results
+func (m *postgresDBRepo) DeleteProbe(id int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := m.DB.ExecContext(ctx, `DELETE FROM probes WHERE id = $1`, id)
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	return nil
+}
+
+// SetHostServiceProbes replaces the probes that check a host service, and drops the results
+// of probes no longer assigned
+func (m *postgresDBRepo) SetHostServiceProbes(hostServiceID int, probeIDs []int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM host_service_probes WHERE host_service_id = $1`, hostServiceID)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	for _, probeID := range probeIDs {
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO host_service_probes (host_service_id, probe_id) VALUES ($1, $2)
+			ON CONFLICT (host_service_id, probe_id) DO NOTHING`,
+			hostServiceID, probeID)
+		if err != nil {
+			log.Println(err)
+			_ = tx.Rollback()
+			return err
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		DELETE FROM probe_results r WHERE r.host_service_id = $1 AND NOT EXISTS (
+			SELECT 1 FROM host_service_probes hsp
+			WHERE hsp.host_service_id = r.host_service_id AND hsp.probe_id = r.probe_id
+		)`, hostServiceID)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// ProbesForHostService returns the active probes that check a host service
+func (m *postgresDBRepo) ProbesForHostService(hostServiceID int) ([]models.Probe, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT p.id, p.name, p.location, p.active, p.last_seen_at, p.created_at, p.updated_at
+		FROM probes p
+		JOIN host_service_probes hsp ON hsp.probe_id = p.id
+		WHERE hsp.host_service_id = $1 AND p.active = 1
+		ORDER BY p.name`
+
+	rows, err := m.DB.QueryContext(ctx, query, hostServiceID)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	defer func(rows *sql.Rows) {
+		_ = rows.Close()
+	}(rows)
+
+	return scanProbes(rows)
+}
+
+// ProbeAssignments returns the monitored host services a probe checks
+func (m *postgresDBRepo) ProbeAssignments(probeID int) ([]models.ProbeAssignment, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `
+		select hs.id,
+			   hs.host_id,
+			   hs.service_id,
+			   hs.active,
+			   hs.scheduler_number,
+			   hs.scheduler_unit,
+			   hs.cron_expression,
+			   hs.time_zone,
+			   hs.active_hours,
+			   hs.last_check,
+			   hs.status,
+			   hs.created_at,
+			   hs.updated_at,
+			   s.id,
+			   s.service_name,
+			   s.active,
+			   s.icon,
+			   s.created_at,
+			   s.updated_at,
+			   h.host_name,
+			   hs.last_message,
+			   h.url
+		from host_service_probes hsp
+		join host_services hs on hsp.host_service_id = hs.id
+		left join hosts h on hs.host_id = h.id
+		left join services s on hs.service_id = s.id
+		where hsp.probe_id = $1 and hs.active = 1 and h.active = 1
+		order by h.host_name, s.service_name`
+
+	rows, err := m.DB.QueryContext(ctx, query, probeID)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	defer func(rows *sql.Rows) {
+		_ = rows.Close()
+	}(rows)
+
+	var assignments []models.ProbeAssignment
+
+	for rows.Next() {
+		var a models.ProbeAssignment
+		hs := &a.HostService
+		err = rows.Scan(
+			&hs.ID,
+			&hs.HostID,
+			&hs.ServiceID,
+			&hs.Active,
+			&hs.SchedulerNumber,
+			&hs.SchedulerUnit,
+			&hs.CronExpression,
+			&hs.TimeZone,
+			&hs.ActiveHours,
+			&hs.LastCheck,
+			&hs.Status,
+			&hs.CreatedAt,
+			&hs.UpdatedAt,
+			&hs.Service.ID,
+			&hs.Service.ServiceName,
+			&hs.Service.Active,
+			&hs.Service.Icon,
+			&hs.Service.CreatedAt,
+			&hs.Service.UpdatedAt,
+			&hs.HostName,
+			&hs.LastMessage,
+			&a.URL,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		assignments = append(assignments, a)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return assignments, nil
+}
+
+// UpsertProbeResult stores the latest result of a probe for a host service
+func (m *postgresDBRepo) UpsertProbeResult(r models.ProbeResult) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `
+		INSERT INTO probe_results (host_service_id, probe_id, status, message, checked_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (host_service_id, probe_id) DO UPDATE SET
+			status = EXCLUDED.status, message = EXCLUDED.message, checked_at = EXCLUDED.checked_at`
+
+	_, err := m.DB.ExecContext(ctx, query, r.HostServiceID, r.ProbeID, r.Status, r.Message, r.CheckedAt)
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	return nil
+}
+
+// ProbeResultsForHostService returns the latest result of every active probe assigned to a
+// host service
+func (m *postgresDBRepo) ProbeResultsForHostService(hostServiceID int) ([]models.ProbeResult, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT r.host_service_id, r.probe_id, p.name, p.location, r.status, r.message, r.checked_at
+		FROM probe_results r
+		JOIN probes p ON p.id = r.probe_id
+		JOIN host_service_probes hsp ON hsp.host_service_id = r.host_service_id AND hsp.probe_id = r.probe_id
+		WHERE r.host_service_id = $1 AND p.active = 1
+		ORDER BY p.name`
+
+	rows, err := m.DB.QueryContext(ctx, query, hostServiceID)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	defer func(rows *sql.Rows) {
+		_ = rows.Close()
+	}(rows)
+
+	var results []models.ProbeResult
+
+	for rows.Next() {
+		var r models.ProbeResult
+		err = rows.Scan(
+			&r.HostServiceID,
+			&r.ProbeID,
+			&r.ProbeName,
+			&r.Location,
+			&r.Status,
+			&r.Message,
+			&r.CheckedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		results = append(results, r)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// scanProbes reads probes rows
+func scanProbes(rows *sql.Rows) ([]models.Probe, error) {
+	var probes []models.Probe
+
+	for rows.Next() {
+		var p models.Probe
+		err := rows.Scan(
+			&p.ID,
+			&p.Name,
+			&p.Location,
+			&p.Active,
+			&p.LastSeenAt,
+			&p.CreatedAt,
+			&p.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		probes = append(probes, p)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return probes, nil
+}
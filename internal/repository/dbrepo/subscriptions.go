@@ -0,0 +1,186 @@
+package dbrepo
+
+import (
+	"context"
+	"database/sql"
+	"golang-observer-project/internal/models"
+	"log"
+	"time"
+)
+
+// ContactMethodsForUser returns the contact methods of a user
+func (m *postgresDBRepo) ContactMethodsForUser(userID int) ([]models.ContactMethod, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT id, user_id, method_type, address, created_at, updated_at
+		FROM user_contact_methods WHERE user_id = $1 ORDER BY method_type, id`
+
+	rows, err := m.DB.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func(rows *sql.Rows) {
+		_ = rows.Close()
+	}(rows)
+
+	var methods []models.ContactMethod
+
+	for rows.Next() {
+		var cm models.ContactMethod
+		err = rows.Scan(
+			&cm.ID,
+			&cm.UserID,
+			&cm.MethodType,
+			&cm.Address,
+			&cm.CreatedAt,
+			&cm.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		methods = append(methods, cm)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return methods, nil
+}
+
+// InsertContactMethod adds a contact method for a user
+func (m *postgresDBRepo) InsertContactMethod(cm models.ContactMethod) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `
+		INSERT INTO user_contact_methods (user_id, method_type, address, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5) RETURNING id`
+
+	var newID int
+	err := m.DB.QueryRowContext(ctx, query, cm.UserID, cm.MethodType, cm.Address, time.Now(), time.Now()).Scan(&newID)
+	if err != nil {
+		log.Println(err)
+		return 0, err
+	}
+
+	return newID, nil
+}
+
+// DeleteContactMethod deletes a contact method belonging to a user
+func (m *postgresDBRepo) DeleteContactMethod(userID, id int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := m.DB.ExecContext(ctx, `DELETE FROM user_contact_methods WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	return nil
+}
+
+// AllSubscriptions returns the subscriptions of all active users
+func (m *postgresDBRepo) AllSubscriptions() ([]models.Subscription, error) {
+	query := `
+		SELECT s.id, s.user_id, s.host_id, s.tag, s.severity, s.created_at, s.updated_at
+		FROM user_subscriptions s
+		LEFT JOIN users u ON u.id = s.user_id
+		WHERE u.user_active = 1 AND u.deleted_at IS NULL
+		ORDER BY s.user_id, s.id`
+
+	return m.querySubscriptions(query)
+}
+
+// SubscriptionsForUser returns the subscriptions of a user
+func (m *postgresDBRepo) SubscriptionsForUser(userID int) ([]models.Subscription, error) {
+	query := `
+		SELECT id, user_id, host_id, tag, severity, created_at, updated_at
+		FROM user_subscriptions WHERE user_id = $1 ORDER BY id`
+
+	return m.querySubscriptions(query, userID)
+}
+
+// querySubscriptions runs a query that selects subscription rows
+func (m *postgresDBRepo) querySubscriptions(query string, args ...interface{}) ([]models.Subscription, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func(rows *sql.Rows) {
+		_ = rows.Close()
+	}(rows)
+
+	var subscriptions []models.Subscription
+
+	for rows.Next() {
+		var sub models.Subscription
+		err = rows.Scan(
+			&sub.ID,
+			&sub.UserID,
+			&sub.HostID,
+			&sub.Tag,
+			&sub.Severity,
+			&sub.CreatedAt,
+			&sub.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		subscriptions = append(subscriptions, sub)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return subscriptions, nil
+}
+
+// InsertSubscription adds a subscription for a user
+func (m *postgresDBRepo) InsertSubscription(sub models.Subscription) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `
+		INSERT INTO user_subscriptions (user_id, host_id, tag, severity, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6) RETURNING id`
+
+	var newID int
+	err := m.DB.QueryRowContext(ctx, query,
+		sub.UserID,
+		sub.HostID,
+		sub.Tag,
+		sub.Severity,
+		time.Now(),
+		time.Now(),
+	).Scan(&newID)
+	if err != nil {
+		log.Println(err)
+		return 0, err
+	}
+
+	return newID, nil
+}
+
+// DeleteSubscription deletes a subscription belonging to a user
+func (m *postgresDBRepo) DeleteSubscription(userID, id int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := m.DB.ExecContext(ctx, `DELETE FROM user_subscriptions WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,73 @@
+package dbrepo
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"golang-observer-project/internal/models"
+	"log"
+	"time"
+)
+
+// Transaction returns the steps a transaction host service runs
+func (m *postgresDBRepo) Transaction(hostServiceID int) (models.Transaction, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT host_service_id, steps, timeout_seconds, created_at, updated_at
+		FROM transactions WHERE host_service_id = $1`
+
+	var t models.Transaction
+	var steps []byte
+
+	err := m.DB.QueryRowContext(ctx, query, hostServiceID).Scan(
+		&t.HostServiceID,
+		&steps,
+		&t.TimeoutSeconds,
+		&t.CreatedAt,
+		&t.UpdatedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return t, models.ErrNoRecord
+	}
+	if err != nil {
+		log.Println(err)
+		return t, err
+	}
+
+	err = json.Unmarshal(steps, &t.Steps)
+	if err != nil {
+		log.Printf("cannot decode steps of host service %d: %s\n", hostServiceID, err)
+	}
+
+	return t, nil
+}
+
+// UpsertTransaction saves the steps and timeout of a transaction host service
+func (m *postgresDBRepo) UpsertTransaction(t models.Transaction) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	steps, err := json.Marshal(t.Steps)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO transactions (host_service_id, steps, timeout_seconds, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $4)
+		ON CONFLICT (host_service_id) DO UPDATE SET
+			steps = EXCLUDED.steps,
+			timeout_seconds = EXCLUDED.timeout_seconds,
+			updated_at = EXCLUDED.updated_at`
+
+	_, err = m.DB.ExecContext(ctx, query, t.HostServiceID, steps, t.TimeoutSeconds, time.Now())
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	return nil
+}
@@ -50,7 +50,7 @@ func (m *postgresDBRepo) GetUserById(id int) (models.User, error) {
 	defer cancel()
 
 	stmt := `SELECT id, first_name, last_name,  user_active, access_level, email, 
-			created_at, updated_at
+			created_at, updated_at, time_zone, quiet_hours_start, quiet_hours_end
 			FROM users where id = $1`
 	row := m.DB.QueryRowContext(ctx, stmt, id)
 
@@ -65,6 +65,9 @@ func (m *postgresDBRepo) GetUserById(id int) (models.User, error) {
 		&u.Email,
 		&u.CreatedAt,
 		&u.UpdatedAt,
+		&u.TimeZone,
+		&u.QuietHoursStart,
+		&u.QuietHoursEnd,
 	)
 
 	if err != nil {
@@ -191,6 +194,22 @@ func (m *postgresDBRepo) UpdateUser(u models.User) error {
 	return nil
 }
 
+// UpdateUserQuietHours updates the time zone and quiet hours used for a user's notifications
+func (m *postgresDBRepo) UpdateUserQuietHours(id int, timeZone, start, end string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	stmt := `update users set time_zone = $1, quiet_hours_start = $2, quiet_hours_end = $3 where id = $4`
+
+	_, err := m.DB.ExecContext(ctx, stmt, timeZone, start, end, id)
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	return nil
+}
+
 // DeleteUser sets a user to deleted by populating deleted_at value
 func (m *postgresDBRepo) DeleteUser(id int) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
@@ -0,0 +1,149 @@
+package dbrepo
+
+import (
+	"context"
+	"database/sql"
+	"golang-observer-project/internal/models"
+	"log"
+	"time"
+)
+
+// AllWebhooks returns a slice of all webhooks
+func (m *postgresDBRepo) AllWebhooks() ([]models.Webhook, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT id, name, url, secret, payload_template, active, created_at, updated_at
+		FROM webhooks ORDER BY name`
+
+	rows, err := m.DB.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func(rows *sql.Rows) {
+		_ = rows.Close()
+	}(rows)
+
+	var webhooks []models.Webhook
+
+	for rows.Next() {
+		var wh models.Webhook
+		err = rows.Scan(
+			&wh.ID,
+			&wh.Name,
+			&wh.URL,
+			&wh.Secret,
+			&wh.PayloadTemplate,
+			&wh.Active,
+			&wh.CreatedAt,
+			&wh.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		webhooks = append(webhooks, wh)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return webhooks, nil
+}
+
+// GetWebhookByID returns a webhook by id
+func (m *postgresDBRepo) GetWebhookByID(id int) (models.Webhook, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT id, name, url, secret, payload_template, active, created_at, updated_at
+		FROM webhooks WHERE id = $1`
+
+	var wh models.Webhook
+	err := m.DB.QueryRowContext(ctx, query, id).Scan(
+		&wh.ID,
+		&wh.Name,
+		&wh.URL,
+		&wh.Secret,
+		&wh.PayloadTemplate,
+		&wh.Active,
+		&wh.CreatedAt,
+		&wh.UpdatedAt,
+	)
+	if err != nil {
+		return wh, err
+	}
+
+	return wh, nil
+}
+
+// InsertWebhook inserts a webhook into the database
+func (m *postgresDBRepo) InsertWebhook(wh models.Webhook) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `
+		INSERT INTO webhooks (name, url, secret, payload_template, active, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id`
+
+	var newID int
+	err := m.DB.QueryRowContext(ctx, query,
+		wh.Name,
+		wh.URL,
+		wh.Secret,
+		wh.PayloadTemplate,
+		wh.Active,
+		time.Now(),
+		time.Now(),
+	).Scan(&newID)
+	if err != nil {
+		log.Println(err)
+		return 0, err
+	}
+
+	return newID, nil
+}
+
+// UpdateWebhook updates a webhook in the database
+func (m *postgresDBRepo) UpdateWebhook(wh models.Webhook) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `
+		UPDATE webhooks SET name = $1, url = $2, secret = $3, payload_template = $4,
+		                    active = $5, updated_at = $6
+		WHERE id = $7`
+
+	_, err := m.DB.ExecContext(ctx, query,
+		wh.Name,
+		wh.URL,
+		wh.Secret,
+		wh.PayloadTemplate,
+		wh.Active,
+		time.Now(),
+		wh.ID,
+	)
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	return nil
+}
+
+// DeleteWebhook deletes a webhook by id
+func (m *postgresDBRepo) DeleteWebhook(id int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := m.DB.ExecContext(ctx, `DELETE FROM webhooks WHERE id = $1`, id)
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	return nil
+}
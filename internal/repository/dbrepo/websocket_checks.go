@@ -0,0 +1,96 @@
+package dbrepo
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"golang-observer-project/internal/models"
+	"log"
+	"time"
+)
+
+// WebSocketCheck returns the endpoint and message of a WebSocket host service
+func (m *postgresDBRepo) WebSocketCheck(hostServiceID int) (models.WebSocketCheck, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT host_service_id, url, headers, subprotocol, send, expect, timeout_seconds, created_at,
+			updated_at
+		FROM websocket_checks WHERE host_service_id = $1`
+
+	var wc models.WebSocketCheck
+	var headers []byte
+
+	err := m.DB.QueryRowContext(ctx, query, hostServiceID).Scan(
+		&wc.HostServiceID,
+		&wc.URL,
+		&headers,
+		&wc.Subprotocol,
+		&wc.Send,
+		&wc.Expect,
+		&wc.TimeoutSeconds,
+		&wc.CreatedAt,
+		&wc.UpdatedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return wc, models.ErrNoRecord
+	}
+	if err != nil {
+		log.Println(err)
+		return wc, err
+	}
+
+	err = json.Unmarshal(headers, &wc.Headers)
+	if err != nil {
+		log.Printf("cannot decode headers of host service %d: %s\n", hostServiceID, err)
+	}
+
+	return wc, nil
+}
+
+// UpsertWebSocketCheck saves the endpoint and message of a WebSocket host service
+func (m *postgresDBRepo) UpsertWebSocketCheck(wc models.WebSocketCheck) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	if wc.Headers == nil {
+		wc.Headers = map[string]string{}
+	}
+
+	headers, err := json.Marshal(wc.Headers)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO websocket_checks (host_service_id, url, headers, subprotocol, send, expect,
+			timeout_seconds, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $8)
+		ON CONFLICT (host_service_id) DO UPDATE SET
+			url = EXCLUDED.url,
+			headers = EXCLUDED.headers,
+			subprotocol = EXCLUDED.subprotocol,
+			send = EXCLUDED.send,
+			expect = EXCLUDED.expect,
+			timeout_seconds = EXCLUDED.timeout_seconds,
+			updated_at = EXCLUDED.updated_at`
+
+	_, err = m.DB.ExecContext(ctx, query,
+		wc.HostServiceID,
+		wc.URL,
+		headers,
+		wc.Subprotocol,
+		wc.Send,
+		wc.Expect,
+		wc.TimeoutSeconds,
+		time.Now(),
+	)
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	return nil
+}
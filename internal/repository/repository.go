@@ -1,6 +1,10 @@
 package repository
 
-import "golang-observer-project/internal/models"
+import (
+	"golang-observer-project/internal/channeldata"
+	"golang-observer-project/internal/models"
+	"time"
+)
 
 // DatabaseRepo is the database repository
 type DatabaseRepo interface {
@@ -19,21 +23,122 @@ type DatabaseRepo interface {
 	Authenticate(email, testPassword string) (int, string, error)
 	AllUsers() ([]*models.User, error)
 	GetUserByEmail(email string) (models.User, error)
+	UpdateUserQuietHours(id int, timeZone, start, end string) error
+
+	// notification subscriptions
+	ContactMethodsForUser(userID int) ([]models.ContactMethod, error)
+	InsertContactMethod(cm models.ContactMethod) (int, error)
+	DeleteContactMethod(userID, id int) error
+	AllSubscriptions() ([]models.Subscription, error)
+	SubscriptionsForUser(userID int) ([]models.Subscription, error)
+	InsertSubscription(sub models.Subscription) (int, error)
+	DeleteSubscription(userID, id int) error
 
 	// hosts
 	InsertHost(h models.Host) (int, error)
 	FindHostByID(id int) (models.Host, error)
 	UpdateHost(h models.Host) error
+	UpdateHostTags(hostID int, tags []string) error
 	AllHosts() ([]models.Host, error)
 	UpdateHostService(hs models.HostServices) error
 	GetAllServicesStatusCounts() (int, int, int, int, error)
 	GetServicesByStatus(status string) ([]models.HostServices, error)
 	GetHostServiceByID(id int) (models.HostServices, error)
 	UpdateHostServiceStatus(hostID, serviceID, active int) error
+	UpdateHostServiceSchedule(hs models.HostServices) error
 	GetServicesToMonitor() ([]models.HostServices, error)
 	GetHostServiceByHostIDServiceID(hostID, serviceID int) (models.HostServices, error)
 	AllEvents() ([]models.Event, error)
-	InsertEvent(e models.Event) error
+	InsertEvent(e models.Event) (int, error)
+	StatusEventsBetween(from, to time.Time) ([]models.Event, error)
+	LatestStatusEventsBefore(t time.Time) ([]models.Event, error)
+
+	// webhooks
+	AllWebhooks() ([]models.Webhook, error)
+	GetWebhookByID(id int) (models.Webhook, error)
+	InsertWebhook(wh models.Webhook) (int, error)
+	UpdateWebhook(wh models.Webhook) error
+	DeleteWebhook(id int) error
+
+	// email templates
+	GetEmailTemplate(name string) (models.EmailTemplate, error)
+	UpsertEmailTemplate(et models.EmailTemplate) error
+	DeleteEmailTemplate(name string) error
+	ProblemStartedAt(hostServiceID, beforeEventID int) (time.Time, error)
+
+	// notification log
+	InsertNotificationLog(l models.NotificationLog) error
+	RecentNotificationLogs(limit int) ([]models.NotificationLog, error)
+
+	// mail outbox
+	InsertMail(msg channeldata.MailData) (int, error)
+	ClaimDueMail(limit int) ([]models.OutboxMail, error)
+	MarkMailSent(id int) error
+	MarkMailFailed(id int, nextAttemptAt time.Time, lastError string, dead bool) error
+	RequeueMail(id int) error
+	PurgeSentMail(before time.Time) error
+	MailQueueStats() (models.MailQueueStats, error)
+	OutboxMailByStatus(status string, failedOnly bool, limit int) ([]models.OutboxMail, error)
+
+	// check queue
+	EnqueueCheck(hostServiceID, hostID int) (bool, error)
+	ClaimChecks(workerID string, lease time.Duration, limit int) ([]models.CheckJob, error)
+	ExtendCheckLease(id int, workerID string, lease time.Duration) (bool, error)
+	FinishCheck(id int, workerID, lastError string) error
+	FailAbandonedChecks() (int, error)
+	PurgeFinishedChecks(before time.Time) error
+	CheckQueueStats() (models.CheckQueueStats, error)
+	FailedChecks(limit int) ([]models.CheckJob, error)
+
+	// probes
+	RegisterProbe(name, location, tokenHash string) (models.Probe, error)
+	AuthenticateProbe(tokenHash string) (models.Probe, error)
+	AllProbes() ([]models.Probe, error)
+	UpdateProbeActive(id, active int) error
+	DeleteProbe(id int) error
+	SetHostServiceProbes(hostServiceID int, probeIDs []int) error
+	ProbesForHostService(hostServiceID int) ([]models.Probe, error)
+	ProbeAssignments(probeID int) ([]models.ProbeAssignment, error)
+	UpsertProbeResult(r models.ProbeResult) error
+	ProbeResultsForHostService(hostServiceID int) ([]models.ProbeResult, error)
+
+	// heartbeats
+	EnsureHeartbeat(hostServiceID int, token string) (models.Heartbeat, error)
+	HeartbeatByToken(token string) (models.Heartbeat, error)
+	UpdateHeartbeat(hb models.Heartbeat) error
+	RecordHeartbeatSignal(hostServiceID int, signal, logPayload string) (models.Heartbeat, error)
+
+	// host agents
+	EnsureHostAgent(hostServiceID int, token string) (models.HostAgent, error)
+	HostAgentByToken(token string) (models.HostAgent, error)
+	UpdateHostAgentToken(hostServiceID int, token string) error
+	RecordHostMetrics(hostServiceID, intervalSeconds int, metrics models.HostMetrics) (models.HostAgent, error)
+	MetricThresholds(hostServiceID int) ([]models.MetricThreshold, error)
+	SetMetricThresholds(hostServiceID int, thresholds []models.MetricThreshold) error
+
+	// exec checks
+	ExecCheck(hostServiceID int) (models.ExecCheck, error)
+	UpsertExecCheck(ec models.ExecCheck) error
+
+	// transactions
+	Transaction(hostServiceID int) (models.Transaction, error)
+	UpsertTransaction(t models.Transaction) error
+
+	// database checks
+	DatabaseCheck(hostServiceID int) (models.DatabaseCheck, error)
+	UpsertDatabaseCheck(dc models.DatabaseCheck) error
+
+	// gRPC checks
+	GRPCCheck(hostServiceID int) (models.GRPCCheck, error)
+	UpsertGRPCCheck(gc models.GRPCCheck) error
+
+	// WebSocket checks
+	WebSocketCheck(hostServiceID int) (models.WebSocketCheck, error)
+	UpsertWebSocketCheck(wc models.WebSocketCheck) error
+
+	// check settings
+	CheckSettings(hostServiceID int) (models.CheckSettings, error)
+	UpsertCheckSettings(cs models.CheckSettings) error
 
 	//sessions
 	CreateSession(params models.CreateSessionsParams) (models.Session, error)
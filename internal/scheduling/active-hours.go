@@ -0,0 +1,152 @@
+package scheduling
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// Window is a daily time range on some days of the week. Start and End are offsets from
+// midnight; a window ending before it starts runs past midnight into the next day.
+type Window struct {
+	Days  [7]bool
+	Start time.Duration
+	End   time.Duration
+}
+
+// Contains reports whether t, in the window's time zone, is inside the window
+func (w Window) Contains(t time.Time) bool {
+	clock := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute +
+		time.Duration(t.Second())*time.Second
+
+	if w.Start <= w.End {
+		return w.Days[t.Weekday()] && clock >= w.Start && clock < w.End
+	}
+
+	// past midnight, the window belongs to the day it started on
+	if clock >= w.Start {
+		return w.Days[t.Weekday()]
+	}
+
+	return clock < w.End && w.Days[(t.Weekday()+6)%7]
+}
+
+// ParseActiveHours parses windows separated by semicolons, each made of optional days and a
+// time range: "09:00-17:00", "Mon-Fri 08:30-18:00; Sat 10:00-14:00" or "Mon,Wed 22:00-06:00".
+// Without days a window applies every day.
+func ParseActiveHours(s string) ([]Window, error) {
+	var windows []Window
+
+	for _, part := range strings.Split(s, ";") {
+		fields := strings.Fields(part)
+		if len(fields) == 0 {
+			continue
+		}
+
+		if len(fields) > 2 {
+			return nil, fmt.Errorf("invalid active hours %q, expected e.g. \"Mon-Fri 09:00-17:00\"", strings.TrimSpace(part))
+		}
+
+		var w Window
+		var err error
+
+		if len(fields) == 1 {
+			for d := range w.Days {
+				w.Days[d] = true
+			}
+		} else {
+			w.Days, err = parseDays(fields[0])
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		w.Start, w.End, err = parseTimeRange(fields[len(fields)-1])
+		if err != nil {
+			return nil, err
+		}
+
+		windows = append(windows, w)
+	}
+
+	if len(windows) == 0 {
+		return nil, fmt.Errorf("invalid active hours %q", s)
+	}
+
+	return windows, nil
+}
+
+// parseDays parses days such as "Mon-Fri", "Sat,Sun" or "Fri-Mon"
+func parseDays(s string) ([7]bool, error) {
+	var days [7]bool
+
+	for _, item := range strings.Split(s, ",") {
+		bounds := strings.SplitN(item, "-", 2)
+
+		first, ok := weekdays[strings.ToLower(bounds[0])]
+		if !ok {
+			return days, fmt.Errorf("unknown day %q, use Mon, Tue, Wed, Thu, Fri, Sat or Sun", bounds[0])
+		}
+
+		last := first
+		if len(bounds) == 2 {
+			last, ok = weekdays[strings.ToLower(bounds[1])]
+			if !ok {
+				return days, fmt.Errorf("unknown day %q, use Mon, Tue, Wed, Thu, Fri, Sat or Sun", bounds[1])
+			}
+		}
+
+		for d := first; ; d = (d + 1) % 7 {
+			days[d] = true
+			if d == last {
+				break
+			}
+		}
+	}
+
+	return days, nil
+}
+
+// parseTimeRange parses "HH:MM-HH:MM"; 24:00 may be used as the end of the day
+func parseTimeRange(s string) (time.Duration, time.Duration, error) {
+	bounds := strings.SplitN(s, "-", 2)
+	if len(bounds) != 2 {
+		return 0, 0, fmt.Errorf("invalid time range %q, expected e.g. 09:00-17:00", s)
+	}
+
+	start, err := parseClock(bounds[0])
+	if err != nil {
+		return 0, 0, err
+	}
+
+	end, err := parseClock(bounds[1])
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if start == end {
+		return 0, 0, fmt.Errorf("time range %q is empty", s)
+	}
+
+	return start, end, nil
+}
+
+// parseClock parses HH:MM into an offset from midnight
+func parseClock(s string) (time.Duration, error) {
+	var h, m int
+	if _, err := fmt.Sscanf(s, "%d:%d", &h, &m); err != nil || h < 0 || m < 0 || m > 59 || h > 24 || (h == 24 && m != 0) {
+		return 0, fmt.Errorf("invalid time %q, expected HH:MM", s)
+	}
+
+	return time.Duration(h)*time.Hour + time.Duration(m)*time.Minute, nil
+}
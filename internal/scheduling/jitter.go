@@ -0,0 +1,95 @@
+package scheduling
+
+import (
+	"github.com/robfig/cron/v3"
+	"hash/fnv"
+	"strconv"
+	"time"
+)
+
+// Offset returns a stable offset in [0, max) for a host service, so its checks keep the same
+// place in the spread across restarts
+func Offset(hostServiceID int, max time.Duration) time.Duration {
+	if max <= 0 {
+		return 0
+	}
+
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(strconv.Itoa(hostServiceID)))
+
+	return time.Duration(h.Sum64() % uint64(max))
+}
+
+// Jitter moves a schedule by offset. An interval schedule (@every) has its first run at
+// now + offset, capped to one interval, so checks with the same interval do not all fire
+// together; with active hours it also starts again offset after a window opens. Any other
+// schedule runs offset after each of its own run times.
+func Jitter(schedule cron.Schedule, offset time.Duration, now time.Time) cron.Schedule {
+	if offset <= 0 {
+		return schedule
+	}
+
+	// shifting an interval schedule changes nothing, so the interval inside the active hours
+	// is spread instead
+	if active, ok := schedule.(*activeSchedule); ok {
+		if interval, ok := active.schedule.(cron.ConstantDelaySchedule); ok {
+			offset = capOffset(offset, interval)
+			spread := *active
+			spread.schedule = &spreadSchedule{schedule: interval, first: now.Add(offset)}
+			spread.offset = offset
+			return &spread
+		}
+	}
+
+	if interval, ok := schedule.(cron.ConstantDelaySchedule); ok {
+		return &spreadSchedule{schedule: interval, first: now.Add(capOffset(offset, interval))}
+	}
+
+	return &shiftedSchedule{schedule: schedule, offset: offset}
+}
+
+// capOffset keeps an offset within one interval
+func capOffset(offset time.Duration, interval cron.ConstantDelaySchedule) time.Duration {
+	if offset >= interval.Delay {
+		offset %= interval.Delay
+	}
+	return offset
+}
+
+// isInterval reports whether a schedule is an interval schedule, spread or not
+func isInterval(schedule cron.Schedule) bool {
+	switch s := schedule.(type) {
+	case cron.ConstantDelaySchedule:
+		return true
+	case *spreadSchedule:
+		return isInterval(s.schedule)
+	}
+	return false
+}
+
+// spreadSchedule runs first at a set time, and on the underlying schedule after that
+type spreadSchedule struct {
+	schedule cron.Schedule
+	first    time.Time
+}
+
+func (s *spreadSchedule) Next(t time.Time) time.Time {
+	if t.Before(s.first) {
+		return s.first
+	}
+	return s.schedule.Next(t)
+}
+
+// shiftedSchedule runs offset after every run of the underlying schedule
+type shiftedSchedule struct {
+	schedule cron.Schedule
+	offset   time.Duration
+}
+
+func (s *shiftedSchedule) Next(t time.Time) time.Time {
+	next := s.schedule.Next(t.Add(-s.offset))
+	if next.IsZero() {
+		return next
+	}
+	return next.Add(s.offset)
+}
@@ -0,0 +1,64 @@
+package scheduling
+
+import (
+	"golang-observer-project/internal/models"
+	"testing"
+	"time"
+)
+
+func TestJitterWithActiveHours(t *testing.T) {
+	hs := models.HostServices{SchedulerNumber: 5, SchedulerUnit: "m", ActiveHours: "09:00-17:00"}
+
+	schedule, err := New(hs, time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a Wednesday, inside the active hours
+	now := time.Date(2024, 1, 10, 10, 0, 0, 0, time.UTC)
+	at := func(day, hour, min, sec int) time.Time {
+		return time.Date(2024, 1, day, hour, min, sec, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name   string
+		offset time.Duration
+		from   time.Time
+		want   time.Time
+	}{
+		{"no offset", 0, now, at(10, 10, 5, 0)},
+		{"first run", 90 * time.Second, now, at(10, 10, 1, 30)},
+		{"keeps the spread", 90 * time.Second, at(10, 10, 1, 30), at(10, 10, 6, 30)},
+		{"window opens", 90 * time.Second, at(10, 16, 58, 30), at(11, 9, 1, 30)},
+		{"capped to the interval", 7 * time.Minute, now, at(10, 10, 2, 0)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Jitter(schedule, tt.offset, now).Next(tt.from); !got.Equal(tt.want) {
+				t.Errorf("Next(%s) = %s, want %s", tt.from.Format(time.TimeOnly), got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJitterSpreadsIntervalsWithActiveHours(t *testing.T) {
+	hs := models.HostServices{SchedulerNumber: 1, SchedulerUnit: "m", ActiveHours: "Mon-Fri 08:00-18:00"}
+
+	schedule, err := New(hs, time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Date(2024, 1, 10, 12, 0, 0, 0, time.UTC)
+
+	// checks with the same interval but other host services do not all fire together
+	runs := make(map[time.Time]bool)
+	for id := 1; id <= 20; id++ {
+		runs[Jitter(schedule, Offset(id, time.Minute), now).Next(now)] = true
+	}
+
+	if len(runs) < 10 {
+		t.Errorf("20 checks run at %d different times, want them spread", len(runs))
+	}
+}
@@ -0,0 +1,146 @@
+package scheduling
+
+import (
+	"sync"
+	"time"
+)
+
+// Limiter caps how many checks run at once, overall and per host. Checks over a limit wait
+// for a running one to finish. A limit of 0 means unlimited.
+type Limiter struct {
+	mu          sync.Mutex
+	cond        *sync.Cond
+	global      int
+	perHost     int
+	running     int
+	hostRunning map[int]int
+	waiting     map[int]time.Time
+	nextTicket  int
+	started     int64
+	delayed     int64
+	waited      time.Duration
+	maxWait     time.Duration
+}
+
+// LimiterMetrics is a snapshot of a Limiter
+type LimiterMetrics struct {
+	MaxConcurrent        int         `json:"max_concurrent"`
+	MaxConcurrentPerHost int         `json:"max_concurrent_per_host"`
+	Running              int         `json:"running"`
+	Waiting              int         `json:"waiting"`
+	LongestWaiting       string      `json:"longest_waiting"`
+	Started              int64       `json:"started"`
+	Delayed              int64       `json:"delayed"`
+	AverageWait          string      `json:"average_wait"`
+	MaxWait              string      `json:"max_wait"`
+	RunningPerHost       map[int]int `json:"running_per_host"`
+}
+
+// NewLimiter creates a Limiter
+func NewLimiter(global, perHost int) *Limiter {
+	l := &Limiter{
+		global:      global,
+		perHost:     perHost,
+		hostRunning: make(map[int]int),
+		waiting:     make(map[int]time.Time),
+	}
+	l.cond = sync.NewCond(&l.mu)
+	return l
+}
+
+// SetLimits changes the limits; waiting checks are let through if the new limits allow
+func (l *Limiter) SetLimits(global, perHost int) {
+	l.mu.Lock()
+	l.global = global
+	l.perHost = perHost
+	l.mu.Unlock()
+
+	l.cond.Broadcast()
+}
+
+// Acquire waits until a check on hostID may run, and returns the func that must be called
+// once it is done
+func (l *Limiter) Acquire(hostID int) func() {
+	l.mu.Lock()
+
+	start := time.Now()
+	ticket := l.nextTicket
+	l.nextTicket++
+
+	if l.full(hostID) {
+		l.delayed++
+		l.waiting[ticket] = start
+		for l.full(hostID) {
+			l.cond.Wait()
+		}
+		delete(l.waiting, ticket)
+	}
+
+	wait := time.Since(start)
+	l.waited += wait
+	if wait > l.maxWait {
+		l.maxWait = wait
+	}
+
+	l.started++
+	l.running++
+	l.hostRunning[hostID]++
+	l.mu.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			l.mu.Lock()
+			l.running--
+			l.hostRunning[hostID]--
+			if l.hostRunning[hostID] == 0 {
+				delete(l.hostRunning, hostID)
+			}
+			l.mu.Unlock()
+
+			l.cond.Broadcast()
+		})
+	}
+}
+
+// full reports whether a check on hostID has to wait; l.mu must be held
+func (l *Limiter) full(hostID int) bool {
+	return (l.global > 0 && l.running >= l.global) || (l.perHost > 0 && l.hostRunning[hostID] >= l.perHost)
+}
+
+// Metrics returns the current state of the limiter
+func (l *Limiter) Metrics() LimiterMetrics {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	m := LimiterMetrics{
+		MaxConcurrent:        l.global,
+		MaxConcurrentPerHost: l.perHost,
+		Running:              l.running,
+		Waiting:              len(l.waiting),
+		Started:              l.started,
+		Delayed:              l.delayed,
+		MaxWait:              l.maxWait.Round(time.Millisecond).String(),
+		RunningPerHost:       make(map[int]int, len(l.hostRunning)),
+	}
+
+	for host, n := range l.hostRunning {
+		m.RunningPerHost[host] = n
+	}
+
+	var longest time.Duration
+	for _, since := range l.waiting {
+		if wait := time.Since(since); wait > longest {
+			longest = wait
+		}
+	}
+	m.LongestWaiting = longest.Round(time.Millisecond).String()
+
+	if l.started > 0 {
+		m.AverageWait = (l.waited / time.Duration(l.started)).Round(time.Millisecond).String()
+	} else {
+		m.AverageWait = "0s"
+	}
+
+	return m
+}
@@ -0,0 +1,120 @@
+package scheduling
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLimiterConcurrentUse(t *testing.T) {
+	const global, perHost = 4, 2
+
+	l := NewLimiter(global, perHost)
+
+	var running int64
+	var hostRunning [3]int64
+	var wg sync.WaitGroup
+
+	for i := 0; i < 60; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			host := i % len(hostRunning)
+			release := l.Acquire(host)
+
+			if n := atomic.AddInt64(&running, 1); n > global {
+				t.Errorf("%d checks running, limit is %d", n, global)
+			}
+			if n := atomic.AddInt64(&hostRunning[host], 1); n > perHost {
+				t.Errorf("%d checks running on host %d, limit is %d", n, host, perHost)
+			}
+
+			time.Sleep(time.Millisecond)
+
+			atomic.AddInt64(&hostRunning[host], -1)
+			atomic.AddInt64(&running, -1)
+			release()
+			// releasing twice does nothing
+			release()
+		}(i)
+	}
+
+	// metrics are read while checks come and go
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 50; i++ {
+			m := l.Metrics()
+			if m.Running > global {
+				t.Errorf("metrics show %d running, limit is %d", m.Running, global)
+			}
+			time.Sleep(100 * time.Microsecond)
+		}
+	}()
+
+	wg.Wait()
+	<-done
+
+	m := l.Metrics()
+	if m.Running != 0 || m.Waiting != 0 || len(m.RunningPerHost) != 0 {
+		t.Errorf("checks left after all were released: %+v", m)
+	}
+	if m.Started != 60 {
+		t.Errorf("started = %d, want 60", m.Started)
+	}
+}
+
+func TestLimiterSetLimits(t *testing.T) {
+	l := NewLimiter(1, 0)
+
+	release := l.Acquire(1)
+
+	acquired := make(chan func())
+	go func() {
+		acquired <- l.Acquire(2)
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("a second check ran over the limit")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	if m := l.Metrics(); m.Waiting != 1 || m.Delayed != 1 {
+		t.Errorf("waiting = %d, delayed = %d; want 1 and 1", m.Waiting, m.Delayed)
+	}
+
+	// raising the limit lets the waiting check through
+	l.SetLimits(2, 0)
+
+	select {
+	case second := <-acquired:
+		second()
+	case <-time.After(time.Second):
+		t.Fatal("the waiting check was not let through by the new limit")
+	}
+
+	release()
+
+	// a limit of 0 is unlimited, concurrent changes included
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			l.SetLimits(i%3, i%2)
+		}(i)
+		go func(i int) {
+			defer wg.Done()
+			l.Acquire(i)()
+		}(i)
+	}
+	l.SetLimits(0, 0)
+	wg.Wait()
+
+	if m := l.Metrics(); m.Running != 0 {
+		t.Errorf("running = %d after all were released", m.Running)
+	}
+}
@@ -0,0 +1,185 @@
+package scheduling
+
+import (
+	"context"
+	"github.com/robfig/cron/v3"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Manager owns the monitoring scheduler and keeps track of which entry checks which host
+// service. All of its methods are safe for concurrent use.
+type Manager struct {
+	mu      sync.RWMutex
+	cron    *cron.Cron
+	entries map[int]managed
+	running bool
+}
+
+// managed is what the Manager knows about the entry of a host service
+type managed struct {
+	id  cron.EntryID
+	key string
+}
+
+// ManagedEntry is the scheduler entry of a host service. Key identifies the schedule it was
+// added with (see Key), to tell whether it is still up-to-date.
+type ManagedEntry struct {
+	HostServiceID int
+	Key           string
+	Entry         cron.Entry
+}
+
+// NewManager creates a Manager around a (not yet started) cron scheduler
+func NewManager(c *cron.Cron) *Manager {
+	return &Manager{
+		cron:    c,
+		entries: make(map[int]managed),
+	}
+}
+
+// Location returns the time zone of the scheduler
+func (m *Manager) Location() *time.Location {
+	return m.cron.Location()
+}
+
+// Add schedules job for a host service, replacing the entry it had
+func (m *Manager) Add(hostServiceID int, key string, schedule cron.Schedule, job cron.Job) cron.EntryID {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if old, ok := m.entries[hostServiceID]; ok {
+		m.cron.Remove(old.id)
+	}
+
+	id := m.cron.Schedule(schedule, job)
+	m.entries[hostServiceID] = managed{id: id, key: key}
+
+	return id
+}
+
+// Reschedule replaces the schedule of a host service. It reports false, and schedules
+// nothing, when the host service was not scheduled.
+func (m *Manager) Reschedule(hostServiceID int, key string, schedule cron.Schedule, job cron.Job) (cron.EntryID, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	old, ok := m.entries[hostServiceID]
+	if !ok {
+		return 0, false
+	}
+
+	m.cron.Remove(old.id)
+	id := m.cron.Schedule(schedule, job)
+	m.entries[hostServiceID] = managed{id: id, key: key}
+
+	return id, true
+}
+
+// Remove unschedules a host service, reporting whether it was scheduled
+func (m *Manager) Remove(hostServiceID int) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e, ok := m.entries[hostServiceID]
+	if !ok {
+		return false
+	}
+
+	m.cron.Remove(e.id)
+	delete(m.entries, hostServiceID)
+
+	return true
+}
+
+// RemoveAll unschedules every host service
+func (m *Manager) RemoveAll() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for hostServiceID, e := range m.entries {
+		m.cron.Remove(e.id)
+		delete(m.entries, hostServiceID)
+	}
+}
+
+// Entry returns the scheduler entry of a host service
+func (m *Manager) Entry(hostServiceID int) (cron.Entry, bool) {
+	m.mu.RLock()
+	e, ok := m.entries[hostServiceID]
+	m.mu.RUnlock()
+
+	if !ok {
+		return cron.Entry{}, false
+	}
+
+	entry := m.cron.Entry(e.id)
+	return entry, entry.Valid()
+}
+
+// Key returns the key a host service was scheduled with
+func (m *Manager) Key(hostServiceID int) (string, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	e, ok := m.entries[hostServiceID]
+	return e.key, ok
+}
+
+// List returns the entries of all scheduled host services, ordered by host service id
+func (m *Manager) List() []ManagedEntry {
+	m.mu.RLock()
+	entries := make(map[int]managed, len(m.entries))
+	for hostServiceID, e := range m.entries {
+		entries[hostServiceID] = e
+	}
+	m.mu.RUnlock()
+
+	list := make([]ManagedEntry, 0, len(entries))
+	for hostServiceID, e := range entries {
+		if entry := m.cron.Entry(e.id); entry.Valid() {
+			list = append(list, ManagedEntry{HostServiceID: hostServiceID, Key: e.key, Entry: entry})
+		}
+	}
+
+	sort.Slice(list, func(i, j int) bool { return list[i].HostServiceID < list[j].HostServiceID })
+
+	return list
+}
+
+// Len returns how many host services are scheduled
+func (m *Manager) Len() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return len(m.entries)
+}
+
+// Start starts the scheduler, if it is not running yet
+func (m *Manager) Start() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.running {
+		m.cron.Start()
+		m.running = true
+	}
+}
+
+// Stop stops the scheduler. The returned context is done once running checks have finished.
+func (m *Manager) Stop() context.Context {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.running = false
+	return m.cron.Stop()
+}
+
+// Running reports whether the scheduler is started
+func (m *Manager) Running() bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.running
+}
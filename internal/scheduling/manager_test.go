@@ -0,0 +1,94 @@
+package scheduling
+
+import (
+	"github.com/robfig/cron/v3"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestManagerConcurrentUse(t *testing.T) {
+	m := NewManager(cron.New())
+	m.Start()
+	defer m.Stop()
+
+	var runs sync.WaitGroup
+	job := cron.FuncJob(func() {})
+
+	const workers = 8
+	const hostServices = 20
+
+	for w := 0; w < workers; w++ {
+		runs.Add(1)
+		go func(w int) {
+			defer runs.Done()
+
+			for i := 0; i < 200; i++ {
+				id := (w*7 + i) % hostServices
+				key := strconv.Itoa(i)
+
+				switch i % 6 {
+				case 0, 1:
+					m.Add(id, key, cron.Every(time.Hour), job)
+				case 2:
+					m.Reschedule(id, key, cron.Every(time.Minute), job)
+				case 3:
+					m.Remove(id)
+				case 4:
+					for _, e := range m.List() {
+						if e.HostServiceID < 0 || e.HostServiceID >= hostServices {
+							t.Errorf("unexpected host service %d in the list", e.HostServiceID)
+						}
+					}
+				case 5:
+					if entry, ok := m.Entry(id); ok && !entry.Valid() {
+						t.Errorf("entry of host service %d is not valid", id)
+					}
+					m.Key(id)
+					m.Len()
+				}
+			}
+		}(w)
+	}
+
+	runs.Wait()
+
+	// every host service has at most one entry in the scheduler
+	if got, want := len(m.List()), m.Len(); got != want {
+		t.Errorf("List has %d entries, Len is %d", got, want)
+	}
+	if got := len(m.cron.Entries()); got != m.Len() {
+		t.Errorf("the scheduler has %d entries for %d host services", got, m.Len())
+	}
+
+	m.RemoveAll()
+	if m.Len() != 0 || len(m.cron.Entries()) != 0 {
+		t.Errorf("entries left after RemoveAll: %d managed, %d scheduled", m.Len(), len(m.cron.Entries()))
+	}
+}
+
+func TestManagerReschedule(t *testing.T) {
+	m := NewManager(cron.New())
+	job := cron.FuncJob(func() {})
+
+	if _, ok := m.Reschedule(1, "a", cron.Every(time.Minute), job); ok {
+		t.Fatal("a host service that was not scheduled was rescheduled")
+	}
+	if m.Len() != 0 {
+		t.Fatalf("Reschedule added an entry")
+	}
+
+	first := m.Add(1, "a", cron.Every(time.Minute), job)
+	second, ok := m.Reschedule(1, "b", cron.Every(time.Hour), job)
+	if !ok || second == first {
+		t.Fatalf("Reschedule = %d, %v; want a new entry", second, ok)
+	}
+
+	if key, _ := m.Key(1); key != "b" {
+		t.Errorf("key = %q, want b", key)
+	}
+	if len(m.cron.Entries()) != 1 {
+		t.Errorf("the old entry was not removed")
+	}
+}
@@ -0,0 +1,232 @@
+package scheduling
+
+import (
+	"errors"
+	"fmt"
+	"github.com/robfig/cron/v3"
+	"golang-observer-project/internal/models"
+	"strings"
+	"time"
+)
+
+// parser accepts the same expressions as the scheduler: five fields or a descriptor such as
+// @hourly or @every 5m
+var parser = cron.NewParser(cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+
+// maxSearch bounds how far ahead a run inside the active hours is looked for
+const maxSearch = 366 * 24 * time.Hour
+
+// Spec returns the cron spec of a host service: its cron expression, or @every built from
+// SchedulerNumber and SchedulerUnit (with d converted to hours, which @every does not know)
+func Spec(hs models.HostServices) string {
+	if hs.CronExpression != "" {
+		return hs.CronExpression
+	}
+
+	if hs.SchedulerUnit == "d" {
+		return fmt.Sprintf("@every %dh", hs.SchedulerNumber*24)
+	}
+
+	return fmt.Sprintf("@every %d%s", hs.SchedulerNumber, hs.SchedulerUnit)
+}
+
+// Key identifies everything a host service's scheduler entry is built from; when it
+// changes, the entry has to be rescheduled
+func Key(hs models.HostServices) string {
+	return fmt.Sprintf("%d|%s|%s|%s", hs.HostID, Spec(hs), hs.TimeZone, hs.ActiveHours)
+}
+
+// Describe returns the schedule of a host service as shown to users
+func Describe(hs models.HostServices) string {
+	text := fmt.Sprintf("@every %d%s", hs.SchedulerNumber, hs.SchedulerUnit)
+	if hs.CronExpression != "" {
+		text = hs.CronExpression
+	}
+
+	if hs.TimeZone != "" {
+		text += " (" + hs.TimeZone + ")"
+	}
+
+	if hs.ActiveHours != "" {
+		text += ", only " + hs.ActiveHours
+	}
+
+	return text
+}
+
+// New builds the schedule of a host service. Cron expressions and active hours are in the
+// host service's time zone, or in loc when it has none.
+func New(hs models.HostServices, loc *time.Location) (cron.Schedule, error) {
+	if hs.TimeZone != "" {
+		var err error
+		loc, err = time.LoadLocation(hs.TimeZone)
+		if err != nil {
+			return nil, fmt.Errorf("unknown time zone %q", hs.TimeZone)
+		}
+	}
+
+	if hs.CronExpression == "" {
+		if hs.SchedulerNumber < 1 {
+			return nil, errors.New("the interval must be at least 1")
+		}
+
+		switch hs.SchedulerUnit {
+		case "s", "m", "h", "d":
+		default:
+			return nil, fmt.Errorf("unknown interval unit %q, use s, m, h or d", hs.SchedulerUnit)
+		}
+	}
+
+	if strings.HasPrefix(hs.CronExpression, "CRON_TZ=") || strings.HasPrefix(hs.CronExpression, "TZ=") {
+		return nil, errors.New("set the time zone separately, not in the cron expression")
+	}
+
+	spec := Spec(hs)
+	if hs.CronExpression != "" && !strings.HasPrefix(spec, "@every") {
+		spec = "CRON_TZ=" + loc.String() + " " + spec
+	}
+
+	schedule, err := parser.Parse(spec)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schedule %q: %s", Spec(hs), err)
+	}
+
+	if hs.ActiveHours == "" {
+		return schedule, nil
+	}
+
+	windows, err := ParseActiveHours(hs.ActiveHours)
+	if err != nil {
+		return nil, err
+	}
+
+	return &activeSchedule{schedule: schedule, windows: windows, loc: loc}, nil
+}
+
+// Validate checks that a host service's schedule can be built and will ever run
+func Validate(hs models.HostServices, loc *time.Location) error {
+	schedule, err := New(hs, loc)
+	if err != nil {
+		return err
+	}
+
+	if schedule.Next(time.Now()).IsZero() {
+		return errors.New("the schedule never runs within the active hours")
+	}
+
+	return nil
+}
+
+// NextRuns returns up to n upcoming runs of schedule after from
+func NextRuns(schedule cron.Schedule, from time.Time, n int) []time.Time {
+	var runs []time.Time
+
+	t := from
+	for i := 0; i < n; i++ {
+		t = schedule.Next(t)
+		if t.IsZero() {
+			break
+		}
+		runs = append(runs, t)
+	}
+
+	return runs
+}
+
+// Humanize formats a run time for people, e.g. "Mon 2 Jan 15:04 +03 (in 5m)"
+func Humanize(t, now time.Time) string {
+	in := t.Sub(now).Round(time.Second)
+
+	var relative string
+	switch {
+	case in <= 0:
+		relative = "due now"
+	case in < time.Minute:
+		relative = fmt.Sprintf("in %ds", int(in.Seconds()))
+	case in < time.Hour:
+		relative = fmt.Sprintf("in %dm", int(in.Minutes()))
+	case in < 48*time.Hour:
+		relative = fmt.Sprintf("in %dh%02dm", int(in.Hours()), int(in.Minutes())%60)
+	default:
+		relative = fmt.Sprintf("in %d days", int(in.Hours()/24))
+	}
+
+	return fmt.Sprintf("%s (%s)", t.Format("Mon 2 Jan 2006 15:04:05 MST"), relative)
+}
+
+// activeSchedule only lets the runs of schedule through that fall inside one of its windows
+type activeSchedule struct {
+	schedule cron.Schedule
+	windows  []Window
+	loc      *time.Location
+	// offset is how long after a window opens an interval schedule starts again, see Jitter
+	offset time.Duration
+}
+
+// Next returns the next run of the underlying schedule inside the active hours. Interval
+// schedules start again when a window opens, or offset after.
+func (s *activeSchedule) Next(t time.Time) time.Time {
+	interval := isInterval(s.schedule)
+	limit := t.Add(maxSearch)
+
+	next := s.schedule.Next(t)
+	for !next.IsZero() && next.Before(limit) {
+		if s.active(next) {
+			return next
+		}
+
+		opens := s.nextOpening(next)
+		if opens.IsZero() {
+			break
+		}
+
+		if interval {
+			if start := opens.Add(s.offset); s.active(start) {
+				return start
+			}
+			return opens
+		}
+
+		next = s.schedule.Next(opens.Add(-time.Second))
+	}
+
+	return time.Time{}
+}
+
+// active reports whether t is inside one of the windows
+func (s *activeSchedule) active(t time.Time) bool {
+	local := t.In(s.loc)
+	for _, w := range s.windows {
+		if w.Contains(local) {
+			return true
+		}
+	}
+	return false
+}
+
+// nextOpening returns when the next window after t opens, or the zero time when none does
+func (s *activeSchedule) nextOpening(t time.Time) time.Time {
+	local := t.In(s.loc)
+	midnight := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, s.loc)
+
+	var earliest time.Time
+	for day := 0; day <= 7; day++ {
+		date := midnight.AddDate(0, 0, day)
+		for _, w := range s.windows {
+			if !w.Days[date.Weekday()] {
+				continue
+			}
+
+			opens := date.Add(w.Start)
+			if opens.After(local) && (earliest.IsZero() || opens.Before(earliest)) {
+				earliest = opens
+			}
+		}
+
+		if !earliest.IsZero() {
+			return earliest
+		}
+	}
+
+	return earliest
+}
@@ -0,0 +1,89 @@
+package sms
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"text/template"
+)
+
+// HTTPProvider sends text messages to any gateway that accepts a templated HTTP request.
+// The body template gets .To and .Message, and a json function for safe quoting, e.g.
+//
+//	{"to": {{ json .To }}, "text": {{ json .Message }}}
+type HTTPProvider struct {
+	URL         string
+	Method      string
+	ContentType string
+	AuthHeader  string
+	body        *template.Template
+}
+
+// NewHTTPProvider creates a generic HTTP provider, parsing the body template up front
+func NewHTTPProvider(url, method, contentType, authHeader, bodyTemplate string) (*HTTPProvider, error) {
+	if url == "" {
+		return nil, errors.New("sms http url is required")
+	}
+
+	if method == "" {
+		method = "POST"
+	}
+
+	if contentType == "" {
+		contentType = "application/json"
+	}
+
+	t, err := template.New("sms").Funcs(template.FuncMap{
+		"json": func(v interface{}) (string, error) {
+			out, err := json.Marshal(v)
+			return string(out), err
+		},
+	}).Parse(bodyTemplate)
+	if err != nil {
+		return nil, err
+	}
+
+	return &HTTPProvider{
+		URL:         url,
+		Method:      method,
+		ContentType: contentType,
+		AuthHeader:  authHeader,
+		body:        t,
+	}, nil
+}
+
+// Name identifies the provider
+func (h *HTTPProvider) Name() string {
+	return "http"
+}
+
+// Send renders the body template and sends it to the gateway. Gateways answer in too many
+// different ways to parse, so the returned message id is always empty.
+func (h *HTTPProvider) Send(to, msg string) (string, error) {
+	var buf bytes.Buffer
+	err := h.body.Execute(&buf, struct {
+		To      string
+		Message string
+	}{to, msg})
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequest(h.Method, h.URL, &buf)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", h.ContentType)
+	if h.AuthHeader != "" {
+		req.Header.Set("Authorization", h.AuthHeader)
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+
+	return "", checkResponse(h.Name(), res)
+}
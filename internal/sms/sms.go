@@ -0,0 +1,61 @@
+package sms
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// SMSProvider sends text messages through an SMS gateway
+type SMSProvider interface {
+	// Name identifies the provider in logs
+	Name() string
+	// Send delivers msg to the phone number to and returns the provider's message id
+	Send(to, msg string) (string, error)
+}
+
+// client is shared by all providers
+var client = &http.Client{Timeout: 10 * time.Second}
+
+// NewProvider builds the SMS provider selected by the sms_provider preference,
+// defaulting to Twilio
+func NewProvider(preferenceMap map[string]string) (SMSProvider, error) {
+	switch preferenceMap["sms_provider"] {
+	case "", "twilio":
+		return &TwilioProvider{
+			APIURL:     preferenceMap["twilio_api_url"],
+			AccountSID: preferenceMap["twilio_sid"],
+			AuthToken:  preferenceMap["twilio_auth_token"],
+			From:       preferenceMap["twilio_phone_number"],
+		}, nil
+	case "vonage", "nexmo":
+		return &VonageProvider{
+			APIURL:    preferenceMap["vonage_api_url"],
+			APIKey:    preferenceMap["vonage_api_key"],
+			APISecret: preferenceMap["vonage_api_secret"],
+			From:      preferenceMap["vonage_from"],
+		}, nil
+	case "http":
+		return NewHTTPProvider(
+			preferenceMap["sms_http_url"],
+			preferenceMap["sms_http_method"],
+			preferenceMap["sms_http_content_type"],
+			preferenceMap["sms_http_auth_header"],
+			preferenceMap["sms_http_body_template"],
+		)
+	default:
+		return nil, fmt.Errorf("unknown sms provider %q", preferenceMap["sms_provider"])
+	}
+}
+
+// checkResponse turns a non 2xx gateway response into an error that includes the response body
+func checkResponse(provider string, res *http.Response) error {
+	if res.StatusCode >= 200 && res.StatusCode < 300 {
+		return nil
+	}
+
+	body, _ := io.ReadAll(io.LimitReader(res.Body, 512))
+	return fmt.Errorf("%s responded with %s: %s", provider, res.Status, strings.TrimSpace(string(body)))
+}
@@ -3,45 +3,69 @@ package sms
 import (
 	"encoding/json"
 	"errors"
-	"golang-observer-project/internal/config"
-	"log"
+	"fmt"
 	"net/http"
 	"net/url"
 	"strings"
 )
 
-func SendTextTwilio(to, msg string, app *config.AppConfig) error {
-	secret := app.PreferenceMap["twilio_auth_token"]
-	accountSid := app.PreferenceMap["twilio_sid"]
+// TwilioAPIURL is the default base URL of the Twilio REST API
+const TwilioAPIURL = "https://api.twilio.com"
 
-	urlString := "https://api.twilio.com/2010-04-01/Accounts/" + accountSid + "/Messages.json"
+// TwilioProvider sends text messages with the Twilio Messages API
+type TwilioProvider struct {
+	APIURL     string
+	AccountSID string
+	AuthToken  string
+	From       string
+}
+
+// Name identifies the provider
+func (t *TwilioProvider) Name() string {
+	return "twilio"
+}
+
+// Send sends a text message and returns the Twilio message sid
+func (t *TwilioProvider) Send(to, msg string) (string, error) {
+	if t.AccountSID == "" || t.AuthToken == "" {
+		return "", errors.New("twilio sid and auth token are required")
+	}
+
+	baseURL := t.APIURL
+	if baseURL == "" {
+		baseURL = TwilioAPIURL
+	}
+	urlString := fmt.Sprintf("%s/2010-04-01/Accounts/%s/Messages.json", strings.TrimSuffix(baseURL, "/"), t.AccountSID)
 
 	msgData := url.Values{}
 	msgData.Set("To", to)
-	msgData.Set("From", app.PreferenceMap["twilio_phone_number"])
+	msgData.Set("From", t.From)
 	msgData.Set("Body", msg)
-	msgDataReader := *strings.NewReader(msgData.Encode())
 
-	client := &http.Client{}
-	req, _ := http.NewRequest("POST", urlString, &msgDataReader)
-	req.SetBasicAuth(accountSid, secret)
+	req, err := http.NewRequest("POST", urlString, strings.NewReader(msgData.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.SetBasicAuth(t.AccountSID, t.AuthToken)
 	req.Header.Add("Accept", "application/json")
 	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
 
-	res, _ := client.Do(req)
-	if res.StatusCode >= 200 && res.StatusCode < 300 {
-		var data map[string]interface{}
-		decoder := json.NewDecoder(res.Body)
-		err := decoder.Decode(&data)
-		if err == nil {
-			log.Printf("Twilio response: %s", data["sid"])
-			return err
-		}
-	} else {
-		log.Println("Twilio error: ", res.Status)
-		return errors.New("failed to send sms")
+	res, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+
+	if err := checkResponse(t.Name(), res); err != nil {
+		return "", err
 	}
 
-	return nil
+	var data struct {
+		Sid string `json:"sid"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&data); err != nil {
+		return "", fmt.Errorf("decoding twilio response: %w", err)
+	}
 
+	return data.Sid, nil
 }
@@ -0,0 +1,77 @@
+package sms
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// VonageAPIURL is the default base URL of the Vonage (formerly Nexmo) SMS API
+const VonageAPIURL = "https://rest.nexmo.com"
+
+// VonageProvider sends text messages with the Vonage SMS API
+type VonageProvider struct {
+	APIURL    string
+	APIKey    string
+	APISecret string
+	From      string
+}
+
+// Name identifies the provider
+func (v *VonageProvider) Name() string {
+	return "vonage"
+}
+
+// Send sends a text message and returns the Vonage message id
+func (v *VonageProvider) Send(to, msg string) (string, error) {
+	if v.APIKey == "" || v.APISecret == "" {
+		return "", errors.New("vonage api key and secret are required")
+	}
+
+	baseURL := v.APIURL
+	if baseURL == "" {
+		baseURL = VonageAPIURL
+	}
+
+	msgData := url.Values{}
+	msgData.Set("api_key", v.APIKey)
+	msgData.Set("api_secret", v.APISecret)
+	msgData.Set("from", v.From)
+	msgData.Set("to", strings.TrimPrefix(to, "+"))
+	msgData.Set("text", msg)
+
+	res, err := client.PostForm(strings.TrimSuffix(baseURL, "/")+"/sms/json", msgData)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+
+	if err := checkResponse(v.Name(), res); err != nil {
+		return "", err
+	}
+
+	// Vonage answers 200 even for rejected messages, the outcome is per message
+	var data struct {
+		Messages []struct {
+			Status    string `json:"status"`
+			MessageID string `json:"message-id"`
+			ErrorText string `json:"error-text"`
+		} `json:"messages"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&data); err != nil {
+		return "", fmt.Errorf("decoding vonage response: %w", err)
+	}
+
+	if len(data.Messages) == 0 {
+		return "", errors.New("vonage response contained no messages")
+	}
+
+	m := data.Messages[0]
+	if m.Status != "0" {
+		return "", fmt.Errorf("vonage rejected message with status %s: %s", m.Status, m.ErrorText)
+	}
+
+	return m.MessageID, nil
+}
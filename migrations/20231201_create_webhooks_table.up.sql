@@ -0,0 +1,19 @@
+-- Create table
+CREATE TABLE "webhooks"
+(
+    "id"               serial PRIMARY KEY,
+    "name"             varchar(255),
+    "url"              varchar(1024),
+    "secret"           varchar(255) DEFAULT '',
+    "payload_template" text         DEFAULT '',
+    "active"           integer      DEFAULT 1,
+    "created_at"       timestamp    DEFAULT now(),
+    "updated_at"       timestamp    DEFAULT now()
+);
+
+-- Create trigger
+CREATE TRIGGER set_timestamp
+    BEFORE UPDATE
+    ON webhooks
+    FOR EACH ROW
+EXECUTE PROCEDURE trigger_set_timestamp();
@@ -0,0 +1,21 @@
+-- Create table
+CREATE TABLE "notification_log"
+(
+    "id"              serial PRIMARY KEY,
+    "channel"         varchar(255),
+    "target"          varchar(1024),
+    "host_service_id" integer,
+    "event_type"      varchar(255),
+    "status"          varchar(255),
+    "attempts"        integer   DEFAULT 0,
+    "error"           text      DEFAULT '',
+    "created_at"      timestamp NOT NULL DEFAULT NOW(),
+    "updated_at"      timestamp NOT NULL DEFAULT NOW()
+);
+
+-- Create trigger
+CREATE TRIGGER set_timestamp
+    BEFORE UPDATE
+    ON notification_log
+    FOR EACH ROW
+EXECUTE PROCEDURE trigger_set_timestamp();
@@ -0,0 +1,19 @@
+-- Create table
+CREATE TABLE "host_tags"
+(
+    "id"         serial PRIMARY KEY,
+    "host_id"    integer,
+    "tag"        varchar(255),
+    "created_at" timestamp DEFAULT now(),
+    "updated_at" timestamp DEFAULT now()
+);
+
+CREATE UNIQUE INDEX host_tags_host_id_tag_idx ON host_tags (host_id, tag);
+
+-- Add foreign key constraint for host_id
+ALTER TABLE "host_tags"
+    ADD CONSTRAINT fk_host_tags_host_id
+        FOREIGN KEY ("host_id")
+            REFERENCES "hosts" ("id")
+            ON DELETE CASCADE
+            ON UPDATE CASCADE;
@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS user_subscriptions;
+DROP TABLE IF EXISTS user_contact_methods;
+
+ALTER TABLE "users"
+    DROP COLUMN "time_zone",
+    DROP COLUMN "quiet_hours_start",
+    DROP COLUMN "quiet_hours_end";
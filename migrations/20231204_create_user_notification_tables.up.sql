@@ -0,0 +1,55 @@
+ALTER TABLE "users"
+    ADD COLUMN "time_zone" varchar(255) DEFAULT 'UTC',
+    ADD COLUMN "quiet_hours_start" varchar(5) DEFAULT '',
+    ADD COLUMN "quiet_hours_end" varchar(5) DEFAULT '';
+
+-- Create table
+CREATE TABLE "user_contact_methods"
+(
+    "id"          serial PRIMARY KEY,
+    "user_id"     integer,
+    "method_type" varchar(255),
+    "address"     varchar(1024),
+    "created_at"  timestamp DEFAULT now(),
+    "updated_at"  timestamp DEFAULT now()
+);
+
+-- Create trigger
+CREATE TRIGGER set_timestamp
+    BEFORE UPDATE
+    ON user_contact_methods
+    FOR EACH ROW
+EXECUTE PROCEDURE trigger_set_timestamp();
+
+ALTER TABLE "user_contact_methods"
+    ADD CONSTRAINT fk_user_contact_methods_user_id
+        FOREIGN KEY ("user_id")
+            REFERENCES "users" ("id")
+            ON DELETE CASCADE
+            ON UPDATE CASCADE;
+
+-- Create table
+CREATE TABLE "user_subscriptions"
+(
+    "id"         serial PRIMARY KEY,
+    "user_id"    integer,
+    "host_id"    integer      DEFAULT 0,
+    "tag"        varchar(255) DEFAULT '',
+    "severity"   varchar(255) DEFAULT '',
+    "created_at" timestamp    DEFAULT now(),
+    "updated_at" timestamp    DEFAULT now()
+);
+
+-- Create trigger
+CREATE TRIGGER set_timestamp
+    BEFORE UPDATE
+    ON user_subscriptions
+    FOR EACH ROW
+EXECUTE PROCEDURE trigger_set_timestamp();
+
+ALTER TABLE "user_subscriptions"
+    ADD CONSTRAINT fk_user_subscriptions_user_id
+        FOREIGN KEY ("user_id")
+            REFERENCES "users" ("id")
+            ON DELETE CASCADE
+            ON UPDATE CASCADE;
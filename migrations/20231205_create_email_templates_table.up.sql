@@ -0,0 +1,17 @@
+-- Create table
+CREATE TABLE "email_templates"
+(
+    "id"         serial PRIMARY KEY,
+    "name"       varchar(255) UNIQUE,
+    "subject"    text,
+    "body"       text,
+    "created_at" timestamp DEFAULT now(),
+    "updated_at" timestamp DEFAULT now()
+);
+
+-- Create trigger
+CREATE TRIGGER set_timestamp
+    BEFORE UPDATE
+    ON email_templates
+    FOR EACH ROW
+EXECUTE PROCEDURE trigger_set_timestamp();
@@ -0,0 +1,21 @@
+-- Create table
+CREATE TABLE "mail_outbox"
+(
+    "id"              serial PRIMARY KEY,
+    "message"         jsonb        NOT NULL,
+    "status"          varchar(255) NOT NULL DEFAULT 'pending',
+    "attempts"        integer      NOT NULL DEFAULT 0,
+    "next_attempt_at" timestamp    NOT NULL DEFAULT NOW(),
+    "last_error"      text         NOT NULL DEFAULT '',
+    "created_at"      timestamp    NOT NULL DEFAULT NOW(),
+    "updated_at"      timestamp    NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX "mail_outbox_status_next_attempt_at_idx" ON "mail_outbox" ("status", "next_attempt_at");
+
+-- Create trigger
+CREATE TRIGGER set_timestamp
+    BEFORE UPDATE
+    ON mail_outbox
+    FOR EACH ROW
+EXECUTE PROCEDURE trigger_set_timestamp();
@@ -0,0 +1,4 @@
+ALTER TABLE "host_services"
+    DROP COLUMN "cron_expression",
+    DROP COLUMN "time_zone",
+    DROP COLUMN "active_hours";
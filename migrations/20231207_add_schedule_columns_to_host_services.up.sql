@@ -0,0 +1,4 @@
+ALTER TABLE "host_services"
+    ADD COLUMN "cron_expression" varchar(255) NOT NULL DEFAULT '',
+    ADD COLUMN "time_zone"       varchar(255) NOT NULL DEFAULT '',
+    ADD COLUMN "active_hours"    varchar(255) NOT NULL DEFAULT '';
@@ -0,0 +1,27 @@
+-- Create table
+CREATE TABLE "check_jobs"
+(
+    "id"              serial PRIMARY KEY,
+    "host_service_id" integer      NOT NULL REFERENCES host_services (id) ON DELETE CASCADE,
+    "host_id"         integer      NOT NULL,
+    "status"          varchar(255) NOT NULL DEFAULT 'queued',
+    "attempts"        integer      NOT NULL DEFAULT 0,
+    "locked_by"       varchar(255) NOT NULL DEFAULT '',
+    "locked_until"    timestamp    NOT NULL DEFAULT NOW(),
+    "last_error"      text         NOT NULL DEFAULT '',
+    "created_at"      timestamp    NOT NULL DEFAULT NOW(),
+    "updated_at"      timestamp    NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX "check_jobs_status_created_at_idx" ON "check_jobs" ("status", "created_at");
+
+-- A host service is queued at most once until a worker has finished its check
+CREATE UNIQUE INDEX "check_jobs_host_service_id_open_idx" ON "check_jobs" ("host_service_id")
+    WHERE status IN ('queued', 'running');
+
+-- Create trigger
+CREATE TRIGGER set_timestamp
+    BEFORE UPDATE
+    ON check_jobs
+    FOR EACH ROW
+EXECUTE PROCEDURE trigger_set_timestamp();
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS probe_results;
+DROP TABLE IF EXISTS host_service_probes;
+DROP TABLE IF EXISTS probes;
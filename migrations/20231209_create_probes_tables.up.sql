@@ -0,0 +1,38 @@
+-- Create tables
+CREATE TABLE "probes"
+(
+    "id"           serial PRIMARY KEY,
+    "name"         varchar(255) NOT NULL UNIQUE,
+    "location"     varchar(255) NOT NULL DEFAULT '',
+    "token_hash"   varchar(255) NOT NULL DEFAULT '',
+    "active"       integer      NOT NULL DEFAULT 1,
+    "last_seen_at" timestamp    NOT NULL DEFAULT NOW(),
+    "created_at"   timestamp    NOT NULL DEFAULT NOW(),
+    "updated_at"   timestamp    NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX "probes_token_hash_idx" ON "probes" ("token_hash");
+
+CREATE TABLE "host_service_probes"
+(
+    "host_service_id" integer NOT NULL REFERENCES host_services (id) ON DELETE CASCADE,
+    "probe_id"        integer NOT NULL REFERENCES probes (id) ON DELETE CASCADE,
+    PRIMARY KEY ("host_service_id", "probe_id")
+);
+
+CREATE TABLE "probe_results"
+(
+    "host_service_id" integer      NOT NULL REFERENCES host_services (id) ON DELETE CASCADE,
+    "probe_id"        integer      NOT NULL REFERENCES probes (id) ON DELETE CASCADE,
+    "status"          varchar(255) NOT NULL,
+    "message"         text         NOT NULL DEFAULT '',
+    "checked_at"      timestamp    NOT NULL DEFAULT NOW(),
+    PRIMARY KEY ("host_service_id", "probe_id")
+);
+
+-- Create trigger
+CREATE TRIGGER set_timestamp
+    BEFORE UPDATE
+    ON probes
+    FOR EACH ROW
+EXECUTE PROCEDURE trigger_set_timestamp();
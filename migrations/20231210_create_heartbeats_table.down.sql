@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS heartbeats;
+DELETE FROM host_services WHERE service_id = 4;
+DELETE FROM public.services WHERE id = 4;
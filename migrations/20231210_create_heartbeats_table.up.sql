@@ -0,0 +1,27 @@
+-- Add the heartbeat service
+INSERT INTO public.services (id, service_name, active, icon, created_at, updated_at)
+VALUES (4, 'Heartbeat', 1, 'fa fa-heartbeat', NOW(), NOW())
+ON CONFLICT (id) DO NOTHING;
+
+-- Create table
+CREATE TABLE "heartbeats"
+(
+    "host_service_id" integer      NOT NULL PRIMARY KEY REFERENCES host_services (id) ON DELETE CASCADE,
+    "token"           varchar(255) NOT NULL UNIQUE,
+    "period_seconds"  integer      NOT NULL DEFAULT 3600,
+    "grace_seconds"   integer      NOT NULL DEFAULT 300,
+    "last_signal"     varchar(255) NOT NULL DEFAULT '',
+    "last_signal_at"  timestamp,
+    "last_success_at" timestamp,
+    "last_start_at"   timestamp,
+    "last_log"        text         NOT NULL DEFAULT '',
+    "created_at"      timestamp    NOT NULL DEFAULT NOW(),
+    "updated_at"      timestamp    NOT NULL DEFAULT NOW()
+);
+
+-- Create trigger
+CREATE TRIGGER set_timestamp
+    BEFORE UPDATE
+    ON heartbeats
+    FOR EACH ROW
+EXECUTE PROCEDURE trigger_set_timestamp();
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS metric_thresholds;
+DROP TABLE IF EXISTS host_agents;
+DELETE FROM host_services WHERE service_id = 5;
+DELETE FROM public.services WHERE id = 5;
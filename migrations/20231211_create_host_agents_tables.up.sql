@@ -0,0 +1,46 @@
+-- Add the system service, reported by the host agent
+INSERT INTO public.services (id, service_name, active, icon, created_at, updated_at)
+VALUES (5, 'System', 1, 'fa fa-microchip', NOW(), NOW())
+ON CONFLICT (id) DO NOTHING;
+
+INSERT INTO host_services (host_id, service_id, active, scheduler_number, scheduler_unit, status, created_at, updated_at)
+SELECT h.id, 5, 0, 3, 'm', 'pending', NOW(), NOW()
+FROM hosts h
+WHERE NOT EXISTS (SELECT 1 FROM host_services hs WHERE hs.host_id = h.id AND hs.service_id = 5);
+
+-- Create tables
+CREATE TABLE "host_agents"
+(
+    "host_service_id"  integer      NOT NULL PRIMARY KEY REFERENCES host_services (id) ON DELETE CASCADE,
+    "token"            varchar(255) NOT NULL UNIQUE,
+    "interval_seconds" integer      NOT NULL DEFAULT 60,
+    "last_seen_at"     timestamp,
+    "last_metrics"     jsonb        NOT NULL DEFAULT '{}',
+    "created_at"       timestamp    NOT NULL DEFAULT NOW(),
+    "updated_at"       timestamp    NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE "metric_thresholds"
+(
+    "id"              serial PRIMARY KEY,
+    "host_service_id" integer          NOT NULL REFERENCES host_services (id) ON DELETE CASCADE,
+    "metric"          varchar(255)     NOT NULL,
+    "warning"         double precision NOT NULL,
+    "problem"         double precision NOT NULL,
+    "created_at"      timestamp        NOT NULL DEFAULT NOW(),
+    "updated_at"      timestamp        NOT NULL DEFAULT NOW(),
+    UNIQUE ("host_service_id", "metric")
+);
+
+-- Create triggers
+CREATE TRIGGER set_timestamp
+    BEFORE UPDATE
+    ON host_agents
+    FOR EACH ROW
+EXECUTE PROCEDURE trigger_set_timestamp();
+
+CREATE TRIGGER set_timestamp
+    BEFORE UPDATE
+    ON metric_thresholds
+    FOR EACH ROW
+EXECUTE PROCEDURE trigger_set_timestamp();
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS exec_checks;
+DELETE FROM host_services WHERE service_id = 6;
+DELETE FROM public.services WHERE id = 6;
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS transactions;
+DELETE FROM host_services WHERE service_id = 7;
+DELETE FROM public.services WHERE id = 7;
@@ -0,0 +1,26 @@
+-- Add the transaction service, ordered HTTP steps
+INSERT INTO public.services (id, service_name, active, icon, created_at, updated_at)
+VALUES (7, 'Transaction', 1, 'fa fa-exchange', NOW(), NOW())
+ON CONFLICT (id) DO NOTHING;
+
+INSERT INTO host_services (host_id, service_id, active, scheduler_number, scheduler_unit, status, created_at, updated_at)
+SELECT h.id, 7, 0, 3, 'm', 'pending', NOW(), NOW()
+FROM hosts h
+WHERE NOT EXISTS (SELECT 1 FROM host_services hs WHERE hs.host_id = h.id AND hs.service_id = 7);
+
+-- Create table
+CREATE TABLE "transactions"
+(
+    "host_service_id" integer   NOT NULL PRIMARY KEY REFERENCES host_services (id) ON DELETE CASCADE,
+    "steps"           jsonb     NOT NULL DEFAULT '[]',
+    "timeout_seconds" integer   NOT NULL DEFAULT 30,
+    "created_at"      timestamp NOT NULL DEFAULT NOW(),
+    "updated_at"      timestamp NOT NULL DEFAULT NOW()
+);
+
+-- Create trigger
+CREATE TRIGGER set_timestamp
+    BEFORE UPDATE
+    ON transactions
+    FOR EACH ROW
+EXECUTE PROCEDURE trigger_set_timestamp();
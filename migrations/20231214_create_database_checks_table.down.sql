@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS database_checks;
+DELETE FROM host_services WHERE service_id IN (8, 9, 10);
+DELETE FROM public.services WHERE id IN (8, 9, 10);
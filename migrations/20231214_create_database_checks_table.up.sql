@@ -0,0 +1,36 @@
+-- Add the database services
+INSERT INTO public.services (id, service_name, active, icon, created_at, updated_at)
+VALUES (8, 'PostgreSQL', 1, 'fa fa-database', NOW(), NOW()),
+       (9, 'MySQL', 1, 'fa fa-database', NOW(), NOW()),
+       (10, 'Redis', 1, 'fa fa-database', NOW(), NOW())
+ON CONFLICT (id) DO NOTHING;
+
+INSERT INTO host_services (host_id, service_id, active, scheduler_number, scheduler_unit, status, created_at, updated_at)
+SELECT h.id, s.id, 0, 3, 'm', 'pending', NOW(), NOW()
+FROM hosts h
+CROSS JOIN (VALUES (8), (9), (10)) AS s (id)
+WHERE NOT EXISTS (SELECT 1 FROM host_services hs WHERE hs.host_id = h.id AND hs.service_id = s.id);
+
+-- Create table
+CREATE TABLE "database_checks"
+(
+    "host_service_id"     integer          NOT NULL PRIMARY KEY REFERENCES host_services (id) ON DELETE CASCADE,
+    "address"             varchar(255)     NOT NULL DEFAULT '',
+    "username"            varchar(255)     NOT NULL DEFAULT '',
+    "password"            varchar(255)     NOT NULL DEFAULT '',
+    "database_name"       varchar(255)     NOT NULL DEFAULT '',
+    "query"               text             NOT NULL DEFAULT '',
+    "tls_mode"            varchar(255)     NOT NULL DEFAULT 'disable',
+    "timeout_seconds"     integer          NOT NULL DEFAULT 10,
+    "lag_warning_seconds" double precision NOT NULL DEFAULT 0,
+    "lag_problem_seconds" double precision NOT NULL DEFAULT 0,
+    "created_at"          timestamp        NOT NULL DEFAULT NOW(),
+    "updated_at"          timestamp        NOT NULL DEFAULT NOW()
+);
+
+-- Create trigger
+CREATE TRIGGER set_timestamp
+    BEFORE UPDATE
+    ON database_checks
+    FOR EACH ROW
+EXECUTE PROCEDURE trigger_set_timestamp();
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS grpc_checks;
+DELETE FROM host_services WHERE service_id = 11;
+DELETE FROM public.services WHERE id = 11;
@@ -0,0 +1,32 @@
+-- Add the gRPC service, calls of the standard health service
+INSERT INTO public.services (id, service_name, active, icon, created_at, updated_at)
+VALUES (11, 'gRPC', 1, 'fa fa-plug', NOW(), NOW())
+ON CONFLICT (id) DO NOTHING;
+
+INSERT INTO host_services (host_id, service_id, active, scheduler_number, scheduler_unit, status, created_at, updated_at)
+SELECT h.id, 11, 0, 3, 'm', 'pending', NOW(), NOW()
+FROM hosts h
+WHERE NOT EXISTS (SELECT 1 FROM host_services hs WHERE hs.host_id = h.id AND hs.service_id = 11);
+
+-- Create table
+CREATE TABLE "grpc_checks"
+(
+    "host_service_id" integer      NOT NULL PRIMARY KEY REFERENCES host_services (id) ON DELETE CASCADE,
+    "address"         varchar(255) NOT NULL DEFAULT '',
+    "service_name"    varchar(255) NOT NULL DEFAULT '',
+    "tls_mode"        varchar(255) NOT NULL DEFAULT 'disable',
+    "server_name"     varchar(255) NOT NULL DEFAULT '',
+    "ca_cert"         text         NOT NULL DEFAULT '',
+    "client_cert"     text         NOT NULL DEFAULT '',
+    "client_key"      text         NOT NULL DEFAULT '',
+    "timeout_seconds" integer      NOT NULL DEFAULT 10,
+    "created_at"      timestamp    NOT NULL DEFAULT NOW(),
+    "updated_at"      timestamp    NOT NULL DEFAULT NOW()
+);
+
+-- Create trigger
+CREATE TRIGGER set_timestamp
+    BEFORE UPDATE
+    ON grpc_checks
+    FOR EACH ROW
+EXECUTE PROCEDURE trigger_set_timestamp();
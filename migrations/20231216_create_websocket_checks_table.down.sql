@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS websocket_checks;
+DELETE FROM host_services WHERE service_id = 12;
+DELETE FROM public.services WHERE id = 12;
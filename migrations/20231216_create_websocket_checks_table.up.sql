@@ -0,0 +1,30 @@
+-- Add the WebSocket service, a handshake and an optional message and reply
+INSERT INTO public.services (id, service_name, active, icon, created_at, updated_at)
+VALUES (12, 'WebSocket', 1, 'fa fa-comments', NOW(), NOW())
+ON CONFLICT (id) DO NOTHING;
+
+INSERT INTO host_services (host_id, service_id, active, scheduler_number, scheduler_unit, status, created_at, updated_at)
+SELECT h.id, 12, 0, 3, 'm', 'pending', NOW(), NOW()
+FROM hosts h
+WHERE NOT EXISTS (SELECT 1 FROM host_services hs WHERE hs.host_id = h.id AND hs.service_id = 12);
+
+-- Create table
+CREATE TABLE "websocket_checks"
+(
+    "host_service_id" integer      NOT NULL PRIMARY KEY REFERENCES host_services (id) ON DELETE CASCADE,
+    "url"             varchar(255) NOT NULL DEFAULT '',
+    "headers"         jsonb        NOT NULL DEFAULT '{}',
+    "subprotocol"     varchar(255) NOT NULL DEFAULT '',
+    "send"            text         NOT NULL DEFAULT '',
+    "expect"          text         NOT NULL DEFAULT '',
+    "timeout_seconds" integer      NOT NULL DEFAULT 10,
+    "created_at"      timestamp    NOT NULL DEFAULT NOW(),
+    "updated_at"      timestamp    NOT NULL DEFAULT NOW()
+);
+
+-- Create trigger
+CREATE TRIGGER set_timestamp
+    BEFORE UPDATE
+    ON websocket_checks
+    FOR EACH ROW
+EXECUTE PROCEDURE trigger_set_timestamp();
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS check_settings;
+DELETE FROM host_services WHERE service_id IN (13, 14, 15);
+DELETE FROM public.services WHERE id IN (13, 14, 15);
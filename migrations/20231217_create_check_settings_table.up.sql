@@ -0,0 +1,28 @@
+-- Add the network services: NTP, SNMP and generic UDP request/response checks
+INSERT INTO public.services (id, service_name, active, icon, created_at, updated_at)
+VALUES (13, 'NTP', 1, 'fa fa-clock-o', NOW(), NOW()),
+       (14, 'SNMP', 1, 'fa fa-sitemap', NOW(), NOW()),
+       (15, 'UDP', 1, 'fa fa-random', NOW(), NOW())
+ON CONFLICT (id) DO NOTHING;
+
+INSERT INTO host_services (host_id, service_id, active, scheduler_number, scheduler_unit, status, created_at, updated_at)
+SELECT h.id, s.id, 0, 3, 'm', 'pending', NOW(), NOW()
+FROM hosts h
+CROSS JOIN (VALUES (13), (14), (15)) AS s (id)
+WHERE NOT EXISTS (SELECT 1 FROM host_services hs WHERE hs.host_id = h.id AND hs.service_id = s.id);
+
+-- Create table
+CREATE TABLE "check_settings"
+(
+    "host_service_id" integer   NOT NULL PRIMARY KEY REFERENCES host_services (id) ON DELETE CASCADE,
+    "settings"        jsonb     NOT NULL DEFAULT '{}',
+    "created_at"      timestamp NOT NULL DEFAULT NOW(),
+    "updated_at"      timestamp NOT NULL DEFAULT NOW()
+);
+
+-- Create trigger
+CREATE TRIGGER set_timestamp
+    BEFORE UPDATE
+    ON check_settings
+    FOR EACH ROW
+EXECUTE PROCEDURE trigger_set_timestamp();